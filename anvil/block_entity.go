@@ -0,0 +1,86 @@
+package anvil
+
+import (
+	"fmt"
+	"github.com/mkorman9/go-minecraft-server/blocks"
+	"github.com/mkorman9/go-minecraft-server/chunk"
+	"github.com/mkorman9/go-minecraft-server/nbt"
+)
+
+// blockEntityHeader holds the fields of block entities which in the protocol are sent separately from the data.
+type blockEntityHeader struct {
+	ID string `nbt:"id"`
+	X  int32  `nbt:"x"`
+	Y  int32  `nbt:"y"`
+	Z  int32  `nbt:"z"`
+}
+
+var blockEntityHeaderFields = []string{"id", "x", "y", "z", "keepPacked"}
+
+// newBlockEntityData merges the block entity data with its id and absolute coordinates.
+func newBlockEntityData(chunkX, chunkZ int, blockEntity *chunk.BlockEntity) (nbt.RawMessage, error) {
+	id, ok := blocks.BlockEntityTypeName(blockEntity.Type)
+	if !ok {
+		return nbt.RawMessage{}, fmt.Errorf("unknown block entity type: %d", blockEntity.Type)
+	}
+
+	fields := make(map[string]any)
+	if blockEntity.Data.Type == nbt.TagCompound {
+		err := blockEntity.Data.Unmarshal(&fields)
+		if err != nil {
+			return nbt.RawMessage{}, err
+		}
+	}
+
+	fields["id"] = id
+	fields["x"] = int32(chunkX*chunk.ChunkSize + blockEntity.X())
+	fields["y"] = int32(blockEntity.Y)
+	fields["z"] = int32(chunkZ*chunk.ChunkSize + blockEntity.Z())
+	fields["keepPacked"] = int8(0)
+
+	return toRawMessage(fields)
+}
+
+// toBlockEntity splits the block entity stored in the Anvil format into its type, position and data.
+// It returns nil for block entities of unknown types.
+func toBlockEntity(data nbt.RawMessage) (*chunk.BlockEntity, error) {
+	var header blockEntityHeader
+	err := data.Unmarshal(&header)
+	if err != nil {
+		return nil, err
+	}
+
+	entityType, ok := blocks.BlockEntityTypeID(header.ID)
+	if !ok {
+		return nil, nil
+	}
+
+	fields := make(map[string]any)
+	err = data.Unmarshal(&fields)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range blockEntityHeaderFields {
+		delete(fields, field)
+	}
+
+	raw, err := toRawMessage(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return chunk.NewBlockEntity(int(header.X), int(header.Y), int(header.Z), entityType, raw), nil
+}
+
+func toRawMessage(value any) (nbt.RawMessage, error) {
+	var raw nbt.RawMessage
+
+	data, err := nbt.Marshal(value)
+	if err != nil {
+		return raw, err
+	}
+
+	err = nbt.Unmarshal(data, &raw)
+	return raw, err
+}
@@ -0,0 +1,94 @@
+package anvil
+
+import (
+	"github.com/mkorman9/go-minecraft-server/chunk"
+	"github.com/mkorman9/go-minecraft-server/nbt"
+	"path/filepath"
+	"testing"
+)
+
+type testSign struct {
+	Text1 string `nbt:"Text1"`
+	Color string `nbt:"Color"`
+}
+
+func TestSaveBlockEntities(t *testing.T) {
+	directory := t.TempDir()
+	storage := OpenStorage(filepath.Join(directory, "region"))
+	defer func() {
+		_ = storage.Close()
+	}()
+
+	signData, err := toRawMessage(&testSign{Text1: `{"text":"Hello"}`, Color: "black"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := chunk.NewChunk()
+	c.SetBlockEntity(chunk.NewBlockEntity(3, -10, 12, 7, signData))
+	c.SetBlockEntity(chunk.NewBlockEntity(0, 100, 0, 1, nbt.RawMessage{}))
+
+	err = storage.SaveChunks([]*ChunkData{NewChunkData(-2, 5, c, &testPalette{})})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chunkData, err := storage.ReadChunkData(-2, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunkData.BlockEntities) != 2 {
+		t.Fatalf("expected 2 block entities, got %d", len(chunkData.BlockEntities))
+	}
+
+	found := false
+	for _, data := range chunkData.BlockEntities {
+		var header blockEntityHeader
+		_ = data.Unmarshal(&header)
+		if header.ID == "minecraft:sign" {
+			found = true
+			if header.X != -29 || header.Y != -10 || header.Z != 92 {
+				t.Fatalf("unexpected sign position: %+v", header)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("sign was not saved")
+	}
+
+	loaded, err := storage.LoadChunk(-2, 5, &testPalette{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sign := loaded.GetBlockEntity(3, -10, 12)
+	if sign == nil || sign.Type != 7 {
+		t.Fatalf("sign was not loaded: %+v", sign)
+	}
+
+	var loadedSign map[string]any
+	err = sign.Data.Unmarshal(&loadedSign)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loadedSign) != 2 || loadedSign["Text1"] != `{"text":"Hello"}` || loadedSign["Color"] != "black" {
+		t.Fatalf("unexpected sign data: %v", loadedSign)
+	}
+
+	chest := loaded.GetBlockEntity(0, 100, 0)
+	if chest == nil || chest.Type != 1 || chest.Data.Type != nbt.TagCompound {
+		t.Fatalf("chest was not loaded: %+v", chest)
+	}
+}
+
+func TestUnknownBlockEntitiesAreDropped(t *testing.T) {
+	data, err := toRawMessage(&blockEntityHeader{ID: "minecraft:unknown", X: 1, Y: 2, Z: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blockEntity, err := toBlockEntity(data)
+	if err != nil || blockEntity != nil {
+		t.Fatalf("expected unknown block entity to be dropped, got %+v (%v)", blockEntity, err)
+	}
+}
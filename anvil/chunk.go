@@ -0,0 +1,299 @@
+package anvil
+
+import (
+	"errors"
+	"fmt"
+	"github.com/mkorman9/go-minecraft-server/chunk"
+	"github.com/mkorman9/go-minecraft-server/nbt"
+)
+
+// DataVersion of chunks written by Minecraft 1.19.
+const DataVersion = 3105
+
+// ErrUnknownPaletteEntry is returned when a chunk contains blocks or biomes unknown to the palette. Such chunks
+// can't be converted without losing the unknown entries.
+var ErrUnknownPaletteEntry = errors.New("unknown palette entry")
+
+// Palette translates names used in the Anvil format into protocol IDs and back.
+type Palette interface {
+	BlockStateID(name string, properties map[string]string) (int, bool)
+	BiomeID(name string) (int, bool)
+	BlockStateName(id int) (string, map[string]string, bool)
+	BiomeName(id int) (string, bool)
+}
+
+type ChunkData struct {
+	DataVersion   int32              `nbt:"DataVersion"`
+	XPos          int32              `nbt:"xPos"`
+	ZPos          int32              `nbt:"zPos"`
+	YPos          int32              `nbt:"yPos"`
+	Status        string             `nbt:"Status"`
+	LastUpdate    int64              `nbt:"LastUpdate"`
+	InhabitedTime int64              `nbt:"InhabitedTime"`
+	IsLightOn     bool               `nbt:"isLightOn"`
+	Sections      []SectionData      `nbt:"sections"`
+	BlockEntities []nbt.RawMessage   `nbt:"block_entities"`
+	Heightmaps    map[string][]int64 `nbt:"Heightmaps"`
+}
+
+type SectionData struct {
+	Y           int8            `nbt:"Y"`
+	BlockStates BlockStatesData `nbt:"block_states"`
+	Biomes      BiomesData      `nbt:"biomes"`
+	BlockLight  []byte          `nbt:"BlockLight,omitempty"`
+	SkyLight    []byte          `nbt:"SkyLight,omitempty"`
+}
+
+type BlockStatesData struct {
+	Palette []BlockStateData `nbt:"palette"`
+	Data    []int64          `nbt:"data,omitempty"`
+}
+
+type BlockStateData struct {
+	Name       string            `nbt:"Name"`
+	Properties map[string]string `nbt:"Properties,omitempty"`
+}
+
+type BiomesData struct {
+	Palette []string `nbt:"palette"`
+	Data    []int64  `nbt:"data,omitempty"`
+}
+
+// NewChunkData converts the protocol representation of a chunk into the Anvil format.
+// Blocks and biomes unknown to the palette are written as air and plains, invalid block entities are skipped.
+func NewChunkData(x, z int, c *chunk.Chunk, palette Palette) *ChunkData {
+	chunkData := &ChunkData{
+		DataVersion: DataVersion,
+		XPos:        int32(x),
+		ZPos:        int32(z),
+		YPos:        chunk.MinY / chunk.SectionHeight,
+		Status:      "full",
+		Sections:    make([]SectionData, len(c.Sections)),
+	}
+
+	for i := range c.Sections {
+		chunkData.Sections[i] = newSectionData(i+chunk.MinY/chunk.SectionHeight, &c.Sections[i], palette)
+	}
+
+	for _, blockEntity := range c.BlockEntities {
+		data, err := newBlockEntityData(x, z, blockEntity)
+		if err != nil {
+			continue
+		}
+
+		chunkData.BlockEntities = append(chunkData.BlockEntities, data)
+	}
+
+	if c.Heightmaps != nil {
+		chunkData.Heightmaps = map[string][]int64{
+			"MOTION_BLOCKING": c.Heightmaps.MotionBlocking,
+			"WORLD_SURFACE":   c.Heightmaps.WorldSurface,
+		}
+	}
+
+	return chunkData
+}
+
+func (cd *ChunkData) Encode() ([]byte, error) {
+	return nbt.Marshal(cd)
+}
+
+func DecodeChunkData(data []byte) (*ChunkData, error) {
+	var chunkData ChunkData
+
+	err := nbt.Unmarshal(data, &chunkData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &chunkData, nil
+}
+
+// ToChunk converts sections stored in the Anvil format into the protocol representation.
+// ErrUnknownPaletteEntry is returned if any block or biome is unknown to the palette,
+// block entities of unknown types are dropped.
+func (cd *ChunkData) ToChunk(palette Palette) (*chunk.Chunk, error) {
+	c := chunk.NewChunk()
+
+	for _, sectionData := range cd.Sections {
+		index := int(sectionData.Y) - chunk.MinY/chunk.SectionHeight
+		if index < 0 || index >= len(c.Sections) {
+			continue
+		}
+
+		section, err := sectionData.toSection(palette)
+		if err != nil {
+			return nil, fmt.Errorf("section %d: %w", sectionData.Y, err)
+		}
+
+		c.Sections[index] = *section
+	}
+
+	for _, data := range cd.BlockEntities {
+		blockEntity, err := toBlockEntity(data)
+		if err != nil {
+			return nil, fmt.Errorf("block entity: %v", err)
+		}
+
+		if blockEntity != nil {
+			c.SetBlockEntity(blockEntity)
+		}
+	}
+
+	return c, nil
+}
+
+func (sd *SectionData) toSection(palette Palette) (*chunk.Section, error) {
+	section := chunk.NewSection(chunk.AirState, chunk.DefaultBiome)
+
+	if len(sd.BlockStates.Palette) > 0 {
+		states := make([]int, len(sd.BlockStates.Palette))
+		for i, blockState := range sd.BlockStates.Palette {
+			id, ok := palette.BlockStateID(blockState.Name, blockState.Properties)
+			if !ok {
+				return nil, fmt.Errorf("%w: block state %s %v", ErrUnknownPaletteEntry, blockState.Name, blockState.Properties)
+			}
+
+			states[i] = id
+		}
+
+		values, err := unpackPalettedData(states, sd.BlockStates.Data, chunk.BlocksPerSection, 4)
+		if err != nil {
+			return nil, err
+		}
+
+		section.BlockStates = chunk.NewPalettedContainerFrom(chunk.BlockStatesKind, values)
+		section.RecalculateBlockCount()
+	}
+
+	if len(sd.Biomes.Palette) > 0 {
+		biomes := make([]int, len(sd.Biomes.Palette))
+		for i, name := range sd.Biomes.Palette {
+			id, ok := palette.BiomeID(name)
+			if !ok {
+				return nil, fmt.Errorf("%w: biome %s", ErrUnknownPaletteEntry, name)
+			}
+
+			biomes[i] = id
+		}
+
+		values, err := unpackPalettedData(biomes, sd.Biomes.Data, chunk.BiomesPerSection, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		section.Biomes = chunk.NewPalettedContainerFrom(chunk.BiomesKind, values)
+	}
+
+	return &section, nil
+}
+
+func newSectionData(y int, section *chunk.Section, palette Palette) SectionData {
+	blockStates, blockData := packPalettedData(section.BlockStates.Values(), 4)
+	biomes, biomeData := packPalettedData(section.Biomes.Values(), 1)
+
+	sectionData := SectionData{
+		Y: int8(y),
+		BlockStates: BlockStatesData{
+			Palette: make([]BlockStateData, len(blockStates)),
+			Data:    blockData,
+		},
+		Biomes: BiomesData{
+			Palette: make([]string, len(biomes)),
+			Data:    biomeData,
+		},
+	}
+
+	for i, id := range blockStates {
+		name, properties, ok := palette.BlockStateName(id)
+		if !ok {
+			name = "minecraft:air"
+		}
+
+		sectionData.BlockStates.Palette[i] = BlockStateData{Name: name, Properties: properties}
+	}
+
+	for i, id := range biomes {
+		name, ok := palette.BiomeName(id)
+		if !ok {
+			name = "minecraft:plains"
+		}
+
+		sectionData.Biomes.Palette[i] = name
+	}
+
+	return sectionData
+}
+
+// packPalettedData builds a local palette for given values and packs indices into longs.
+// Data is omitted when the palette consists of a single value.
+func packPalettedData(values []int, minBits int) ([]int, []int64) {
+	var palette []int
+	indices := make(map[int]int)
+
+	for _, value := range values {
+		if _, ok := indices[value]; !ok {
+			indices[value] = len(palette)
+			palette = append(palette, value)
+		}
+	}
+
+	if len(palette) == 1 {
+		return palette, nil
+	}
+
+	bits := bitsForPalette(len(palette), minBits)
+	entriesPerLong := 64 / bits
+	data := make([]int64, (len(values)+entriesPerLong-1)/entriesPerLong)
+
+	for i, value := range values {
+		data[i/entriesPerLong] |= int64(uint64(indices[value]) << ((i % entriesPerLong) * bits))
+	}
+
+	return palette, data
+}
+
+// unpackPalettedData decodes palette indices packed into longs (entries never span two longs)
+// and maps them through the palette.
+func unpackPalettedData(palette []int, data []int64, size int, minBits int) ([]int, error) {
+	values := make([]int, size)
+
+	if len(palette) == 1 {
+		for i := range values {
+			values[i] = palette[0]
+		}
+		return values, nil
+	}
+
+	bits := bitsForPalette(len(palette), minBits)
+	entriesPerLong := 64 / bits
+	mask := uint64(1)<<bits - 1
+
+	if len(data) < (size+entriesPerLong-1)/entriesPerLong {
+		return nil, fmt.Errorf("too short data array: %d longs for %d bits per entry", len(data), bits)
+	}
+
+	for i := range values {
+		index := int((uint64(data[i/entriesPerLong]) >> ((i % entriesPerLong) * bits)) & mask)
+		if index >= len(palette) {
+			return nil, fmt.Errorf("palette index %d out of bounds", index)
+		}
+
+		values[i] = palette[index]
+	}
+
+	return values, nil
+}
+
+func bitsForPalette(paletteLength int, minBits int) int {
+	bits := 0
+	for 1<<bits < paletteLength {
+		bits++
+	}
+
+	if bits < minBits {
+		bits = minBits
+	}
+
+	return bits
+}
@@ -0,0 +1,124 @@
+package anvil
+
+import (
+	"errors"
+	"github.com/mkorman9/go-minecraft-server/blocks"
+	"github.com/mkorman9/go-minecraft-server/chunk"
+	"reflect"
+	"testing"
+)
+
+// testdata/r.0.0.mca holds chunks (0, 0) and (8, 8) of a world saved by the vanilla 1.18.1 server
+// (DataVersion 2865), copied byte for byte from the test data of github.com/Tnze/go-mc (MIT).
+const vanillaRegionDirectory = "testdata"
+
+// registryPalette resolves real block states with the bundled blocks report. Biomes get IDs in order
+// of appearance.
+type registryPalette struct {
+	blocks       *blocks.Registry
+	biomes       map[string]int
+	unknownBlock string
+	unknownBiome string
+}
+
+func newRegistryPalette(t *testing.T) *registryPalette {
+	t.Helper()
+
+	registry, err := blocks.LoadRegistry("../data/1_19/blocks.json", "../data/1_19/block_metadata.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &registryPalette{blocks: registry, biomes: make(map[string]int)}
+}
+
+func (rp *registryPalette) BlockStateID(name string, properties map[string]string) (int, bool) {
+	if name == rp.unknownBlock {
+		return 0, false
+	}
+	return rp.blocks.StateID(name, properties)
+}
+
+func (rp *registryPalette) BiomeID(name string) (int, bool) {
+	if name == rp.unknownBiome {
+		return 0, false
+	}
+	if _, ok := rp.biomes[name]; !ok {
+		rp.biomes[name] = len(rp.biomes)
+	}
+	return rp.biomes[name], true
+}
+
+func (rp *registryPalette) BlockStateName(id int) (string, map[string]string, bool) {
+	state, ok := rp.blocks.State(id)
+	if !ok {
+		return "", nil, false
+	}
+	return state.Block.Name, state.Properties, true
+}
+
+func (rp *registryPalette) BiomeName(id int) (string, bool) {
+	for name, value := range rp.biomes {
+		if value == id {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func TestLoadVanillaChunk(t *testing.T) {
+	palette := newRegistryPalette(t)
+	storage := OpenStorage(vanillaRegionDirectory)
+	defer storage.Close()
+
+	c, err := storage.LoadChunk(0, 0, palette)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bedrock, _ := palette.blocks.StateID("minecraft:bedrock", nil)
+	if state := c.GetBlock(0, chunk.MinY, 0); state != bedrock {
+		t.Fatalf("expected bedrock at the bottom of the world, got %d", state)
+	}
+	if c.Sections[0].BlockCount == 0 || c.Sections[len(c.Sections)-1].BlockCount != 0 {
+		t.Fatalf("expected blocks at the bottom of the chunk and air at the top")
+	}
+
+	// chunks written back and read again keep every block
+	saved, err := NewChunkData(0, 0, c, palette).ToChunk(palette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range c.Sections {
+		if !reflect.DeepEqual(saved.Sections[i].BlockStates.Values(), c.Sections[i].BlockStates.Values()) {
+			t.Fatalf("section %d changed after saving", i)
+		}
+	}
+}
+
+func TestUnknownPaletteEntries(t *testing.T) {
+	storage := OpenStorage(vanillaRegionDirectory)
+	defer storage.Close()
+
+	cases := []struct {
+		name         string
+		unknownBlock string
+		unknownBiome string
+	}{
+		{name: "unknown block", unknownBlock: "minecraft:deepslate"},
+		{name: "unknown biome", unknownBiome: "minecraft:forest"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			palette := newRegistryPalette(t)
+			palette.unknownBlock = c.unknownBlock
+			palette.unknownBiome = c.unknownBiome
+
+			loaded, err := storage.LoadChunk(0, 0, palette)
+			if !errors.Is(err, ErrUnknownPaletteEntry) {
+				t.Fatalf("expected the chunk to be refused, got %v (%v)", loaded, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package anvil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	SectorSize         = 4096
+	ChunksPerRegion    = 32
+	regionHeaderLength = 2 * SectorSize
+)
+
+const (
+	CompressionGzip         = 1
+	CompressionZlib         = 2
+	CompressionNone         = 3
+	compressionExternalFlag = 128
+)
+
+var ErrChunkNotFound = errors.New("chunk not present in region")
+
+type Region struct {
+	path       string
+	file       *os.File
+	offsets    [ChunksPerRegion * ChunksPerRegion]uint32
+	timestamps [ChunksPerRegion * ChunksPerRegion]uint32
+	m          sync.Mutex
+}
+
+func RegionFileName(regionX, regionZ int) string {
+	return fmt.Sprintf("r.%d.%d.mca", regionX, regionZ)
+}
+
+func OpenRegion(path string) (*Region, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	region := &Region{
+		path: path,
+		file: file,
+	}
+
+	err = region.readHeader()
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to read header of %s: %v", path, err)
+	}
+
+	return region, nil
+}
+
+func (r *Region) Close() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return r.file.Close()
+}
+
+func (r *Region) HasChunk(x, z int) bool {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return r.offsets[chunkIndex(x, z)] != 0
+}
+
+// ReadChunk returns decompressed NBT data of the chunk at given coordinates (only the lowest 5 bits are used).
+func (r *Region) ReadChunk(x, z int) ([]byte, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	location := r.offsets[chunkIndex(x, z)]
+	if location == 0 {
+		return nil, ErrChunkNotFound
+	}
+
+	offset := int64(location>>8) * SectorSize
+	sectors := int(location & 0xff)
+
+	header := make([]byte, 5)
+	_, err := r.file.ReadAt(header, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	length := int(binary.BigEndian.Uint32(header[:4]))
+	compression := header[4]
+
+	if length < 1 || length+4 > sectors*SectorSize && compression&compressionExternalFlag == 0 {
+		return nil, fmt.Errorf("invalid length of chunk (%d, %d): %d", x, z, length)
+	}
+
+	var compressed []byte
+	if compression&compressionExternalFlag != 0 {
+		compressed, err = os.ReadFile(r.externalChunkPath(x, z))
+		if err != nil {
+			return nil, err
+		}
+
+		compression &^= compressionExternalFlag
+	} else {
+		compressed = make([]byte, length-1)
+		_, err = r.file.ReadAt(compressed, offset+5)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return decompress(compression, compressed)
+}
+
+// readRawChunk returns the stored chunk payload (compression type followed by compressed data) without decoding it.
+func (r *Region) readRawChunk(index int) ([]byte, error) {
+	location := r.offsets[index]
+	if location == 0 {
+		return nil, nil
+	}
+
+	offset := int64(location>>8) * SectorSize
+
+	header := make([]byte, 4)
+	_, err := r.file.ReadAt(header, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	length := int(binary.BigEndian.Uint32(header))
+	if length < 1 || length+4 > int(location&0xff)*SectorSize {
+		return nil, fmt.Errorf("invalid length of chunk %d: %d", index, length)
+	}
+
+	payload := make([]byte, length)
+	_, err = r.file.ReadAt(payload, offset+4)
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+func (r *Region) readHeader() error {
+	header := make([]byte, regionHeaderLength)
+
+	_, err := io.ReadFull(r.file, header)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			// empty region file, created but never written
+			return nil
+		}
+
+		return err
+	}
+
+	for i := range r.offsets {
+		r.offsets[i] = binary.BigEndian.Uint32(header[i*4:])
+		r.timestamps[i] = binary.BigEndian.Uint32(header[SectorSize+i*4:])
+	}
+
+	return nil
+}
+
+func (r *Region) externalChunkPath(x, z int) string {
+	var regionX, regionZ int
+	_, _ = fmt.Sscanf(filepath.Base(r.path), "r.%d.%d.mca", &regionX, &regionZ)
+
+	return filepath.Join(
+		filepath.Dir(r.path),
+		fmt.Sprintf("c.%d.%d.mcc", regionX*ChunksPerRegion+x&31, regionZ*ChunksPerRegion+z&31),
+	)
+}
+
+func decompress(compression byte, data []byte) ([]byte, error) {
+	var reader io.Reader
+
+	switch compression {
+	case CompressionGzip:
+		gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		reader = gzipReader
+	case CompressionZlib:
+		zlibReader, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		reader = zlibReader
+	case CompressionNone:
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unsupported compression type: %d", compression)
+	}
+
+	return io.ReadAll(reader)
+}
+
+func chunkIndex(x, z int) int {
+	return (x & 31) + (z&31)*ChunksPerRegion
+}
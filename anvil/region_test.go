@@ -0,0 +1,237 @@
+package anvil
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"github.com/mkorman9/go-minecraft-server/chunk"
+	"github.com/mkorman9/go-minecraft-server/nbt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var (
+	testBlockStates = map[string]int{"minecraft:air": 0, "minecraft:stone": 1, "minecraft:dirt": 10}
+	testBiomes      = map[string]int{"minecraft:plains": 1, "minecraft:desert": 5}
+)
+
+type testPalette struct {
+}
+
+func (tp *testPalette) BlockStateID(name string, _ map[string]string) (int, bool) {
+	id, ok := testBlockStates[name]
+	return id, ok
+}
+
+func (tp *testPalette) BiomeID(name string) (int, bool) {
+	id, ok := testBiomes[name]
+	return id, ok
+}
+
+func (tp *testPalette) BlockStateName(id int) (string, map[string]string, bool) {
+	for name, value := range testBlockStates {
+		if value == id {
+			return name, nil, true
+		}
+	}
+	return "", nil, false
+}
+
+func (tp *testPalette) BiomeName(id int) (string, bool) {
+	for name, value := range testBiomes {
+		if value == id {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func TestLoadChunk(t *testing.T) {
+	// bottom layer of stone, a single dirt block above it
+	blockData := make([]int64, 256)
+	for i := 0; i < 16; i++ {
+		blockData[i] = 0x1111111111111111
+	}
+	blockData[16] = 2
+
+	chunkData := ChunkData{
+		DataVersion: 3105,
+		XPos:        33,
+		ZPos:        -2,
+		YPos:        -4,
+		Status:      "full",
+		Sections: []SectionData{
+			{
+				Y: -4,
+				BlockStates: BlockStatesData{
+					Palette: []BlockStateData{{Name: "minecraft:air"}, {Name: "minecraft:stone"}, {Name: "minecraft:dirt"}},
+					Data:    blockData,
+				},
+				Biomes: BiomesData{
+					Palette: []string{"minecraft:plains", "minecraft:desert"},
+					Data:    []int64{1},
+				},
+			},
+		},
+	}
+
+	encoded, err := nbt.Marshal(&chunkData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	directory := t.TempDir()
+	writeTestRegion(t, filepath.Join(directory, RegionFileName(1, -1)), 33, -2, encoded)
+
+	storage := OpenStorage(directory)
+	defer func() {
+		_ = storage.Close()
+	}()
+
+	c, err := storage.LoadChunk(33, -2, &testPalette{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.GetBlock(5, chunk.MinY, 5) != 1 || c.GetBlock(0, chunk.MinY+1, 0) != 10 || c.GetBlock(1, chunk.MinY+1, 0) != 0 {
+		t.Fatalf("unexpected blocks in loaded chunk")
+	}
+	if c.Sections[0].BlockCount != 257 {
+		t.Fatalf("expected block count 257, got %d", c.Sections[0].BlockCount)
+	}
+	if c.GetBiome(0, chunk.MinY, 0) != 5 || c.GetBiome(4, chunk.MinY, 0) != 1 {
+		t.Fatalf("unexpected biomes in loaded chunk")
+	}
+	if c.GetBlock(0, 100, 0) != chunk.AirState {
+		t.Fatalf("expected missing sections to be air")
+	}
+
+	_, err = storage.LoadChunk(34, -2, &testPalette{})
+	if !errors.Is(err, ErrChunkNotFound) {
+		t.Fatalf("expected ErrChunkNotFound, got %v", err)
+	}
+
+	_, err = storage.LoadChunk(0, 0, &testPalette{})
+	if !errors.Is(err, ErrChunkNotFound) {
+		t.Fatalf("expected ErrChunkNotFound for missing region, got %v", err)
+	}
+}
+
+func TestSaveChunks(t *testing.T) {
+	directory := t.TempDir()
+	storage := OpenStorage(filepath.Join(directory, "region"))
+	defer func() {
+		_ = storage.Close()
+	}()
+
+	first := chunk.NewChunk()
+	first.SetBlock(1, 10, 2, 1)
+	first.SetBiome(0, 0, 0, 5)
+	first.Heightmaps = chunk.ComputeHeightmaps(first, func(state int) bool {
+		return state != chunk.AirState
+	})
+
+	second := chunk.NewChunk()
+	second.SetBlock(15, chunk.MinY, 15, 10)
+
+	err := storage.SaveChunks([]*ChunkData{
+		NewChunkData(-1, 31, first, &testPalette{}),
+		NewChunkData(-32, 0, second, &testPalette{}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// saving again to the same region must keep the other chunk intact
+	second.SetBlock(0, 0, 0, 1)
+	err = storage.SaveChunks([]*ChunkData{NewChunkData(-32, 0, second, &testPalette{})})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loadedFirst, err := storage.LoadChunk(-1, 31, &testPalette{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loadedFirst.GetBlock(1, 10, 2) != 1 || loadedFirst.GetBiome(0, 0, 0) != 5 || loadedFirst.Sections[4].BlockCount != 1 {
+		t.Fatalf("first chunk was not saved correctly")
+	}
+
+	loadedSecond, err := storage.LoadChunk(-32, 0, &testPalette{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loadedSecond.GetBlock(15, chunk.MinY, 15) != 10 || loadedSecond.GetBlock(0, 0, 0) != 1 {
+		t.Fatalf("second chunk was not saved correctly")
+	}
+
+	chunkData, err := storage.ReadChunkData(-1, 31)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chunkData.DataVersion != DataVersion || chunkData.XPos != -1 || chunkData.ZPos != 31 || chunkData.Status != "full" {
+		t.Fatalf("unexpected chunk metadata: %+v", chunkData)
+	}
+	if chunk.HeightAt(chunkData.Heightmaps["WORLD_SURFACE"], 1, 2) != 11-chunk.MinY {
+		t.Fatalf("heightmaps were not saved correctly: %v", chunkData.Heightmaps)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(directory, "region", "*"))
+	if len(files) != 1 || filepath.Base(files[0]) != "r.-1.0.mca" {
+		t.Fatalf("unexpected files in region directory: %v", files)
+	}
+}
+
+func TestMissingRegion(t *testing.T) {
+	storage := OpenStorage(filepath.Join(t.TempDir(), "region"))
+	defer func() {
+		_ = storage.Close()
+	}()
+
+	for i := 0; i < 2; i++ {
+		_, err := storage.LoadChunk(40, -3, &testPalette{})
+		if !errors.Is(err, ErrChunkNotFound) {
+			t.Fatalf("expected ErrChunkNotFound, got %v", err)
+		}
+	}
+	if _, ok := storage.missing[regionPosition{X: 1, Z: -1}]; !ok {
+		t.Fatalf("missing region was not remembered")
+	}
+
+	c := chunk.NewChunk()
+	c.SetBlock(0, 0, 0, 1)
+	err := storage.SaveChunks([]*ChunkData{NewChunkData(40, -3, c, &testPalette{})})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := storage.LoadChunk(40, -3, &testPalette{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.GetBlock(0, 0, 0) != 1 {
+		t.Fatalf("saved chunk was not loaded correctly")
+	}
+}
+
+func writeTestRegion(t *testing.T, path string, x, z int, data []byte) {
+	var compressed bytes.Buffer
+	zlibWriter := zlib.NewWriter(&compressed)
+	_, _ = zlibWriter.Write(data)
+	_ = zlibWriter.Close()
+
+	sectors := (compressed.Len() + 5 + SectorSize - 1) / SectorSize
+	file := make([]byte, regionHeaderLength+sectors*SectorSize)
+
+	binary.BigEndian.PutUint32(file[chunkIndex(x, z)*4:], uint32(2<<8|sectors))
+	binary.BigEndian.PutUint32(file[regionHeaderLength:], uint32(compressed.Len()+1))
+	file[regionHeaderLength+4] = CompressionZlib
+	copy(file[regionHeaderLength+5:], compressed.Bytes())
+
+	err := os.WriteFile(path, file, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,197 @@
+package anvil
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const maxSectorsPerChunk = 255
+
+type regionEntry struct {
+	payload   []byte
+	timestamp uint32
+}
+
+// SaveChunks writes given chunks into their region files. Every touched region file is rewritten
+// into a temporary file first and then renamed over the old one, so a crash in the middle of the save
+// never leaves a region with a corrupted header.
+func (s *Storage) SaveChunks(chunks []*ChunkData) error {
+	byRegion := make(map[regionPosition][]*ChunkData)
+	for _, chunkData := range chunks {
+		position := regionPosition{X: int(chunkData.XPos) >> 5, Z: int(chunkData.ZPos) >> 5}
+		byRegion[position] = append(byRegion[position], chunkData)
+	}
+
+	err := os.MkdirAll(s.directory, 0755)
+	if err != nil {
+		return err
+	}
+
+	for position, regionChunks := range byRegion {
+		err = s.saveRegion(position, regionChunks)
+		if err != nil {
+			return fmt.Errorf("failed to save region %s: %v", RegionFileName(position.X, position.Z), err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) saveRegion(position regionPosition, chunks []*ChunkData) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	path := filepath.Join(s.directory, RegionFileName(position.X, position.Z))
+	var entries [ChunksPerRegion * ChunksPerRegion]regionEntry
+
+	region, ok := s.regions[position]
+	if !ok {
+		var err error
+		region, err = OpenRegion(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if region != nil {
+		err := region.readEntries(&entries)
+		if err != nil {
+			return err
+		}
+	}
+
+	now := uint32(time.Now().Unix())
+	for _, chunkData := range chunks {
+		data, err := chunkData.Encode()
+		if err != nil {
+			return err
+		}
+
+		payload, err := compressPayload(data)
+		if err != nil {
+			return err
+		}
+
+		index := chunkIndex(int(chunkData.XPos), int(chunkData.ZPos))
+		if len(payload)+4 > maxSectorsPerChunk*SectorSize {
+			payload, err = s.writeExternalChunk(int(chunkData.XPos), int(chunkData.ZPos), payload)
+			if err != nil {
+				return err
+			}
+		}
+
+		entries[index] = regionEntry{payload: payload, timestamp: now}
+	}
+
+	err := writeRegionFile(path, &entries)
+	if err != nil {
+		return err
+	}
+
+	if region != nil {
+		_ = region.Close()
+	}
+	delete(s.regions, position)
+	delete(s.missing, position)
+
+	return nil
+}
+
+func (r *Region) readEntries(entries *[ChunksPerRegion * ChunksPerRegion]regionEntry) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	for i := range entries {
+		payload, err := r.readRawChunk(i)
+		if err != nil {
+			return err
+		}
+
+		entries[i] = regionEntry{payload: payload, timestamp: r.timestamps[i]}
+	}
+
+	return nil
+}
+
+func (s *Storage) writeExternalChunk(x, z int, payload []byte) ([]byte, error) {
+	path := filepath.Join(s.directory, fmt.Sprintf("c.%d.%d.mcc", x, z))
+
+	err := writeFileAtomically(path, payload[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte{payload[0] | compressionExternalFlag}, nil
+}
+
+func writeRegionFile(path string, entries *[ChunksPerRegion * ChunksPerRegion]regionEntry) error {
+	var body bytes.Buffer
+	header := make([]byte, regionHeaderLength)
+	sector := regionHeaderLength / SectorSize
+
+	for i, entry := range entries {
+		if entry.payload == nil {
+			continue
+		}
+
+		length := make([]byte, 4)
+		binary.BigEndian.PutUint32(length, uint32(len(entry.payload)))
+		body.Write(length)
+		body.Write(entry.payload)
+
+		sectors := (len(entry.payload) + 4 + SectorSize - 1) / SectorSize
+		body.Write(make([]byte, sectors*SectorSize-len(entry.payload)-4))
+
+		binary.BigEndian.PutUint32(header[i*4:], uint32(sector<<8|sectors))
+		binary.BigEndian.PutUint32(header[SectorSize+i*4:], entry.timestamp)
+		sector += sectors
+	}
+
+	return writeFileAtomically(path, append(header, body.Bytes()...))
+}
+
+func writeFileAtomically(path string, data []byte) error {
+	temporaryPath := path + ".tmp"
+
+	file, err := os.Create(temporaryPath)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(temporaryPath)
+		return err
+	}
+
+	return os.Rename(temporaryPath, path)
+}
+
+func compressPayload(data []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteByte(CompressionZlib)
+
+	zlibWriter := zlib.NewWriter(&buffer)
+	_, err := zlibWriter.Write(data)
+	if err != nil {
+		return nil, err
+	}
+
+	err = zlibWriter.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,102 @@
+package anvil
+
+import (
+	"github.com/mkorman9/go-minecraft-server/chunk"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+type regionPosition struct {
+	X int
+	Z int
+}
+
+// Storage gives access to chunks kept in region files of a single directory (usually world/region).
+// Regions without a file are remembered until they are saved, so chunks of an ungenerated area
+// don't have to look for the file again.
+type Storage struct {
+	directory string
+	regions   map[regionPosition]*Region
+	missing   map[regionPosition]struct{}
+	m         sync.Mutex
+}
+
+func OpenStorage(directory string) *Storage {
+	return &Storage{
+		directory: directory,
+		regions:   make(map[regionPosition]*Region),
+		missing:   make(map[regionPosition]struct{}),
+	}
+}
+
+func (s *Storage) Directory() string {
+	return s.directory
+}
+
+func (s *Storage) ReadChunkData(x, z int) (*ChunkData, error) {
+	region, err := s.region(x, z)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := region.ReadChunk(x, z)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeChunkData(data)
+}
+
+// LoadChunk reads the chunk at given chunk coordinates. ErrChunkNotFound is returned if it has never been saved.
+func (s *Storage) LoadChunk(x, z int, palette Palette) (*chunk.Chunk, error) {
+	chunkData, err := s.ReadChunkData(x, z)
+	if err != nil {
+		return nil, err
+	}
+
+	return chunkData.ToChunk(palette)
+}
+
+func (s *Storage) Close() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	var lastErr error
+	for position, region := range s.regions {
+		err := region.Close()
+		if err != nil {
+			lastErr = err
+		}
+
+		delete(s.regions, position)
+	}
+
+	return lastErr
+}
+
+func (s *Storage) region(x, z int) (*Region, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	position := regionPosition{X: x >> 5, Z: z >> 5}
+	if region, ok := s.regions[position]; ok {
+		return region, nil
+	}
+	if _, ok := s.missing[position]; ok {
+		return nil, ErrChunkNotFound
+	}
+
+	region, err := OpenRegion(filepath.Join(s.directory, RegionFileName(position.X, position.Z)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.missing[position] = struct{}{}
+			return nil, ErrChunkNotFound
+		}
+
+		return nil, err
+	}
+
+	s.regions[position] = region
+	return region, nil
+}
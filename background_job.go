@@ -17,6 +17,10 @@ func NewBackgroundJob(world *World) *BackgroundJob {
 
 func (bj *BackgroundJob) Start() {
 	bj.startKeepAliveDaemon()
+
+	if bj.world.Settings().AutosaveInterval > 0 {
+		bj.startAutosaveDaemon()
+	}
 }
 
 func (bj *BackgroundJob) startKeepAliveDaemon() {
@@ -37,3 +41,27 @@ func (bj *BackgroundJob) startKeepAliveDaemon() {
 		}
 	}()
 }
+
+func (bj *BackgroundJob) startAutosaveDaemon() {
+	autosaveInterval := bj.world.Settings().AutosaveInterval * time.Second
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("panic in autosave job: %v, restarting\n", r)
+				bj.startAutosaveDaemon()
+			}
+		}()
+
+		for {
+			time.Sleep(autosaveInterval)
+
+			err := bj.world.Save()
+			if err != nil {
+				log.Printf("Failed to save world: %v\n", err)
+			}
+
+			bj.world.UnloadUnusedChunks()
+		}
+	}()
+}
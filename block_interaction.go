@@ -0,0 +1,200 @@
+package main
+
+import (
+	"github.com/mkorman9/go-minecraft-server/blocks"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxBlockReach is the maximal distance between player's eyes and the center of a block they interact with.
+	MaxBlockReach = 6.0
+
+	PlayerEyeHeight = 1.62
+	PlayerWidth     = 0.6
+	PlayerHeight    = 1.8
+
+	// DiggingTimeTolerance is the part of the expected digging time after which the server accepts
+	// a block as broken, to make up for latency and client-side speed bonuses the server doesn't track.
+	DiggingTimeTolerance = 0.7
+
+	TickDuration = 50 * time.Millisecond
+)
+
+// diggingProgress is a block which a player in survival mode has started to break.
+type diggingProgress struct {
+	x       int
+	y       int
+	z       int
+	started time.Time
+	ticks   int
+}
+
+func (dp *diggingProgress) isAt(x, y, z int) bool {
+	return dp.x == x && dp.y == y && dp.z == z
+}
+
+// isComplete tells whether enough time has passed since the digging started to break the block.
+func (dp *diggingProgress) isComplete() bool {
+	expected := time.Duration(dp.ticks) * TickDuration
+	return time.Since(dp.started) >= time.Duration(float64(expected)*DiggingTimeTolerance)
+}
+
+// BlockPlacement describes a click with an item on a face of the block at X, Y, Z.
+type BlockPlacement struct {
+	Hand    Hand
+	X       int
+	Y       int
+	Z       int
+	Face    BlockFace
+	CursorX float32
+	CursorY float32
+	CursorZ float32
+}
+
+// Adjacent returns position of the block touching the clicked face.
+func (bp *BlockPlacement) Adjacent() (int, int, int) {
+	switch bp.Face {
+	case BlockFaceBottom:
+		return bp.X, bp.Y - 1, bp.Z
+	case BlockFaceTop:
+		return bp.X, bp.Y + 1, bp.Z
+	case BlockFaceNorth:
+		return bp.X, bp.Y, bp.Z - 1
+	case BlockFaceSouth:
+		return bp.X, bp.Y, bp.Z + 1
+	case BlockFaceWest:
+		return bp.X - 1, bp.Y, bp.Z
+	case BlockFaceEast:
+		return bp.X + 1, bp.Y, bp.Z
+	default:
+		return bp.X, bp.Y, bp.Z
+	}
+}
+
+// placementProperties orients the placed block using clicked face and player's rotation, the same way
+// vanilla blocks with these properties do (logs, furnaces, dispensers, slabs, stairs, doors).
+func placementProperties(block *blocks.Block, placement *BlockPlacement, yaw, pitch float32, replaced *blocks.State) map[string]string {
+	properties := make(map[string]string)
+
+	setProperty(block, properties, "axis", faceAxis(placement.Face))
+
+	if hasValue(block.Properties["facing"], "up") {
+		setProperty(block, properties, "facing", oppositeDirection(lookDirection(yaw, pitch)))
+	} else if facesLikePlayer(block) {
+		setProperty(block, properties, "facing", horizontalDirection(yaw))
+	} else {
+		setProperty(block, properties, "facing", oppositeDirection(horizontalDirection(yaw)))
+	}
+
+	half := "bottom"
+	if placement.Face == BlockFaceBottom || (placement.Face != BlockFaceTop && placement.CursorY > 0.5) {
+		half = "top"
+	}
+	setProperty(block, properties, "half", half)
+	setProperty(block, properties, "type", half)
+
+	waterlogged := replaced != nil && replaced.Block.Name == "minecraft:water" && replaced.Properties["level"] == "0"
+	setProperty(block, properties, "waterlogged", strconv.FormatBool(waterlogged))
+	setProperty(block, properties, "persistent", "true")
+
+	return properties
+}
+
+// facesLikePlayer tells whether the block is placed facing the same direction as the player (like stairs),
+// rather than facing the player (like furnaces).
+func facesLikePlayer(block *blocks.Block) bool {
+	return strings.HasSuffix(block.Name, "_stairs") ||
+		strings.HasSuffix(block.Name, "_door") ||
+		strings.HasSuffix(block.Name, "_fence_gate")
+}
+
+func faceAxis(face BlockFace) string {
+	switch face {
+	case BlockFaceBottom, BlockFaceTop:
+		return "y"
+	case BlockFaceNorth, BlockFaceSouth:
+		return "z"
+	default:
+		return "x"
+	}
+}
+
+// horizontalDirection returns the cardinal direction player with given yaw is facing.
+func horizontalDirection(yaw float32) string {
+	switch int(math.Floor(float64(yaw)/90+0.5)) & 3 {
+	case 0:
+		return "south"
+	case 1:
+		return "west"
+	case 2:
+		return "north"
+	default:
+		return "east"
+	}
+}
+
+// lookDirection returns the direction closest to the one player is looking at, including up and down.
+func lookDirection(yaw, pitch float32) string {
+	yawRadians := float64(yaw) * math.Pi / 180
+	pitchRadians := float64(pitch) * math.Pi / 180
+
+	x := -math.Sin(yawRadians) * math.Cos(pitchRadians)
+	y := -math.Sin(pitchRadians)
+	z := math.Cos(yawRadians) * math.Cos(pitchRadians)
+
+	if math.Abs(y) > math.Abs(x) && math.Abs(y) > math.Abs(z) {
+		if y > 0 {
+			return "up"
+		}
+		return "down"
+	}
+
+	return horizontalDirection(yaw)
+}
+
+func oppositeDirection(direction string) string {
+	switch direction {
+	case "north":
+		return "south"
+	case "south":
+		return "north"
+	case "west":
+		return "east"
+	case "east":
+		return "west"
+	case "up":
+		return "down"
+	default:
+		return "up"
+	}
+}
+
+// setProperty sets the property only if the block has it and accepts given value,
+// otherwise the property keeps its default value.
+func setProperty(block *blocks.Block, properties map[string]string, name, value string) {
+	if hasValue(block.Properties[name], value) {
+		properties[name] = value
+	}
+}
+
+func hasValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// blockDistanceSquared returns squared distance between the point and the center of the block.
+func blockDistanceSquared(x, y, z float64, blockX, blockY, blockZ int) float64 {
+	dx := x - (float64(blockX) + 0.5)
+	dy := y - (float64(blockY) + 0.5)
+	dz := z - (float64(blockZ) + 0.5)
+
+	return dx*dx + dy*dy + dz*dz
+}
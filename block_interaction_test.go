@@ -0,0 +1,258 @@
+package main
+
+import (
+	"github.com/mkorman9/go-minecraft-server/chunk"
+	"github.com/mkorman9/go-minecraft-server/packets"
+	"io"
+	"testing"
+)
+
+// newTestWorld opens a new flat world in a temporary directory, using the bundled data.
+func newTestWorld(t *testing.T) *World {
+	t.Helper()
+	return openTestWorld(t, t.TempDir())
+}
+
+func openTestWorld(t *testing.T, directory string) *World {
+	t.Helper()
+
+	data, err := LoadData()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings := DefaultSettings()
+	settings.WorldDirectory = directory
+
+	world, err := newWorld(settings, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return world
+}
+
+// newTestWorldPlayer returns a player standing at the spawn point of the world.
+func newTestWorldPlayer(t *testing.T, world *World, gameMode GameMode) *Player {
+	t.Helper()
+
+	player := NewPlayer(world, "")
+	player.GameMode = gameMode
+	player.AssignPacketHandler(&PlayerPacketHandler{packetWriter: packets.NewPacketWriter(io.Discard)})
+
+	spawn := world.Data().SpawnPosition
+	player.setPosition(float64(spawn.X)+0.5, float64(spawn.Y), float64(spawn.Z)+0.5)
+
+	return player
+}
+
+func holdItem(t *testing.T, player *Player, name string) {
+	t.Helper()
+
+	if name == "" {
+		player.inventory.Set(InventorySlotHotbarFirst+player.heldItemSlot, nil)
+		return
+	}
+
+	held, ok := player.world.Data().Items.ByName(name)
+	if !ok {
+		t.Fatalf("unknown item: %s", name)
+	}
+
+	player.inventory.Set(InventorySlotHotbarFirst+player.heldItemSlot, item(held.ID, 1))
+}
+
+func testState(t *testing.T, world *World, value string) int {
+	t.Helper()
+
+	state, err := world.Data().Blocks.ParseState(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return state
+}
+
+func TestDig(t *testing.T) {
+	world := newTestWorld(t)
+
+	cases := []struct {
+		name          string
+		gameMode      GameMode
+		block         string
+		tool          string
+		expectedTicks int
+	}{
+		{name: "stone by hand", block: "stone", expectedTicks: 150},
+		{name: "stone with wooden pickaxe", block: "stone", tool: "minecraft:wooden_pickaxe", expectedTicks: 23},
+		{name: "stone with shovel", block: "stone", tool: "minecraft:diamond_shovel", expectedTicks: 150},
+		{name: "gold ore with stone pickaxe", block: "gold_ore", tool: "minecraft:stone_pickaxe", expectedTicks: 75},
+		{name: "gold ore with iron pickaxe", block: "gold_ore", tool: "minecraft:iron_pickaxe", expectedTicks: 15},
+		{name: "obsidian with diamond pickaxe", block: "obsidian", tool: "minecraft:diamond_pickaxe", expectedTicks: 188},
+		{name: "log with iron axe", block: "oak_log", tool: "minecraft:iron_axe", expectedTicks: 10},
+		{name: "dirt with wooden shovel", block: "dirt", tool: "minecraft:wooden_shovel", expectedTicks: 8},
+		{name: "leaves with golden hoe", block: "oak_leaves", tool: "minecraft:golden_hoe", expectedTicks: 0},
+		{name: "glass by hand", block: "glass", expectedTicks: 9},
+		{name: "torch by hand", block: "torch", expectedTicks: 0},
+		{name: "bedrock", block: "bedrock", tool: "minecraft:netherite_pickaxe", expectedTicks: -1},
+		{name: "creative with pickaxe", gameMode: GameModeCreative, block: "bedrock", tool: "minecraft:iron_pickaxe", expectedTicks: 0},
+		{name: "creative with sword", gameMode: GameModeCreative, block: "dirt", tool: "minecraft:iron_sword", expectedTicks: -1},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gameMode := c.gameMode
+			if gameMode == 0 {
+				gameMode = GameModeSurvival
+			}
+
+			player := newTestWorldPlayer(t, world, gameMode)
+			holdItem(t, player, c.tool)
+
+			spawn := world.Data().SpawnPosition
+			x, y, z := spawn.X+1, spawn.Y, spawn.Z
+			state := testState(t, world, c.block)
+			world.SetBlock(x, y, z, state)
+
+			started := player.dig(DiggingStatusStarted, x, y, z)
+
+			switch {
+			case c.expectedTicks < 0:
+				if started || world.GetBlock(x, y, z) != state {
+					t.Fatalf("expected %s not to be broken", c.block)
+				}
+			case c.expectedTicks == 0:
+				if !started || world.GetBlock(x, y, z) != chunk.AirState {
+					t.Fatalf("expected %s to be broken instantly", c.block)
+				}
+			default:
+				if !started || player.digging == nil || world.GetBlock(x, y, z) != state {
+					t.Fatalf("expected digging of %s to start", c.block)
+				}
+				if player.digging.ticks != c.expectedTicks {
+					t.Fatalf("expected %d ticks, got %d", c.expectedTicks, player.digging.ticks)
+				}
+			}
+		})
+	}
+}
+
+func TestPlaceBlock(t *testing.T) {
+	world := newTestWorld(t)
+	spawn := world.Data().SpawnPosition
+
+	// blocks are placed either on top of the ground or against the west face of a stone next to it
+	x, y, z := spawn.X+2, spawn.Y, spawn.Z
+	onGround := BlockPlacement{X: x, Y: y - 1, Z: z, Face: BlockFaceTop, CursorX: 0.5, CursorY: 1, CursorZ: 0.5}
+	upperHalf := BlockPlacement{X: x + 1, Y: y, Z: z, Face: BlockFaceWest, CursorX: 0, CursorY: 0.75, CursorZ: 0.5}
+
+	cases := []struct {
+		name      string
+		item      string
+		yaw       float32
+		placement BlockPlacement
+		expected  string
+	}{
+		{
+			name:      "stairs facing south",
+			item:      "minecraft:oak_stairs",
+			yaw:       0,
+			placement: onGround,
+			expected:  "minecraft:oak_stairs[facing=south,half=bottom,shape=straight,waterlogged=false]",
+		},
+		{
+			name:      "upside down stairs facing west",
+			item:      "minecraft:stone_brick_stairs",
+			yaw:       90,
+			placement: upperHalf,
+			expected:  "minecraft:stone_brick_stairs[facing=west,half=top,shape=straight,waterlogged=false]",
+		},
+		{
+			name:      "fence gate facing north",
+			item:      "minecraft:spruce_fence_gate",
+			yaw:       180,
+			placement: onGround,
+			expected:  "minecraft:spruce_fence_gate[facing=north,in_wall=false,open=false,powered=false]",
+		},
+		{
+			name:      "furnace facing the player",
+			item:      "minecraft:furnace",
+			yaw:       0,
+			placement: onGround,
+			expected:  "minecraft:furnace[facing=north,lit=false]",
+		},
+		{
+			name:      "log against a side",
+			item:      "minecraft:birch_log",
+			placement: upperHalf,
+			expected:  "minecraft:birch_log[axis=x]",
+		},
+		{
+			name:      "top slab",
+			item:      "minecraft:oak_slab",
+			placement: upperHalf,
+			expected:  "minecraft:oak_slab[type=top,waterlogged=false]",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			world.SetBlock(x, y, z, chunk.AirState)
+			world.SetBlock(x+1, y, z, testState(t, world, "stone"))
+
+			player := newTestWorldPlayer(t, world, GameModeSurvival)
+			player.Yaw = c.yaw
+			holdItem(t, player, c.item)
+
+			placement := c.placement
+			if !player.placeBlock(&placement) {
+				t.Fatalf("expected %s to be placed", c.item)
+			}
+
+			if state := world.GetBlock(x, y, z); state != testState(t, world, c.expected) {
+				placed, _ := world.Data().Blocks.State(state)
+				t.Fatalf("expected %s, got %s", c.expected, placed)
+			}
+			if player.HeldItem() != nil {
+				t.Fatalf("expected the placed item to be consumed")
+			}
+		})
+	}
+}
+
+func TestUseCraftingTable(t *testing.T) {
+	world := newTestWorld(t)
+	spawn := world.Data().SpawnPosition
+
+	x, y, z := spawn.X+1, spawn.Y, spawn.Z
+	table := testState(t, world, "crafting_table")
+	world.SetBlock(x, y, z, table)
+
+	player := newTestWorldPlayer(t, world, GameModeSurvival)
+	holdItem(t, player, "minecraft:stone")
+
+	mangroveLog, _ := world.Data().Items.ByName("minecraft:mangrove_log")
+	planks, _ := world.Data().Items.ByName("minecraft:mangrove_planks")
+	player.inventory.Set(InventorySlotMainFirst, item(mangroveLog.ID, 1))
+
+	// right click opens the crafting table instead of placing the held block against it
+	player.OnUseItemOn(&BlockPlacement{X: x, Y: y, Z: z, Face: BlockFaceTop, CursorX: 0.5, CursorY: 1, CursorZ: 0.5}, 1)
+
+	window := player.OpenWindow()
+	if window == nil || window.Type != MenuCrafting {
+		t.Fatalf("expected the crafting table to be opened, got %+v", window)
+	}
+	if world.GetBlock(x, y+1, z) != chunk.AirState || player.HeldItem() == nil {
+		t.Fatalf("expected no block to be placed")
+	}
+
+	// the log is moved from the main inventory (first slot after the grid) into the grid, and matches
+	// a recipe using the minecraft:mangrove_logs tag
+	for _, slot := range []int{CraftingTableSize, 1} {
+		window.Click(&ContainerClick{WindowID: window.ID, StateID: window.stateID, Slot: slot, Mode: ClickModePickup})
+	}
+
+	if result := window.get(0); !sameStack(result, item(planks.ID, 4)) {
+		t.Fatalf("expected 4 mangrove planks, got %+v", result)
+	}
+}
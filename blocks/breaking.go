@@ -0,0 +1,27 @@
+package blocks
+
+import "math"
+
+// BreakTicks returns the number of game ticks it takes to break the block using a tool of given speed
+// (1 for a bare hand). correctTool tells whether the tool is able to harvest blocks which require one.
+// 0 means that the block breaks instantly and -1 that it can't be broken at all.
+func (b *Block) BreakTicks(toolSpeed float64, correctTool bool) int {
+	if b.Hardness < 0 {
+		return -1
+	}
+	if b.Hardness == 0 {
+		return 0
+	}
+
+	divider := 30.0
+	if b.RequiresTool && !correctTool {
+		divider = 100.0
+	}
+
+	ticks := b.Hardness * divider / toolSpeed
+	if ticks < 1 {
+		return 0
+	}
+
+	return int(math.Ceil(ticks - 1e-9))
+}
@@ -0,0 +1,82 @@
+package blocks
+
+import "strings"
+
+// blockEntityTypes lists block entity types in the order of the 1.19 minecraft:block_entity_type registry,
+// so that the index of a type is its protocol ID.
+var blockEntityTypes = []string{
+	"minecraft:furnace",
+	"minecraft:chest",
+	"minecraft:trapped_chest",
+	"minecraft:ender_chest",
+	"minecraft:jukebox",
+	"minecraft:dispenser",
+	"minecraft:dropper",
+	"minecraft:sign",
+	"minecraft:mob_spawner",
+	"minecraft:piston",
+	"minecraft:brewing_stand",
+	"minecraft:enchanting_table",
+	"minecraft:end_portal",
+	"minecraft:beacon",
+	"minecraft:skull",
+	"minecraft:daylight_detector",
+	"minecraft:hopper",
+	"minecraft:comparator",
+	"minecraft:banner",
+	"minecraft:structure_block",
+	"minecraft:end_gateway",
+	"minecraft:command_block",
+	"minecraft:shulker_box",
+	"minecraft:bed",
+	"minecraft:conduit",
+	"minecraft:barrel",
+	"minecraft:smoker",
+	"minecraft:blast_furnace",
+	"minecraft:lectern",
+	"minecraft:bell",
+	"minecraft:jigsaw",
+	"minecraft:campfire",
+	"minecraft:beehive",
+	"minecraft:sculk_sensor",
+	"minecraft:sculk_catalyst",
+	"minecraft:sculk_shrieker",
+}
+
+// BlockEntityTypeID returns protocol ID of the block entity type with given name.
+func BlockEntityTypeID(name string) (int, bool) {
+	for id, typeName := range blockEntityTypes {
+		if typeName == name {
+			return id, true
+		}
+	}
+
+	return 0, false
+}
+
+// BlockEntityTypeName returns name of the block entity type with given protocol ID.
+func BlockEntityTypeName(id int) (string, bool) {
+	if id < 0 || id >= len(blockEntityTypes) {
+		return "", false
+	}
+
+	return blockEntityTypes[id], true
+}
+
+// blockEntityOf returns the type of block entity kept by the block. Only signs, chests, banners and skulls
+// are supported.
+func blockEntityOf(blockName string) (string, bool) {
+	switch {
+	case blockName == "minecraft:chest" || blockName == "minecraft:trapped_chest":
+		return blockName, true
+	case strings.HasSuffix(blockName, "_sign"):
+		return "minecraft:sign", true
+	case strings.HasSuffix(blockName, "_banner"):
+		return "minecraft:banner", true
+	case strings.HasSuffix(blockName, "_skull"),
+		strings.HasSuffix(blockName, "_head") && blockName != "minecraft:piston_head":
+		return "minecraft:skull", true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,386 @@
+package blocks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Block describes a block type and all of its states.
+type Block struct {
+	Name         string
+	Properties   map[string][]string
+	States       []*State
+	DefaultState *State
+	Solid        bool
+	Fluid        bool
+	Opacity      int
+	Emission     int
+	Hardness     float64
+	RequiresTool bool
+	// Tool is the type of tool which mines the block faster (e.g. pickaxe), empty if there is none.
+	Tool string
+	// ToolTier is the minimal tier of the tool needed to harvest the block, if it requires one.
+	ToolTier    int
+	Replaceable bool
+	// BlockEntity is the type of block entity kept by the block, empty if there is none.
+	BlockEntity string
+}
+
+// State is a single block state, identified in the protocol by its global ID.
+type State struct {
+	ID         int
+	Block      *Block
+	Properties map[string]string
+	Default    bool
+}
+
+// Registry maps block names and properties to global state IDs and back.
+type Registry struct {
+	blocks   map[string]*Block
+	states   map[int]*State
+	stateIDs map[string]int
+}
+
+type reportBlock struct {
+	Properties map[string][]string `json:"properties"`
+	States     []reportState       `json:"states"`
+}
+
+type reportState struct {
+	ID         int               `json:"id"`
+	Default    bool              `json:"default"`
+	Properties map[string]string `json:"properties"`
+}
+
+type blockMetadata struct {
+	Solid        *bool    `json:"solid"`
+	Fluid        bool     `json:"fluid"`
+	Opacity      *int     `json:"opacity"`
+	Emission     int      `json:"emission"`
+	Hardness     *float64 `json:"hardness"`
+	RequiresTool bool     `json:"requiresTool"`
+	Tool         string   `json:"tool"`
+	ToolTier     string   `json:"toolTier"`
+	Replaceable  bool     `json:"replaceable"`
+}
+
+// toolTiers are tiers of tools needed to harvest blocks, named after the needs_*_tool block tags.
+var toolTiers = map[string]int{
+	"":        0,
+	"stone":   1,
+	"iron":    2,
+	"diamond": 3,
+}
+
+// DefaultHardness is the hardness of blocks without metadata (the same as stone).
+const DefaultHardness = 1.5
+
+// DefaultTool is the tool which mines blocks without metadata faster.
+const DefaultTool = "pickaxe"
+
+// StateCount is the number of block states in the 1.19 global palette.
+const StateCount = 21448
+
+// LoadRegistry reads blocks report generated by the vanilla server (--reports) and block metadata
+// (solidity, light and mining properties, which are missing from the report). Blocks without metadata are solid
+// and opaque, and break like stone. The report has to cover the whole global palette, as states missing from it
+// couldn't be loaded from worlds or sent to clients.
+func LoadRegistry(reportPath, metadataPath string) (*Registry, error) {
+	var report map[string]reportBlock
+	err := readJSON(reportPath, &report)
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata map[string]blockMetadata
+	err = readJSON(metadataPath, &metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	registry, err := newRegistry(report, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	err = registry.checkComplete(StateCount)
+	if err != nil {
+		return nil, fmt.Errorf("incomplete blocks report %s: %v", reportPath, err)
+	}
+
+	return registry, nil
+}
+
+func newRegistry(report map[string]reportBlock, metadata map[string]blockMetadata) (*Registry, error) {
+	registry := &Registry{
+		blocks:   make(map[string]*Block),
+		states:   make(map[int]*State),
+		stateIDs: make(map[string]int),
+	}
+
+	for name, blockReport := range report {
+		block := &Block{
+			Name:       name,
+			Properties: blockReport.Properties,
+			Solid:      true,
+			Opacity:    15,
+			Hardness:   DefaultHardness,
+			Tool:       DefaultTool,
+		}
+
+		block.BlockEntity, _ = blockEntityOf(name)
+
+		if blockMetadata, ok := metadata[name]; ok {
+			if blockMetadata.Solid != nil {
+				block.Solid = *blockMetadata.Solid
+			}
+			if blockMetadata.Opacity != nil {
+				block.Opacity = *blockMetadata.Opacity
+			}
+			if blockMetadata.Hardness != nil {
+				block.Hardness = *blockMetadata.Hardness
+			}
+			block.Fluid = blockMetadata.Fluid
+			block.Emission = blockMetadata.Emission
+			block.RequiresTool = blockMetadata.RequiresTool
+			block.Tool = blockMetadata.Tool
+
+			toolTier, ok := toolTiers[blockMetadata.ToolTier]
+			if !ok {
+				return nil, fmt.Errorf("block %s has unknown tool tier: %s", name, blockMetadata.ToolTier)
+			}
+			block.ToolTier = toolTier
+			block.Replaceable = blockMetadata.Replaceable
+		}
+
+		for _, stateReport := range blockReport.States {
+			if _, ok := registry.states[stateReport.ID]; ok {
+				return nil, fmt.Errorf("duplicated block state ID: %d", stateReport.ID)
+			}
+
+			state := &State{
+				ID:         stateReport.ID,
+				Block:      block,
+				Properties: stateReport.Properties,
+				Default:    stateReport.Default,
+			}
+
+			block.States = append(block.States, state)
+			if state.Default {
+				block.DefaultState = state
+			}
+
+			registry.states[state.ID] = state
+			registry.stateIDs[state.String()] = state.ID
+		}
+
+		if block.DefaultState == nil {
+			return nil, fmt.Errorf("block %s has no default state", name)
+		}
+
+		registry.blocks[name] = block
+	}
+
+	for name := range metadata {
+		if _, ok := registry.blocks[name]; !ok {
+			return nil, fmt.Errorf("metadata of unknown block: %s", name)
+		}
+	}
+
+	return registry, nil
+}
+
+// checkComplete makes sure that the registry contains all states with IDs from 0 to count-1.
+func (r *Registry) checkComplete(count int) error {
+	if len(r.states) != count {
+		return fmt.Errorf("expected %d block states, got %d", count, len(r.states))
+	}
+
+	for id := 0; id < count; id++ {
+		if _, ok := r.states[id]; !ok {
+			return fmt.Errorf("missing block state ID: %d", id)
+		}
+	}
+
+	return nil
+}
+
+func (r *Registry) Block(name string) (*Block, bool) {
+	block, ok := r.blocks[name]
+	return block, ok
+}
+
+func (r *Registry) State(id int) (*State, bool) {
+	state, ok := r.states[id]
+	return state, ok
+}
+
+func (r *Registry) Len() int {
+	return len(r.states)
+}
+
+// StateID returns ID of the state of given block with given properties. Properties which are not specified
+// take their default values.
+func (r *Registry) StateID(name string, properties map[string]string) (int, bool) {
+	block, ok := r.blocks[name]
+	if !ok {
+		return 0, false
+	}
+
+	if len(properties) == 0 {
+		return block.DefaultState.ID, true
+	}
+
+	merged := make(map[string]string, len(block.Properties))
+	for key, value := range block.DefaultState.Properties {
+		merged[key] = value
+	}
+	for key, value := range properties {
+		if _, ok := merged[key]; !ok {
+			return 0, false
+		}
+		merged[key] = value
+	}
+
+	id, ok := r.stateIDs[formatState(name, merged)]
+	return id, ok
+}
+
+// ParseState resolves states written like minecraft:oak_stairs[facing=north,half=top].
+// Namespace defaults to minecraft.
+func (r *Registry) ParseState(value string) (int, error) {
+	name := value
+	var properties map[string]string
+
+	if start := strings.IndexByte(value, '['); start != -1 {
+		if !strings.HasSuffix(value, "]") {
+			return 0, fmt.Errorf("invalid block state: %s", value)
+		}
+
+		name = value[:start]
+		properties = make(map[string]string)
+
+		for _, property := range strings.Split(value[start+1:len(value)-1], ",") {
+			if property == "" {
+				continue
+			}
+
+			key, propertyValue, ok := strings.Cut(property, "=")
+			if !ok {
+				return 0, fmt.Errorf("invalid block state property: %s", property)
+			}
+
+			properties[strings.TrimSpace(key)] = strings.TrimSpace(propertyValue)
+		}
+	}
+
+	if !strings.Contains(name, ":") {
+		name = "minecraft:" + name
+	}
+
+	id, ok := r.StateID(name, properties)
+	if !ok {
+		return 0, fmt.Errorf("unknown block state: %s", value)
+	}
+
+	return id, nil
+}
+
+// IsSolid tells whether the state is a solid block. Unknown states are treated as solid.
+func (r *Registry) IsSolid(id int) bool {
+	if state, ok := r.states[id]; ok {
+		return state.Block.Solid
+	}
+	return true
+}
+
+// IsMotionBlocking tells whether the state blocks movement or contains a fluid.
+func (r *Registry) IsMotionBlocking(id int) bool {
+	if state, ok := r.states[id]; ok {
+		return state.Block.Solid || state.Block.Fluid
+	}
+	return true
+}
+
+// Opacity returns how much light is absorbed by the state. Unknown states are treated as opaque.
+func (r *Registry) Opacity(id int) int {
+	if state, ok := r.states[id]; ok {
+		return state.Block.Opacity
+	}
+	return 15
+}
+
+// Emission returns the level of light emitted by the state.
+func (r *Registry) Emission(id int) int {
+	if state, ok := r.states[id]; ok {
+		return state.Block.Emission
+	}
+	return 0
+}
+
+// IsReplaceable tells whether a block can be placed in place of the state without breaking it first.
+func (r *Registry) IsReplaceable(id int) bool {
+	if state, ok := r.states[id]; ok {
+		return state.Block.Replaceable
+	}
+	return false
+}
+
+// BlockEntityType returns protocol ID of the type of block entity kept by the state.
+func (r *Registry) BlockEntityType(id int) (int, bool) {
+	state, ok := r.states[id]
+	if !ok || state.Block.BlockEntity == "" {
+		return 0, false
+	}
+
+	return BlockEntityTypeID(state.Block.BlockEntity)
+}
+
+// String formats the state the same way ParseState accepts it, with properties sorted by name.
+func (s *State) String() string {
+	return formatState(s.Block.Name, s.Properties)
+}
+
+func formatState(name string, properties map[string]string) string {
+	if len(properties) == 0 {
+		return name
+	}
+
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var builder strings.Builder
+	builder.WriteString(name)
+	builder.WriteByte('[')
+	for i, key := range keys {
+		if i > 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteString(key)
+		builder.WriteByte('=')
+		builder.WriteString(properties[key])
+	}
+	builder.WriteByte(']')
+
+	return builder.String()
+}
+
+func readJSON(path string, value any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(data, value)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,230 @@
+package blocks
+
+import (
+	"github.com/mkorman9/go-minecraft-server/chunk"
+	"testing"
+)
+
+func loadTestRegistry(t *testing.T) *Registry {
+	t.Helper()
+
+	registry, err := LoadRegistry("../data/1_19/blocks.json", "../data/1_19/block_metadata.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return registry
+}
+
+func TestStateID(t *testing.T) {
+	registry := loadTestRegistry(t)
+
+	cases := []struct {
+		name       string
+		properties map[string]string
+		expected   int
+	}{
+		{"minecraft:air", nil, 0},
+		{"minecraft:stone", nil, 1},
+		{"minecraft:grass_block", nil, 9},
+		{"minecraft:grass_block", map[string]string{"snowy": "true"}, 8},
+		{"minecraft:water", nil, 75},
+		{"minecraft:water", map[string]string{"level": "15"}, 90},
+		{"minecraft:oak_log", nil, 118},
+		{"minecraft:oak_log", map[string]string{"axis": "z"}, 119},
+		{"minecraft:mangrove_propagule", map[string]string{"age": "4", "hanging": "true"}, 67},
+		{"minecraft:oak_stairs", nil, 2219},
+		{"minecraft:chest", nil, 2289},
+		{"minecraft:crafting_table", nil, 3611},
+		{"minecraft:void_air", nil, chunk.VoidAirState},
+		{"minecraft:cave_air", nil, chunk.CaveAirState},
+		{"minecraft:reinforced_deepslate", nil, StateCount - 1},
+	}
+
+	for _, c := range cases {
+		id, ok := registry.StateID(c.name, c.properties)
+		if !ok || id != c.expected {
+			t.Fatalf("%s %v: expected %d, got %d (%v)", c.name, c.properties, c.expected, id, ok)
+		}
+	}
+
+	if _, ok := registry.StateID("minecraft:oak_log", map[string]string{"facing": "north"}); ok {
+		t.Fatalf("expected unknown property to be rejected")
+	}
+	if _, ok := registry.StateID("minecraft:unknown", nil); ok {
+		t.Fatalf("expected unknown block to be rejected")
+	}
+}
+
+func TestCompleteRegistry(t *testing.T) {
+	if registry := loadTestRegistry(t); registry.Len() != StateCount {
+		t.Fatalf("expected %d states, got %d", StateCount, registry.Len())
+	}
+
+	registry, err := newRegistry(map[string]reportBlock{
+		"minecraft:air":   {States: []reportState{{ID: 0, Default: true}}},
+		"minecraft:stone": {States: []reportState{{ID: 2, Default: true}}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := registry.checkComplete(2); err == nil {
+		t.Fatalf("expected a gap in state IDs to be reported")
+	}
+	if err := registry.checkComplete(3); err == nil {
+		t.Fatalf("expected missing states to be reported")
+	}
+}
+
+func TestParseState(t *testing.T) {
+	registry := loadTestRegistry(t)
+
+	id, err := registry.ParseState("minecraft:dispenser[triggered=true,facing=up]")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, ok := registry.State(id)
+	if !ok || state.String() != "minecraft:dispenser[facing=up,triggered=true]" {
+		t.Fatalf("unexpected state: %v", state)
+	}
+
+	id, err = registry.ParseState("oak_leaves")
+	if err != nil || id != 233 {
+		t.Fatalf("expected default oak leaves (233), got %d (%v)", id, err)
+	}
+
+	for _, invalid := range []string{"minecraft:stone[", "minecraft:oak_log[axis]", "minecraft:oak_log[axis=w]"} {
+		if _, err := registry.ParseState(invalid); err == nil {
+			t.Fatalf("expected %s to be rejected", invalid)
+		}
+	}
+}
+
+func TestStatesRoundTrip(t *testing.T) {
+	registry := loadTestRegistry(t)
+
+	for id := 0; id < registry.Len(); id++ {
+		state, ok := registry.State(id)
+		if !ok {
+			t.Fatalf("missing state %d", id)
+		}
+
+		parsed, err := registry.ParseState(state.String())
+		if err != nil || parsed != id {
+			t.Fatalf("state %s parsed as %d (%v)", state, parsed, err)
+		}
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	registry := loadTestRegistry(t)
+
+	if registry.IsSolid(0) || registry.Opacity(0) != 0 || registry.IsMotionBlocking(0) {
+		t.Fatalf("unexpected air metadata")
+	}
+	if !registry.IsSolid(1) || registry.Opacity(1) != 15 || registry.Emission(1) != 0 {
+		t.Fatalf("unexpected stone metadata")
+	}
+	if registry.IsSolid(80) || !registry.IsMotionBlocking(80) || registry.Opacity(80) != 1 {
+		t.Fatalf("unexpected water metadata")
+	}
+	if registry.Emission(95) != 15 {
+		t.Fatalf("expected lava to emit light")
+	}
+}
+
+func TestBreakTicks(t *testing.T) {
+	registry := loadTestRegistry(t)
+
+	cases := []struct {
+		name        string
+		toolSpeed   float64
+		correctTool bool
+		ticks       int
+	}{
+		{"minecraft:dirt", 1, false, 15},
+		{"minecraft:stone", 1, false, 150},
+		{"minecraft:stone", 8, true, 6},
+		{"minecraft:cobblestone", 1, false, 200},
+		{"minecraft:oak_sapling", 1, false, 0},
+		{"minecraft:oak_leaves", 9, false, 0},
+		{"minecraft:bedrock", 9, true, -1},
+	}
+
+	for _, c := range cases {
+		block, ok := registry.Block(c.name)
+		if !ok {
+			t.Fatalf("missing block %s", c.name)
+		}
+
+		if ticks := block.BreakTicks(c.toolSpeed, c.correctTool); ticks != c.ticks {
+			t.Fatalf("%s with speed %v: expected %d ticks, got %d", c.name, c.toolSpeed, c.ticks, ticks)
+		}
+	}
+
+	goldOre, _ := registry.Block("minecraft:gold_ore")
+	if goldOre.Tool != "pickaxe" || goldOre.ToolTier != 2 {
+		t.Fatalf("unexpected tool of gold ore: %s, tier %d", goldOre.Tool, goldOre.ToolTier)
+	}
+
+	if !registry.IsReplaceable(0) || !registry.IsReplaceable(80) || registry.IsReplaceable(1) {
+		t.Fatalf("unexpected replaceable blocks")
+	}
+}
+
+func TestBlockEntityTypes(t *testing.T) {
+	registry, err := newRegistry(map[string]reportBlock{
+		"minecraft:oak_sign":        {States: []reportState{{ID: 0, Default: true}}},
+		"minecraft:chest":           {States: []reportState{{ID: 1, Default: true}}},
+		"minecraft:red_wall_banner": {States: []reportState{{ID: 2, Default: true}}},
+		"minecraft:zombie_head":     {States: []reportState{{ID: 3, Default: true}}},
+		"minecraft:piston_head":     {States: []reportState{{ID: 4, Default: true}}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[int]int{0: 7, 1: 1, 2: 18, 3: 14}
+	for state, entityType := range expected {
+		if id, ok := registry.BlockEntityType(state); !ok || id != entityType {
+			t.Fatalf("state %d: expected block entity type %d, got %d", state, entityType, id)
+		}
+	}
+
+	if _, ok := registry.BlockEntityType(4); ok {
+		t.Fatalf("piston head must not keep a block entity")
+	}
+
+	if name, ok := BlockEntityTypeName(14); !ok || name != "minecraft:skull" {
+		t.Fatalf("unexpected name of block entity type 14: %s", name)
+	}
+}
+
+func TestBundledBlockEntityTypes(t *testing.T) {
+	registry := loadTestRegistry(t)
+
+	cases := []struct {
+		state      int
+		entityType int
+		ok         bool
+	}{
+		{2289, 1, true},  // chest
+		{7240, 2, true},  // trapped_chest
+		{3637, 7, true},  // oak_sign
+		{4049, 7, true},  // birch_wall_sign
+		{8638, 18, true}, // white_banner
+		{8950, 18, true}, // red_wall_banner
+		{7107, 14, true}, // skeleton_skull
+		{7183, 14, true}, // player_wall_head
+		{1616, 0, false}, // piston_head
+		{1, 0, false},    // stone
+	}
+
+	for _, c := range cases {
+		if id, ok := registry.BlockEntityType(c.state); ok != c.ok || id != c.entityType {
+			t.Fatalf("state %d: expected block entity type %d (%v), got %d (%v)", c.state, c.entityType, c.ok, id, ok)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package main
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 type ChatMessage struct {
 	Text            string         `json:"text"`
@@ -63,3 +66,35 @@ func (cm *ChatMessage) Encode() string {
 	encoded, _ := json.Marshal(cm)
 	return string(encoded)
 }
+
+// MaxChatMessageLength is the maximal number of characters in a chat message sent by a player.
+const MaxChatMessageLength = 256
+
+// PlayerChatMessage is a chat message sent by a player. The signature is made by the client over the content,
+// timestamp and salt, and is empty if the client didn't sign the message.
+type PlayerChatMessage struct {
+	Sender    *Player
+	Content   string
+	Timestamp time.Time
+	Salt      int64
+	Signature []byte
+}
+
+// SignedContent returns the content as signed by the client, a text component without any formatting.
+func (pcm *PlayerChatMessage) SignedContent() *ChatMessage {
+	return &ChatMessage{Text: pcm.Content}
+}
+
+// ChatHandler decides how a chat message is shown to players. It returns the content to show in place of the message,
+// or false to cancel it.
+type ChatHandler func(message *PlayerChatMessage) (*ChatMessage, bool)
+
+// isAllowedInChat rejects control characters and the section sign, which is used by formatting codes.
+func isAllowedInChat(message string) bool {
+	for _, c := range message {
+		if c < ' ' || c == 0x7f || c == '§' {
+			return false
+		}
+	}
+	return true
+}
@@ -2,8 +2,26 @@ package chunk
 
 const (
 	ChunkSize        = 16
-	ChunkHeight      = 256
+	ChunkHeight      = 384
+	MinY             = -64
 	SectionHeight    = 16
+	SectionsCount    = ChunkHeight / SectionHeight
 	BlocksPerSection = 4096
 	BiomesPerSection = 64
 )
+
+const (
+	AirState     = 0
+	VoidAirState = 10546
+	CaveAirState = 10547
+	DefaultBiome = 1
+)
+
+const (
+	blockStatesMinIndirectBits = 4
+	blockStatesMaxIndirectBits = 8
+	blockStatesDirectBits      = 15
+	biomesMinIndirectBits      = 1
+	biomesMaxIndirectBits      = 3
+	biomesDirectBits           = 6
+)
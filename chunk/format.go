@@ -7,35 +7,56 @@ import (
 )
 
 type Chunk struct {
-	Sections []Section
+	Sections      []Section
+	Heightmaps    *Heightmap
+	Light         *Light
+	BlockEntities map[int]*BlockEntity
 }
 
 type Section struct {
 	BlockCount  int16
-	BlockStates []PalettedContainer
-	Biomes      []PalettedContainer
+	BlockStates *PalettedContainer
+	Biomes      *PalettedContainer
 }
 
-type PalettedContainer struct {
-	BitsPerEntry        byte
-	PaletteSingleValued *PaletteSingleValued
-	PaletteIndirect     *PaletteIndirect
-	Data                []int64
+// BlockEntity holds additional data of a block, like text of a sign or items of a chest.
+// Data is an NBT compound without the id and coordinates of the block entity.
+type BlockEntity struct {
+	PackedXZ byte
+	Y        int16
+	Type     int
+	Data     nbt.RawMessage
 }
 
-type PaletteSingleValued struct {
-	Value int
+// NewBlockEntity creates a block entity at given chunk-local x and z (0-15) and absolute y.
+// Empty data is replaced with an empty compound.
+func NewBlockEntity(x, y, z int, entityType int, data nbt.RawMessage) *BlockEntity {
+	if data.Type == nbt.TagEnd {
+		data = nbt.RawMessage{Type: nbt.TagCompound, Data: []byte{nbt.TagEnd}}
+	}
+
+	return &BlockEntity{
+		PackedXZ: byte((x&15)<<4 | (z & 15)),
+		Y:        int16(y),
+		Type:     entityType,
+		Data:     data,
+	}
 }
 
-type PaletteIndirect struct {
-	Palette []int
+// X returns chunk-local x of the block entity.
+func (be *BlockEntity) X() int {
+	return int(be.PackedXZ >> 4)
 }
 
-type BlockEntity struct {
-	PackedXZ byte
-	Y        int16
-	Type     int
-	Data     nbt.RawMessage
+// Z returns chunk-local z of the block entity.
+func (be *BlockEntity) Z() int {
+	return int(be.PackedXZ & 15)
+}
+
+func (be *BlockEntity) Clone() *BlockEntity {
+	clone := *be
+	clone.Data.Data = append([]byte(nil), be.Data.Data...)
+	return &clone
 }
 
 type Heightmap struct {
@@ -43,23 +64,17 @@ type Heightmap struct {
 	WorldSurface   []int64 `nbt:"WORLD_SURFACE"`
 }
 
-func GenerateExampleHeightmap() *Heightmap {
+func (h *Heightmap) Clone() *Heightmap {
 	return &Heightmap{
-		MotionBlocking: make([]int64, 256),
-		WorldSurface:   make([]int64, 256),
+		MotionBlocking: append([]int64(nil), h.MotionBlocking...),
+		WorldSurface:   append([]int64(nil), h.WorldSurface...),
 	}
 }
 
-func GenerateExampleChunk() *Chunk {
-	sectionsCount := ChunkHeight / SectionHeight
-	sections := make([]Section, sectionsCount)
-
-	for i := 0; i < sectionsCount; i++ {
-		if i <= sectionsCount/2 {
-			sections[i] = getSolidSection()
-		} else {
-			sections[i] = getAirSection()
-		}
+func NewChunk() *Chunk {
+	sections := make([]Section, SectionsCount)
+	for i := range sections {
+		sections[i] = NewSection(AirState, DefaultBiome)
 	}
 
 	return &Chunk{
@@ -67,132 +82,258 @@ func GenerateExampleChunk() *Chunk {
 	}
 }
 
-func (c *Chunk) WriteTo(writer io.Writer) (int64, error) {
-	sectionsCount := ChunkHeight / SectionHeight
-	for section := 0; section < sectionsCount; section++ {
-		err := types.WriteInt16(writer, c.Sections[section].BlockCount)
-		if err != nil {
-			return 0, err
-		}
+func NewSection(blockState int, biome int) Section {
+	blockCount := int16(0)
+	if !IsAir(blockState) {
+		blockCount = BlocksPerSection
+	}
 
-		for i := 0; i < BlocksPerSection; i++ {
-			bitsPerEntry := c.Sections[section].BlockStates[i].BitsPerEntry
+	return Section{
+		BlockCount:  blockCount,
+		BlockStates: NewPalettedContainer(BlockStatesKind, blockState),
+		Biomes:      NewPalettedContainer(BiomesKind, biome),
+	}
+}
 
-			err := types.WriteByte(writer, bitsPerEntry)
-			if err != nil {
-				return 0, err
-			}
+func (c *Chunk) Clone() *Chunk {
+	clone := &Chunk{
+		Sections: make([]Section, len(c.Sections)),
+	}
 
-			switch {
-			case bitsPerEntry == 0:
-				err := types.WriteVarInt(writer, c.Sections[section].BlockStates[i].PaletteSingleValued.Value)
-				if err != nil {
-					return 0, err
-				}
-			default:
-				// TODO
-			}
+	for i, section := range c.Sections {
+		clone.Sections[i] = Section{
+			BlockCount:  section.BlockCount,
+			BlockStates: section.BlockStates.Clone(),
+			Biomes:      section.Biomes.Clone(),
+		}
+	}
 
-			err = types.WriteVarInt(writer, len(c.Sections[section].BlockStates[i].Data))
-			if err != nil {
-				return 0, err
-			}
+	if c.Heightmaps != nil {
+		clone.Heightmaps = c.Heightmaps.Clone()
+	}
 
-			for dataElement := 0; dataElement < len(c.Sections[section].BlockStates[i].Data); dataElement++ {
-				err = types.WriteInt64(writer, c.Sections[section].BlockStates[i].Data[dataElement])
-				if err != nil {
-					return 0, err
-				}
-			}
+	if c.Light != nil {
+		clone.Light = c.Light.Clone()
+	}
+
+	for key, blockEntity := range c.BlockEntities {
+		if clone.BlockEntities == nil {
+			clone.BlockEntities = make(map[int]*BlockEntity, len(c.BlockEntities))
 		}
+		clone.BlockEntities[key] = blockEntity.Clone()
+	}
 
-		for i := 0; i < BiomesPerSection; i++ {
-			bitsPerEntry := c.Sections[section].Biomes[i].BitsPerEntry
+	return clone
+}
 
-			err := types.WriteByte(writer, bitsPerEntry)
-			if err != nil {
-				return 0, err
-			}
+// GetBlock returns the block state at given chunk-local x and z (0-15) and absolute y.
+func (c *Chunk) GetBlock(x, y, z int) int {
+	section := c.section(y)
+	if section == nil {
+		return AirState
+	}
 
-			switch {
-			case bitsPerEntry == 0:
-				err := types.WriteVarInt(writer, c.Sections[section].Biomes[i].PaletteSingleValued.Value)
-				if err != nil {
-					return 0, err
-				}
-			default:
-				// TODO
-			}
+	return section.GetBlock(x, y&(SectionHeight-1), z)
+}
 
-			err = types.WriteVarInt(writer, len(c.Sections[section].Biomes[i].Data))
-			if err != nil {
-				return 0, err
-			}
+// SetBlock replaces the block state at given chunk-local x and z (0-15) and absolute y, returning the previous one.
+func (c *Chunk) SetBlock(x, y, z int, state int) int {
+	section := c.section(y)
+	if section == nil {
+		return AirState
+	}
+
+	return section.SetBlock(x, y&(SectionHeight-1), z, state)
+}
+
+// GetBiome returns the biome of 4x4x4 cell containing given chunk-local x and z (0-15) and absolute y.
+func (c *Chunk) GetBiome(x, y, z int) int {
+	section := c.section(y)
+	if section == nil {
+		return DefaultBiome
+	}
+
+	return section.Biomes.Get(biomeIndex(x>>2, (y&(SectionHeight-1))>>2, z>>2))
+}
+
+func (c *Chunk) SetBiome(x, y, z int, biome int) {
+	section := c.section(y)
+	if section == nil {
+		return
+	}
+
+	section.Biomes.Set(biomeIndex(x>>2, (y&(SectionHeight-1))>>2, z>>2), biome)
+}
+
+// GetBlockEntity returns the block entity at given chunk-local x and z (0-15) and absolute y, or nil.
+func (c *Chunk) GetBlockEntity(x, y, z int) *BlockEntity {
+	return c.BlockEntities[blockEntityKey(x, y, z)]
+}
+
+// SetBlockEntity stores the block entity, replacing the one at the same position.
+func (c *Chunk) SetBlockEntity(blockEntity *BlockEntity) {
+	if c.BlockEntities == nil {
+		c.BlockEntities = make(map[int]*BlockEntity)
+	}
 
-			for dataElement := 0; dataElement < len(c.Sections[section].Biomes[i].Data); dataElement++ {
-				err = types.WriteInt64(writer, c.Sections[section].Biomes[i].Data[dataElement])
-				if err != nil {
-					return 0, err
-				}
+	c.BlockEntities[blockEntityKey(blockEntity.X(), int(blockEntity.Y), blockEntity.Z())] = blockEntity
+}
+
+// RemoveBlockEntity deletes the block entity at given chunk-local x and z (0-15) and absolute y,
+// and returns whether there was any.
+func (c *Chunk) RemoveBlockEntity(x, y, z int) bool {
+	key := blockEntityKey(x, y, z)
+	if _, ok := c.BlockEntities[key]; !ok {
+		return false
+	}
+
+	delete(c.BlockEntities, key)
+	return true
+}
+
+// HighestBlock returns absolute y of the highest non-air block in given column, or MinY-1 if there is none.
+func (c *Chunk) HighestBlock(x, z int) int {
+	for i := len(c.Sections) - 1; i >= 0; i-- {
+		section := &c.Sections[i]
+		if section.BlockCount == 0 {
+			continue
+		}
+
+		for y := SectionHeight - 1; y >= 0; y-- {
+			if !IsAir(section.GetBlock(x, y, z)) {
+				return MinY + i*SectionHeight + y
 			}
 		}
 	}
 
-	return 0, nil
+	return MinY - 1
+}
+
+// IsAir tells whether the block state is one of the air blocks, which are not counted in block counts of sections.
+func IsAir(state int) bool {
+	return state == AirState || state == VoidAirState || state == CaveAirState
 }
 
-func getSolidSection() Section {
-	section := Section{
-		BlockCount:  BlocksPerSection,
-		BlockStates: make([]PalettedContainer, BlocksPerSection),
-		Biomes:      make([]PalettedContainer, BiomesPerSection),
+func (c *Chunk) section(y int) *Section {
+	index := (y - MinY) / SectionHeight
+	if y < MinY || index >= len(c.Sections) {
+		return nil
 	}
 
-	for i := 0; i < BlocksPerSection; i++ {
-		section.BlockStates[i] = PalettedContainer{
-			BitsPerEntry: 0,
-			PaletteSingleValued: &PaletteSingleValued{
-				Value: 1,
-			},
+	return &c.Sections[index]
+}
+
+func (c *Chunk) WriteTo(writer io.Writer) (int64, error) {
+	for i := range c.Sections {
+		_, err := c.Sections[i].WriteTo(writer)
+		if err != nil {
+			return 0, err
 		}
 	}
 
-	for i := 0; i < BiomesPerSection; i++ {
-		section.Biomes[i] = PalettedContainer{
-			BitsPerEntry: 0,
-			PaletteSingleValued: &PaletteSingleValued{
-				Value: 1,
-			},
+	return 0, nil
+}
+
+func ReadChunk(reader io.Reader) (*Chunk, error) {
+	sections := make([]Section, SectionsCount)
+
+	for i := range sections {
+		section, err := ReadSection(reader)
+		if err != nil {
+			return nil, err
 		}
+
+		sections[i] = *section
 	}
 
-	return section
+	return &Chunk{
+		Sections: sections,
+	}, nil
 }
 
-func getAirSection() Section {
-	section := Section{
-		BlockCount:  0,
-		BlockStates: make([]PalettedContainer, BlocksPerSection),
-		Biomes:      make([]PalettedContainer, BiomesPerSection),
+func (s *Section) GetBlock(x, y, z int) int {
+	return s.BlockStates.Get(blockIndex(x, y, z))
+}
+
+func (s *Section) SetBlock(x, y, z int, state int) int {
+	previous := s.BlockStates.Set(blockIndex(x, y, z), state)
+
+	if IsAir(previous) && !IsAir(state) {
+		s.BlockCount++
+	} else if !IsAir(previous) && IsAir(state) {
+		s.BlockCount--
 	}
 
-	for i := 0; i < BlocksPerSection; i++ {
-		section.BlockStates[i] = PalettedContainer{
-			BitsPerEntry: 0,
-			PaletteSingleValued: &PaletteSingleValued{
-				Value: 0,
-			},
+	return previous
+}
+
+func (s *Section) RecalculateBlockCount() {
+	s.BlockCount = 0
+
+	if s.BlockStates.IsSingleValued() {
+		if !IsAir(s.BlockStates.Get(0)) {
+			s.BlockCount = BlocksPerSection
 		}
+		return
 	}
 
-	for i := 0; i < BiomesPerSection; i++ {
-		section.Biomes[i] = PalettedContainer{
-			BitsPerEntry: 0,
-			PaletteSingleValued: &PaletteSingleValued{
-				Value: 1,
-			},
+	for i := 0; i < BlocksPerSection; i++ {
+		if !IsAir(s.BlockStates.Get(i)) {
+			s.BlockCount++
 		}
 	}
+}
+
+func (s *Section) WriteTo(writer io.Writer) (int64, error) {
+	err := types.WriteInt16(writer, s.BlockCount)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = s.BlockStates.WriteTo(writer)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = s.Biomes.WriteTo(writer)
+	if err != nil {
+		return 0, err
+	}
+
+	return 0, nil
+}
+
+func ReadSection(reader io.Reader) (*Section, error) {
+	blockCount, err := types.ReadInt16(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	blockStates, err := ReadPalettedContainer(reader, BlockStatesKind)
+	if err != nil {
+		return nil, err
+	}
+
+	biomes, err := ReadPalettedContainer(reader, BiomesKind)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Section{
+		BlockCount:  blockCount,
+		BlockStates: blockStates,
+		Biomes:      biomes,
+	}, nil
+}
+
+func blockIndex(x, y, z int) int {
+	return (y&15)<<8 | (z&15)<<4 | (x & 15)
+}
+
+func blockEntityKey(x, y, z int) int {
+	return (y-MinY)<<8 | (z&15)<<4 | (x & 15)
+}
 
-	return section
+func biomeIndex(x, y, z int) int {
+	return (y&3)<<4 | (z&3)<<2 | (x & 3)
 }
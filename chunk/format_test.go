@@ -0,0 +1,289 @@
+package chunk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/mkorman9/go-minecraft-server/nbt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSingleValuedSection(t *testing.T) {
+	section := NewSection(AirState, DefaultBiome)
+
+	expected := []byte{
+		0x00, 0x00, // block count
+		0x00, 0x00, 0x00, // block states: bits, value, data length
+		0x00, 0x01, 0x00, // biomes: bits, value, data length
+	}
+
+	assertSectionBytes(t, &section, expected)
+}
+
+func TestIndirectSection(t *testing.T) {
+	section := NewSection(AirState, DefaultBiome)
+	for x := 0; x < 16; x++ {
+		for z := 0; z < 16; z++ {
+			section.SetBlock(x, 0, z, 1)
+		}
+	}
+
+	if section.BlockCount != 256 {
+		t.Fatalf("expected block count 256, got %d", section.BlockCount)
+	}
+
+	var expected bytes.Buffer
+	expected.Write([]byte{
+		0x01, 0x00, // block count
+		0x04,             // bits per entry
+		0x02, 0x00, 0x01, // palette: length, air, stone
+		0x80, 0x02, // data length (256)
+	})
+	for i := 0; i < 256; i++ {
+		value := uint64(0)
+		if i < 16 {
+			value = 0x1111111111111111
+		}
+		_ = binary.Write(&expected, binary.BigEndian, value)
+	}
+	expected.Write([]byte{0x00, 0x01, 0x00})
+
+	assertSectionBytes(t, &section, expected.Bytes())
+}
+
+func TestDirectSection(t *testing.T) {
+	values := make([]int, BlocksPerSection)
+	for i := range values {
+		values[i] = i % 300
+	}
+
+	container := NewPalettedContainerFrom(BlockStatesKind, values)
+	if !container.IsDirect() || container.BitsPerEntry() != blockStatesDirectBits {
+		t.Fatalf("expected direct palette, got %d bits", container.BitsPerEntry())
+	}
+	if len(container.Data()) != 1024 {
+		t.Fatalf("expected 1024 longs, got %d", len(container.Data()))
+	}
+
+	expectedFirstLong := int64(0 | 1<<15 | 2<<30 | 3<<45)
+	if container.Data()[0] != expectedFirstLong {
+		t.Fatalf("expected first long %x, got %x", expectedFirstLong, container.Data()[0])
+	}
+
+	section := Section{
+		BlockCount:  BlocksPerSection - 14,
+		BlockStates: container,
+		Biomes:      NewPalettedContainer(BiomesKind, DefaultBiome),
+	}
+	roundTripSection(t, &section)
+}
+
+// testdata/vanilla_section_*.bin hold sections of anvil/testdata/r.0.0.mca as the vanilla 1.19 server sends them
+// after loading the chunk: palettes in the order they were saved, with state IDs of the 1.19 blocks report,
+// and the longs packed by the vanilla server copied without changes. Air, void air and cave air are not counted
+// in the block count.
+func TestVanillaSections(t *testing.T) {
+	cases := []struct {
+		name       string
+		blockCount int16
+		blockBits  byte
+		biomeBits  byte
+		blocks     map[int]int
+	}{
+		// the dungeon in chunk (8, 8): chests at (11, -54, 15) and (11, -54, 13), spawner at (9, -54, 14)
+		{name: "dungeon", blockCount: 3930, blockBits: 4, blocks: map[int]int{blockIndex(11, 10, 15): 2301, blockIndex(9, 10, 14): 2207}},
+		{name: "surface", blockCount: 3269, blockBits: 5},
+		{name: "biomes", blockCount: 3995, blockBits: 4, biomeBits: 1},
+		{name: "air"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			expected, err := os.ReadFile(filepath.Join("testdata", "vanilla_section_"+c.name+".bin"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			decoded, err := ReadSection(bytes.NewReader(expected))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decoded.BlockStates.BitsPerEntry() != c.blockBits || decoded.Biomes.BitsPerEntry() != c.biomeBits {
+				t.Fatalf("unexpected bits per entry: %d, %d", decoded.BlockStates.BitsPerEntry(), decoded.Biomes.BitsPerEntry())
+			}
+			for index, state := range c.blocks {
+				if value := decoded.BlockStates.Get(index); value != state {
+					t.Fatalf("expected state %d at %d, got %d", state, index, value)
+				}
+			}
+
+			// sections loaded from region files are built from their values
+			section := Section{
+				BlockStates: NewPalettedContainerFrom(BlockStatesKind, decoded.BlockStates.Values()),
+				Biomes:      NewPalettedContainerFrom(BiomesKind, decoded.Biomes.Values()),
+			}
+			section.RecalculateBlockCount()
+
+			if section.BlockCount != c.blockCount {
+				t.Fatalf("expected block count %d, got %d", c.blockCount, section.BlockCount)
+			}
+			assertSectionBytes(t, &section, expected)
+		})
+	}
+}
+
+func TestIndirectBiomes(t *testing.T) {
+	c := NewChunk()
+	c.SetBiome(0, MinY, 0, 5)
+
+	biomes := c.Sections[0].Biomes
+	if biomes.BitsPerEntry() != 1 || len(biomes.Data()) != 1 || biomes.Data()[0] != 1 {
+		t.Fatalf("unexpected biomes container: %d bits, data %v", biomes.BitsPerEntry(), biomes.Data())
+	}
+	if c.GetBiome(3, MinY+3, 3) != 5 || c.GetBiome(4, MinY, 0) != DefaultBiome {
+		t.Fatalf("biome lookup mismatch")
+	}
+}
+
+func TestPaletteGrowth(t *testing.T) {
+	section := NewSection(AirState, DefaultBiome)
+	for i := 1; i <= 16; i++ {
+		section.SetBlock(i-1, 0, 0, i)
+	}
+
+	if section.BlockStates.BitsPerEntry() != 5 {
+		t.Fatalf("expected 5 bits per entry, got %d", section.BlockStates.BitsPerEntry())
+	}
+
+	for i := 1; i <= 16; i++ {
+		if section.GetBlock(i-1, 0, 0) != i {
+			t.Fatalf("expected %d at %d, got %d", i, i-1, section.GetBlock(i-1, 0, 0))
+		}
+	}
+
+	for i := 0; i < 16; i++ {
+		section.SetBlock(i, 0, 0, AirState)
+	}
+	section.BlockStates.Optimize()
+
+	if !section.BlockStates.IsSingleValued() || section.BlockCount != 0 {
+		t.Fatalf("expected optimized empty section")
+	}
+}
+
+func TestChunkRoundTrip(t *testing.T) {
+	c := exampleChunk()
+	c.SetBlock(3, 70, 7, 9)
+	c.SetBlock(15, MinY, 15, AirState)
+
+	var encoded bytes.Buffer
+	_, err := c.WriteTo(&encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := ReadChunk(bytes.NewReader(encoded.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.GetBlock(3, 70, 7) != 9 || decoded.GetBlock(15, MinY, 15) != AirState || decoded.GetBlock(0, 0, 0) != 1 {
+		t.Fatalf("decoded chunk does not match")
+	}
+
+	var reencoded bytes.Buffer
+	_, err = decoded.WriteTo(&reencoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(encoded.Bytes(), reencoded.Bytes()) {
+		t.Fatalf("re-encoded chunk differs")
+	}
+}
+
+// exampleChunk is filled with stone (state 1) below y=64.
+func exampleChunk() *Chunk {
+	c := NewChunk()
+
+	for i := 0; i < SectionsCount; i++ {
+		if MinY+(i+1)*SectionHeight <= 64 {
+			c.Sections[i] = NewSection(1, DefaultBiome)
+		}
+	}
+
+	return c
+}
+
+func assertSectionBytes(t *testing.T, section *Section, expected []byte) {
+	t.Helper()
+
+	var encoded bytes.Buffer
+	_, err := section.WriteTo(&encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(encoded.Bytes(), expected) {
+		t.Fatalf("encoded section mismatch\nexpected: %x\ngot:      %x", expected, encoded.Bytes())
+	}
+
+	roundTripSection(t, section)
+}
+
+func roundTripSection(t *testing.T, section *Section) {
+	t.Helper()
+
+	var encoded bytes.Buffer
+	_, err := section.WriteTo(&encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := ReadSection(bytes.NewReader(encoded.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.BlockCount != section.BlockCount {
+		t.Fatalf("block count mismatch: %d != %d", decoded.BlockCount, section.BlockCount)
+	}
+
+	expectedValues := section.BlockStates.Values()
+	for i, value := range decoded.BlockStates.Values() {
+		if value != expectedValues[i] {
+			t.Fatalf("block state mismatch at %d: %d != %d", i, value, expectedValues[i])
+		}
+	}
+
+	expectedBiomes := section.Biomes.Values()
+	for i, value := range decoded.Biomes.Values() {
+		if value != expectedBiomes[i] {
+			t.Fatalf("biome mismatch at %d: %d != %d", i, value, expectedBiomes[i])
+		}
+	}
+}
+
+func TestBlockEntities(t *testing.T) {
+	c := NewChunk()
+	c.SetBlockEntity(NewBlockEntity(17, -60, 5, 7, nbt.RawMessage{}))
+
+	blockEntity := c.GetBlockEntity(1, -60, 5)
+	if blockEntity == nil || blockEntity.X() != 1 || blockEntity.Z() != 5 || blockEntity.Y != -60 {
+		t.Fatalf("unexpected block entity: %+v", blockEntity)
+	}
+	if blockEntity.Data.Type != nbt.TagCompound {
+		t.Fatalf("expected empty data to be replaced with a compound")
+	}
+
+	clone := c.Clone()
+	clone.GetBlockEntity(1, -60, 5).Type = 1
+	if c.GetBlockEntity(1, -60, 5).Type != 7 {
+		t.Fatalf("clone shares block entities with the original chunk")
+	}
+
+	if !c.RemoveBlockEntity(1, -60, 5) || c.RemoveBlockEntity(1, -60, 5) || c.GetBlockEntity(1, -60, 5) != nil {
+		t.Fatalf("block entity was not removed")
+	}
+}
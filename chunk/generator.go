@@ -0,0 +1,5 @@
+package chunk
+
+type Generator interface {
+	GenerateChunk(x, z int) *Chunk
+}
@@ -0,0 +1,118 @@
+package chunk
+
+// BlockPredicate tells whether a block state should be taken into account by a heightmap.
+type BlockPredicate func(state int) bool
+
+// heightmapBits is the number of bits needed to store heights from 0 to ChunkHeight.
+const heightmapBits = 9
+
+// PackHeightmap packs 256 column heights (indexed by z*16+x, counted from MinY)
+// into longs, the way both the protocol and the Anvil format expect them.
+func PackHeightmap(heights []int) []int64 {
+	entriesPerLong := 64 / heightmapBits
+	data := make([]int64, (len(heights)+entriesPerLong-1)/entriesPerLong)
+
+	for i, height := range heights {
+		data[i/entriesPerLong] |= int64(uint64(height) << ((i % entriesPerLong) * heightmapBits))
+	}
+
+	return data
+}
+
+// UnpackHeightmap reverses PackHeightmap.
+func UnpackHeightmap(data []int64) []int {
+	heights := make([]int, ChunkSize*ChunkSize)
+	for x := 0; x < ChunkSize; x++ {
+		for z := 0; z < ChunkSize; z++ {
+			heights[z*ChunkSize+x] = HeightAt(data, x, z)
+		}
+	}
+
+	return heights
+}
+
+// ComputeHeightmaps builds heightmaps from the chunk's blocks. WORLD_SURFACE counts every non-air block,
+// MOTION_BLOCKING only the ones matching isMotionBlocking.
+func ComputeHeightmaps(c *Chunk, isMotionBlocking BlockPredicate) *Heightmap {
+	motionBlocking := make([]int, ChunkSize*ChunkSize)
+	worldSurface := make([]int, ChunkSize*ChunkSize)
+
+	for x := 0; x < ChunkSize; x++ {
+		for z := 0; z < ChunkSize; z++ {
+			worldSurface[z*ChunkSize+x] = c.columnHeight(x, MinY+ChunkHeight-1, z, isNotAir)
+			motionBlocking[z*ChunkSize+x] = c.columnHeight(x, MinY+ChunkHeight-1, z, isMotionBlocking)
+		}
+	}
+
+	return &Heightmap{
+		MotionBlocking: PackHeightmap(motionBlocking),
+		WorldSurface:   PackHeightmap(worldSurface),
+	}
+}
+
+// UpdateHeightmaps adjusts heightmaps of the column containing given block, after the block has been changed.
+func (c *Chunk) UpdateHeightmaps(x, y, z int, isMotionBlocking BlockPredicate) {
+	if c.Heightmaps == nil {
+		c.Heightmaps = ComputeHeightmaps(c, isMotionBlocking)
+		return
+	}
+
+	state := c.GetBlock(x, y, z)
+	c.updateHeightmap(c.Heightmaps.WorldSurface, x, y, z, isNotAir(state), isNotAir)
+	c.updateHeightmap(c.Heightmaps.MotionBlocking, x, y, z, isMotionBlocking(state), isMotionBlocking)
+}
+
+// HeightAt returns the height (counted from MinY) stored in the heightmap data for given column.
+func HeightAt(data []int64, x, z int) int {
+	index := z*ChunkSize + x
+	entriesPerLong := 64 / heightmapBits
+	if index/entriesPerLong >= len(data) {
+		return 0
+	}
+
+	return int((uint64(data[index/entriesPerLong]) >> ((index % entriesPerLong) * heightmapBits)) & (1<<heightmapBits - 1))
+}
+
+func (c *Chunk) updateHeightmap(data []int64, x, y, z int, matches bool, predicate BlockPredicate) {
+	current := HeightAt(data, x, z)
+	height := y + 1 - MinY
+
+	if matches && height > current {
+		setHeight(data, x, z, height)
+	} else if !matches && height == current {
+		setHeight(data, x, z, c.columnHeight(x, y-1, z, predicate))
+	}
+}
+
+// columnHeight finds the first block matching predicate, going down from given y.
+func (c *Chunk) columnHeight(x, y, z int, predicate BlockPredicate) int {
+	for ; y >= MinY; y-- {
+		section := c.section(y)
+		if section.BlockCount == 0 {
+			// skip to the top of the section below
+			y -= y & (SectionHeight - 1)
+			continue
+		}
+
+		if predicate(section.GetBlock(x, y&(SectionHeight-1), z)) {
+			return y + 1 - MinY
+		}
+	}
+
+	return 0
+}
+
+func setHeight(data []int64, x, z int, height int) {
+	index := z*ChunkSize + x
+	entriesPerLong := 64 / heightmapBits
+	shift := (index % entriesPerLong) * heightmapBits
+
+	value := uint64(data[index/entriesPerLong])
+	value &^= (1<<heightmapBits - 1) << shift
+	value |= uint64(height) << shift
+	data[index/entriesPerLong] = int64(value)
+}
+
+func isNotAir(state int) bool {
+	return !IsAir(state)
+}
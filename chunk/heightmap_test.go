@@ -0,0 +1,81 @@
+package chunk
+
+import "testing"
+
+const testLeaves = 5
+
+func isSolid(state int) bool {
+	return state != AirState && state != testLeaves
+}
+
+func TestPackHeightmap(t *testing.T) {
+	heights := make([]int, ChunkSize*ChunkSize)
+	for i := range heights {
+		heights[i] = i + 100
+	}
+
+	data := PackHeightmap(heights)
+	if len(data) != 37 {
+		t.Fatalf("expected 37 longs, got %d", len(data))
+	}
+
+	expectedFirstLong := int64(100 | 101<<9 | 102<<18 | 103<<27 | 104<<36 | 105<<45 | 106<<54)
+	if data[0] != expectedFirstLong {
+		t.Fatalf("expected first long %x, got %x", expectedFirstLong, data[0])
+	}
+
+	for i, height := range UnpackHeightmap(data) {
+		if height != heights[i] {
+			t.Fatalf("height mismatch at %d: %d != %d", i, height, heights[i])
+		}
+	}
+}
+
+func TestComputeHeightmaps(t *testing.T) {
+	c := exampleChunk()
+	c.SetBlock(3, 100, 5, testLeaves)
+	c.SetBlock(4, 70, 5, 1)
+
+	heightmaps := ComputeHeightmaps(c, isSolid)
+
+	assertHeight(t, heightmaps.WorldSurface, 0, 0, 64-MinY)
+	assertHeight(t, heightmaps.MotionBlocking, 0, 0, 64-MinY)
+	assertHeight(t, heightmaps.WorldSurface, 3, 5, 101-MinY)
+	assertHeight(t, heightmaps.MotionBlocking, 3, 5, 64-MinY)
+	assertHeight(t, heightmaps.MotionBlocking, 4, 5, 71-MinY)
+}
+
+func TestUpdateHeightmaps(t *testing.T) {
+	c := exampleChunk()
+	c.Heightmaps = ComputeHeightmaps(c, isSolid)
+
+	c.SetBlock(7, 200, 7, 1)
+	c.UpdateHeightmaps(7, 200, 7, isSolid)
+	assertHeight(t, c.Heightmaps.MotionBlocking, 7, 7, 201-MinY)
+
+	c.SetBlock(7, 200, 7, testLeaves)
+	c.UpdateHeightmaps(7, 200, 7, isSolid)
+	assertHeight(t, c.Heightmaps.MotionBlocking, 7, 7, 64-MinY)
+	assertHeight(t, c.Heightmaps.WorldSurface, 7, 7, 201-MinY)
+
+	c.SetBlock(7, 200, 7, AirState)
+	c.UpdateHeightmaps(7, 200, 7, isSolid)
+	c.SetBlock(7, 63, 7, AirState)
+	c.UpdateHeightmaps(7, 63, 7, isSolid)
+	assertHeight(t, c.Heightmaps.WorldSurface, 7, 7, 63-MinY)
+	assertHeight(t, c.Heightmaps.WorldSurface, 8, 7, 64-MinY)
+
+	for y := MinY; y < 64; y++ {
+		c.SetBlock(0, y, 0, AirState)
+		c.UpdateHeightmaps(0, y, 0, isSolid)
+	}
+	assertHeight(t, c.Heightmaps.WorldSurface, 0, 0, 0)
+}
+
+func assertHeight(t *testing.T, data []int64, x, z int, expected int) {
+	t.Helper()
+
+	if HeightAt(data, x, z) != expected {
+		t.Fatalf("expected height %d at %d, %d, got %d", expected, x, z, HeightAt(data, x, z))
+	}
+}
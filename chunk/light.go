@@ -0,0 +1,130 @@
+package chunk
+
+const (
+	// LightSectionsCount includes one extra section below and one above the world.
+	LightSectionsCount = SectionsCount + 2
+	MaxLightLevel      = 15
+	nibbleArraySize    = BlocksPerSection / 2
+)
+
+// NibbleArray stores one 4-bit light level per block of a section, indexed the same way as block states.
+type NibbleArray [nibbleArraySize]byte
+
+func NewFullNibbleArray() *NibbleArray {
+	var array NibbleArray
+	for i := range array {
+		array[i] = MaxLightLevel<<4 | MaxLightLevel
+	}
+	return &array
+}
+
+func (na *NibbleArray) Get(index int) byte {
+	if index&1 == 0 {
+		return na[index>>1] & 0x0f
+	}
+	return na[index>>1] >> 4
+}
+
+func (na *NibbleArray) Set(index int, value byte) {
+	if index&1 == 0 {
+		na[index>>1] = na[index>>1]&0xf0 | value&0x0f
+	} else {
+		na[index>>1] = na[index>>1]&0x0f | value<<4
+	}
+}
+
+func (na *NibbleArray) IsEmpty() bool {
+	for _, value := range na {
+		if value != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Light holds sky and block light of a chunk. Nil arrays are treated as completely dark.
+type Light struct {
+	Sky   []*NibbleArray
+	Block []*NibbleArray
+}
+
+// NewLight creates dark light arrays, except for the sky light above the world.
+func NewLight() *Light {
+	light := &Light{
+		Sky:   make([]*NibbleArray, LightSectionsCount),
+		Block: make([]*NibbleArray, LightSectionsCount),
+	}
+
+	light.Sky[LightSectionsCount-1] = NewFullNibbleArray()
+	return light
+}
+
+// GetSky returns the sky light at given chunk-local x and z (0-15) and absolute y.
+func (l *Light) GetSky(x, y, z int) byte {
+	return getLight(l.Sky, x, y, z)
+}
+
+func (l *Light) SetSky(x, y, z int, level byte) {
+	setLight(l.Sky, x, y, z, level)
+}
+
+// GetBlock returns the block light at given chunk-local x and z (0-15) and absolute y.
+func (l *Light) GetBlock(x, y, z int) byte {
+	return getLight(l.Block, x, y, z)
+}
+
+func (l *Light) SetBlock(x, y, z int, level byte) {
+	setLight(l.Block, x, y, z, level)
+}
+
+func (l *Light) Clone() *Light {
+	clone := &Light{
+		Sky:   make([]*NibbleArray, len(l.Sky)),
+		Block: make([]*NibbleArray, len(l.Block)),
+	}
+
+	for i, array := range l.Sky {
+		if array != nil {
+			arrayCopy := *array
+			clone.Sky[i] = &arrayCopy
+		}
+	}
+	for i, array := range l.Block {
+		if array != nil {
+			arrayCopy := *array
+			clone.Block[i] = &arrayCopy
+		}
+	}
+
+	return clone
+}
+
+// LightSectionIndex returns index of the light section containing given absolute y.
+func LightSectionIndex(y int) int {
+	return (y - MinY + SectionHeight) / SectionHeight
+}
+
+func getLight(arrays []*NibbleArray, x, y, z int) byte {
+	index := LightSectionIndex(y)
+	if y < MinY-SectionHeight || index >= len(arrays) || arrays[index] == nil {
+		return 0
+	}
+
+	return arrays[index].Get(blockIndex(x, y, z))
+}
+
+func setLight(arrays []*NibbleArray, x, y, z int, level byte) {
+	index := LightSectionIndex(y)
+	if y < MinY-SectionHeight || index >= len(arrays) {
+		return
+	}
+
+	if arrays[index] == nil {
+		if level == 0 {
+			return
+		}
+		arrays[index] = &NibbleArray{}
+	}
+
+	arrays[index].Set(blockIndex(x, y, z), level)
+}
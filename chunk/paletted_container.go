@@ -0,0 +1,382 @@
+package chunk
+
+import (
+	"errors"
+	"fmt"
+	"github.com/mkorman9/go-minecraft-server/types"
+	"io"
+)
+
+type ContainerKind struct {
+	Size            int
+	MinIndirectBits byte
+	MaxIndirectBits byte
+	DirectBits      byte
+}
+
+var (
+	BlockStatesKind = &ContainerKind{
+		Size:            BlocksPerSection,
+		MinIndirectBits: blockStatesMinIndirectBits,
+		MaxIndirectBits: blockStatesMaxIndirectBits,
+		DirectBits:      blockStatesDirectBits,
+	}
+	BiomesKind = &ContainerKind{
+		Size:            BiomesPerSection,
+		MinIndirectBits: biomesMinIndirectBits,
+		MaxIndirectBits: biomesMaxIndirectBits,
+		DirectBits:      biomesDirectBits,
+	}
+)
+
+// PalettedContainer stores Size entries packed into longs, either as a single value (0 bits per entry),
+// as indices into a local palette (indirect) or as global IDs (direct).
+type PalettedContainer struct {
+	kind         *ContainerKind
+	bitsPerEntry byte
+	palette      []int
+	data         []int64
+}
+
+func NewPalettedContainer(kind *ContainerKind, value int) *PalettedContainer {
+	return &PalettedContainer{
+		kind:         kind,
+		bitsPerEntry: 0,
+		palette:      []int{value},
+	}
+}
+
+// NewPalettedContainerFrom builds a container holding given values, choosing the smallest palette able to hold them.
+func NewPalettedContainerFrom(kind *ContainerKind, values []int) *PalettedContainer {
+	var palette []int
+	indices := make(map[int]int)
+
+	for _, value := range values {
+		if _, ok := indices[value]; !ok {
+			indices[value] = len(palette)
+			palette = append(palette, value)
+		}
+	}
+
+	if len(palette) <= 1 {
+		value := 0
+		if len(palette) == 1 {
+			value = palette[0]
+		}
+
+		return NewPalettedContainer(kind, value)
+	}
+
+	container := &PalettedContainer{
+		kind:         kind,
+		bitsPerEntry: kind.bitsFor(len(palette)),
+	}
+
+	if container.bitsPerEntry != kind.DirectBits {
+		container.palette = palette
+	}
+
+	container.data = make([]int64, dataLength(kind.Size, container.bitsPerEntry))
+	for i, value := range values {
+		if container.palette != nil {
+			container.put(i, indices[value])
+		} else {
+			container.put(i, value)
+		}
+	}
+
+	return container
+}
+
+func (pc *PalettedContainer) Kind() *ContainerKind {
+	return pc.kind
+}
+
+func (pc *PalettedContainer) BitsPerEntry() byte {
+	return pc.bitsPerEntry
+}
+
+func (pc *PalettedContainer) Palette() []int {
+	return pc.palette
+}
+
+func (pc *PalettedContainer) Data() []int64 {
+	return pc.data
+}
+
+func (pc *PalettedContainer) IsSingleValued() bool {
+	return pc.bitsPerEntry == 0
+}
+
+func (pc *PalettedContainer) IsDirect() bool {
+	return pc.bitsPerEntry != 0 && pc.palette == nil
+}
+
+func (pc *PalettedContainer) Get(index int) int {
+	if pc.bitsPerEntry == 0 {
+		return pc.palette[0]
+	}
+
+	value := pc.get(index)
+	if pc.palette == nil {
+		return value
+	}
+
+	return pc.palette[value]
+}
+
+// Set stores the value under given index and returns the previous one, growing the palette when needed.
+func (pc *PalettedContainer) Set(index int, value int) int {
+	previous := pc.Get(index)
+	if previous == value {
+		return previous
+	}
+
+	if pc.palette == nil {
+		pc.put(index, value)
+		return previous
+	}
+
+	paletteIndex := pc.paletteIndex(value)
+	if paletteIndex == -1 {
+		if len(pc.palette) >= 1<<pc.bitsPerEntry {
+			pc.resize(len(pc.palette) + 1)
+			if pc.palette == nil {
+				pc.put(index, value)
+				return previous
+			}
+		}
+
+		pc.palette = append(pc.palette, value)
+		paletteIndex = len(pc.palette) - 1
+	}
+
+	pc.put(index, paletteIndex)
+	return previous
+}
+
+func (pc *PalettedContainer) Fill(value int) {
+	pc.bitsPerEntry = 0
+	pc.palette = []int{value}
+	pc.data = nil
+}
+
+func (pc *PalettedContainer) Values() []int {
+	values := make([]int, pc.kind.Size)
+	for i := range values {
+		values[i] = pc.Get(i)
+	}
+
+	return values
+}
+
+// Optimize rebuilds the container with the smallest palette able to hold its current values.
+// Palettes only grow on Set, so it is worth calling after bulk modifications.
+func (pc *PalettedContainer) Optimize() {
+	*pc = *NewPalettedContainerFrom(pc.kind, pc.Values())
+}
+
+func (pc *PalettedContainer) Clone() *PalettedContainer {
+	clone := &PalettedContainer{
+		kind:         pc.kind,
+		bitsPerEntry: pc.bitsPerEntry,
+	}
+
+	if pc.palette != nil {
+		clone.palette = make([]int, len(pc.palette))
+		copy(clone.palette, pc.palette)
+	}
+	if pc.data != nil {
+		clone.data = make([]int64, len(pc.data))
+		copy(clone.data, pc.data)
+	}
+
+	return clone
+}
+
+func (pc *PalettedContainer) WriteTo(writer io.Writer) (int64, error) {
+	err := types.WriteByte(writer, pc.bitsPerEntry)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case pc.bitsPerEntry == 0:
+		err = types.WriteVarInt(writer, pc.palette[0])
+		if err != nil {
+			return 0, err
+		}
+	case pc.palette != nil:
+		err = types.WriteVarInt(writer, len(pc.palette))
+		if err != nil {
+			return 0, err
+		}
+
+		for _, value := range pc.palette {
+			err = types.WriteVarInt(writer, value)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	err = types.WriteVarInt(writer, len(pc.data))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, value := range pc.data {
+		err = types.WriteInt64(writer, value)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return 0, nil
+}
+
+func ReadPalettedContainer(reader io.Reader, kind *ContainerKind) (*PalettedContainer, error) {
+	bitsPerEntry, err := types.ReadByte(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	container := &PalettedContainer{
+		kind: kind,
+	}
+
+	switch {
+	case bitsPerEntry == 0:
+		value, err := types.ReadVarInt(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		container.palette = []int{value}
+	case bitsPerEntry <= kind.MaxIndirectBits:
+		if bitsPerEntry < kind.MinIndirectBits {
+			bitsPerEntry = kind.MinIndirectBits
+		}
+
+		paletteLength, err := types.ReadVarInt(reader)
+		if err != nil {
+			return nil, err
+		}
+		if paletteLength > 1<<bitsPerEntry {
+			return nil, fmt.Errorf("palette of %d entries does not fit in %d bits", paletteLength, bitsPerEntry)
+		}
+
+		container.palette = make([]int, paletteLength)
+		for i := 0; i < paletteLength; i++ {
+			container.palette[i], err = types.ReadVarInt(reader)
+			if err != nil {
+				return nil, err
+			}
+		}
+	default:
+		bitsPerEntry = kind.DirectBits
+	}
+
+	container.bitsPerEntry = bitsPerEntry
+
+	length, err := types.ReadVarInt(reader)
+	if err != nil {
+		return nil, err
+	}
+	if length != dataLength(kind.Size, bitsPerEntry) {
+		return nil, fmt.Errorf("invalid data length %d for %d bits per entry", length, bitsPerEntry)
+	}
+
+	if length > 0 {
+		container.data = make([]int64, length)
+		for i := 0; i < length; i++ {
+			container.data[i], err = types.ReadInt64(reader)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if container.palette != nil && bitsPerEntry != 0 {
+		for i := 0; i < kind.Size; i++ {
+			if container.get(i) >= len(container.palette) {
+				return nil, errors.New("palette index out of bounds")
+			}
+		}
+	}
+
+	return container, nil
+}
+
+func (pc *PalettedContainer) paletteIndex(value int) int {
+	for i, v := range pc.palette {
+		if v == value {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (pc *PalettedContainer) resize(paletteLength int) {
+	values := pc.Values()
+	bits := pc.kind.bitsFor(paletteLength)
+
+	pc.bitsPerEntry = bits
+	pc.data = make([]int64, dataLength(pc.kind.Size, bits))
+
+	if bits == pc.kind.DirectBits {
+		pc.palette = nil
+		for i, value := range values {
+			pc.put(i, value)
+		}
+		return
+	}
+
+	for i, value := range values {
+		pc.put(i, pc.paletteIndex(value))
+	}
+}
+
+func (pc *PalettedContainer) get(index int) int {
+	entriesPerLong := 64 / int(pc.bitsPerEntry)
+	offset := (index % entriesPerLong) * int(pc.bitsPerEntry)
+	mask := uint64(1)<<pc.bitsPerEntry - 1
+
+	return int((uint64(pc.data[index/entriesPerLong]) >> offset) & mask)
+}
+
+func (pc *PalettedContainer) put(index int, value int) {
+	entriesPerLong := 64 / int(pc.bitsPerEntry)
+	offset := (index % entriesPerLong) * int(pc.bitsPerEntry)
+	mask := uint64(1)<<pc.bitsPerEntry - 1
+
+	long := uint64(pc.data[index/entriesPerLong])
+	long = (long &^ (mask << offset)) | ((uint64(value) & mask) << offset)
+	pc.data[index/entriesPerLong] = int64(long)
+}
+
+func (ck *ContainerKind) bitsFor(paletteLength int) byte {
+	bits := byte(0)
+	for 1<<bits < paletteLength {
+		bits++
+	}
+
+	if bits < ck.MinIndirectBits {
+		bits = ck.MinIndirectBits
+	}
+	if bits > ck.MaxIndirectBits {
+		bits = ck.DirectBits
+	}
+
+	return bits
+}
+
+func dataLength(size int, bitsPerEntry byte) int {
+	if bitsPerEntry == 0 {
+		return 0
+	}
+
+	entriesPerLong := 64 / int(bitsPerEntry)
+	return (size + entriesPerLong - 1) / entriesPerLong
+}
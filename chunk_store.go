@@ -0,0 +1,290 @@
+package main
+
+import (
+	"github.com/mkorman9/go-minecraft-server/chunk"
+	"github.com/mkorman9/go-minecraft-server/nbt"
+	"sync"
+)
+
+type ChunkLoader = func(position ChunkPosition) (c *chunk.Chunk, source ChunkSource)
+
+// ChunkSource tells where a chunk returned by ChunkLoader comes from.
+type ChunkSource int
+
+const (
+	ChunkSourceStorage ChunkSource = iota
+	// ChunkSourceGenerator chunks are new, they end up on disk with the next save.
+	ChunkSourceGenerator
+	// ChunkSourcePlaceholder chunks stand in for saved chunks which couldn't be loaded. They are never modified
+	// or saved, so the data which the server couldn't read stays untouched on disk.
+	ChunkSourcePlaceholder
+)
+
+type storedChunk struct {
+	chunk    *chunk.Chunk
+	dirty    bool
+	readOnly bool
+}
+
+// BlockChange is a new state of the block at given absolute position.
+type BlockChange struct {
+	X     int
+	Y     int
+	Z     int
+	State int
+}
+
+// ChunkStore keeps loaded chunks. Blocks of the stored chunks may only be modified with SetBlocks,
+// and read directly only inside View, as they can be changed concurrently.
+type ChunkStore struct {
+	m      sync.Mutex
+	blocks sync.RWMutex
+	chunks map[ChunkPosition]*storedChunk
+	loader ChunkLoader
+}
+
+func NewChunkStore(loader ChunkLoader) *ChunkStore {
+	return &ChunkStore{
+		chunks: make(map[ChunkPosition]*storedChunk),
+		loader: loader,
+	}
+}
+
+// Get returns the chunk at given position, loading it on first access. Freshly generated chunks are marked as dirty,
+// so they end up on disk with the next save, placeholders are read-only.
+func (cs *ChunkStore) Get(position ChunkPosition) *chunk.Chunk {
+	cs.m.Lock()
+	stored, ok := cs.chunks[position]
+	cs.m.Unlock()
+
+	if ok {
+		return stored.chunk
+	}
+
+	c, source := cs.loader(position)
+
+	cs.m.Lock()
+	defer cs.m.Unlock()
+
+	if stored, ok := cs.chunks[position]; ok {
+		// loaded concurrently by someone else
+		return stored.chunk
+	}
+
+	cs.chunks[position] = &storedChunk{
+		chunk:    c,
+		dirty:    source == ChunkSourceGenerator,
+		readOnly: source == ChunkSourcePlaceholder,
+	}
+	return c
+}
+
+// getWritable returns the chunk at given position like Get, or nil if the chunk is read-only.
+func (cs *ChunkStore) getWritable(position ChunkPosition) *chunk.Chunk {
+	c := cs.Get(position)
+
+	cs.m.Lock()
+	defer cs.m.Unlock()
+
+	if stored, ok := cs.chunks[position]; ok && stored.readOnly {
+		return nil
+	}
+
+	return c
+}
+
+// Peek returns the chunk at given position only if it is already loaded.
+func (cs *ChunkStore) Peek(position ChunkPosition) *chunk.Chunk {
+	cs.m.Lock()
+	defer cs.m.Unlock()
+
+	if stored, ok := cs.chunks[position]; ok {
+		return stored.chunk
+	}
+
+	return nil
+}
+
+// GetBlock returns the block state at given absolute position, loading the chunk if needed.
+func (cs *ChunkStore) GetBlock(x, y, z int) int {
+	c := cs.Get(ChunkPosition{X: x >> 4, Z: z >> 4})
+
+	cs.blocks.RLock()
+	defer cs.blocks.RUnlock()
+
+	return c.GetBlock(x&(chunk.ChunkSize-1), y, z&(chunk.ChunkSize-1))
+}
+
+// SetBlocks applies the changes, loading chunks if needed, and keeps heightmaps and block entities up to date.
+// Modified chunks are marked as dirty, changes of read-only chunks are skipped. It returns the changes which
+// actually modified a block.
+func (cs *ChunkStore) SetBlocks(changes []BlockChange, palette *WorldPalette) []BlockChange {
+	chunks := make(map[ChunkPosition]*chunk.Chunk)
+	for _, change := range changes {
+		position := ChunkPosition{X: change.X >> 4, Z: change.Z >> 4}
+		if _, ok := chunks[position]; !ok {
+			chunks[position] = cs.getWritable(position)
+		}
+	}
+
+	var applied []BlockChange
+	modified := make(map[ChunkPosition]map[int]struct{})
+
+	cs.blocks.Lock()
+
+	for _, change := range changes {
+		if change.Y < chunk.MinY || change.Y >= chunk.MinY+chunk.ChunkHeight {
+			continue
+		}
+
+		position := ChunkPosition{X: change.X >> 4, Z: change.Z >> 4}
+		c := chunks[position]
+		if c == nil {
+			continue
+		}
+
+		x, z := change.X&(chunk.ChunkSize-1), change.Z&(chunk.ChunkSize-1)
+
+		if c.SetBlock(x, change.Y, z, change.State) == change.State {
+			continue
+		}
+
+		c.UpdateHeightmaps(x, change.Y, z, palette.IsMotionBlocking)
+		updateBlockEntity(c, x, change.Y, z, change.State, palette)
+		applied = append(applied, change)
+
+		if modified[position] == nil {
+			modified[position] = make(map[int]struct{})
+		}
+		modified[position][(change.Y-chunk.MinY)/chunk.SectionHeight] = struct{}{}
+	}
+
+	// palettes only grow when blocks are set, shrink them back after bulk changes
+	if len(applied) > chunk.BlocksPerSection/16 {
+		for position, sections := range modified {
+			for index := range sections {
+				chunks[position].Sections[index].BlockStates.Optimize()
+			}
+		}
+	}
+
+	cs.blocks.Unlock()
+
+	for position := range modified {
+		cs.MarkDirty(position)
+	}
+
+	return applied
+}
+
+// GetBlockEntity returns a copy of the block entity at given absolute position, or nil.
+func (cs *ChunkStore) GetBlockEntity(x, y, z int) *chunk.BlockEntity {
+	c := cs.Get(ChunkPosition{X: x >> 4, Z: z >> 4})
+
+	cs.blocks.RLock()
+	defer cs.blocks.RUnlock()
+
+	blockEntity := c.GetBlockEntity(x&(chunk.ChunkSize-1), y, z&(chunk.ChunkSize-1))
+	if blockEntity == nil {
+		return nil
+	}
+
+	return blockEntity.Clone()
+}
+
+// SetBlockEntityData replaces data of the block entity at given absolute position. It returns the updated
+// block entity, or nil if the block at this position doesn't keep any.
+func (cs *ChunkStore) SetBlockEntityData(x, y, z int, data nbt.RawMessage) *chunk.BlockEntity {
+	position := ChunkPosition{X: x >> 4, Z: z >> 4}
+	c := cs.getWritable(position)
+	if c == nil {
+		return nil
+	}
+
+	cs.blocks.Lock()
+
+	blockEntity := c.GetBlockEntity(x&(chunk.ChunkSize-1), y, z&(chunk.ChunkSize-1))
+	if blockEntity == nil {
+		cs.blocks.Unlock()
+		return nil
+	}
+
+	blockEntity = chunk.NewBlockEntity(x, y, z, blockEntity.Type, data)
+	c.SetBlockEntity(blockEntity)
+
+	cs.blocks.Unlock()
+
+	cs.MarkDirty(position)
+	return blockEntity.Clone()
+}
+
+// View calls the function while no blocks are being modified, so it can safely read blocks of the stored chunks.
+// The function must not call other methods of the store which access blocks.
+func (cs *ChunkStore) View(view func()) {
+	cs.blocks.RLock()
+	defer cs.blocks.RUnlock()
+
+	view()
+}
+
+func (cs *ChunkStore) MarkDirty(position ChunkPosition) {
+	cs.m.Lock()
+	defer cs.m.Unlock()
+
+	if stored, ok := cs.chunks[position]; ok && !stored.readOnly {
+		stored.dirty = true
+	}
+}
+
+// TakeDirty returns all modified chunks and clears their dirty flags.
+func (cs *ChunkStore) TakeDirty() map[ChunkPosition]*chunk.Chunk {
+	cs.m.Lock()
+	defer cs.m.Unlock()
+
+	dirty := make(map[ChunkPosition]*chunk.Chunk)
+	for position, stored := range cs.chunks {
+		if stored.dirty {
+			dirty[position] = stored.chunk
+			stored.dirty = false
+		}
+	}
+
+	return dirty
+}
+
+// Unload evicts clean chunks for which inUse returns false.
+func (cs *ChunkStore) Unload(inUse func(ChunkPosition) bool) int {
+	cs.m.Lock()
+	defer cs.m.Unlock()
+
+	unloaded := 0
+	for position, stored := range cs.chunks {
+		if !stored.dirty && !inUse(position) {
+			delete(cs.chunks, position)
+			unloaded++
+		}
+	}
+
+	return unloaded
+}
+
+func (cs *ChunkStore) Len() int {
+	cs.m.Lock()
+	defer cs.m.Unlock()
+
+	return len(cs.chunks)
+}
+
+// updateBlockEntity creates an empty block entity for the new block if it keeps one, and removes the block entity
+// of the previous block otherwise.
+func updateBlockEntity(c *chunk.Chunk, x, y, z int, state int, palette *WorldPalette) {
+	entityType, ok := palette.BlockEntityType(state)
+	if !ok {
+		c.RemoveBlockEntity(x, y, z)
+		return
+	}
+
+	if existing := c.GetBlockEntity(x, y, z); existing == nil || existing.Type != entityType {
+		c.SetBlockEntity(chunk.NewBlockEntity(x, y, z, entityType, nbt.RawMessage{}))
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"math"
+	"sync"
+)
+
+type ChunkPosition struct {
+	X int
+	Z int
+}
+
+// SectionPosition identifies a 16x16x16 section of a chunk, in section coordinates.
+type SectionPosition struct {
+	X int
+	Y int
+	Z int
+}
+
+func ChunkPositionAt(x, z float64) ChunkPosition {
+	return ChunkPosition{
+		X: int(math.Floor(x)) >> 4,
+		Z: int(math.Floor(z)) >> 4,
+	}
+}
+
+func (cp ChunkPosition) DistanceTo(other ChunkPosition) int {
+	dx := cp.X - other.X
+	if dx < 0 {
+		dx = -dx
+	}
+
+	dz := cp.Z - other.Z
+	if dz < 0 {
+		dz = -dz
+	}
+
+	if dx > dz {
+		return dx
+	}
+	return dz
+}
+
+type ChunkView struct {
+	m           sync.Mutex
+	initialized bool
+	center      ChunkPosition
+	distance    int
+	loaded      map[ChunkPosition]struct{}
+}
+
+type ChunkViewUpdate struct {
+	CenterChanged bool
+	Center        ChunkPosition
+	ToLoad        []ChunkPosition
+	ToUnload      []ChunkPosition
+}
+
+func NewChunkView() *ChunkView {
+	return &ChunkView{
+		loaded: make(map[ChunkPosition]struct{}),
+	}
+}
+
+// Update moves the view to the new center and distance, and returns the chunks that have to be loaded
+// (in spiral order, closest first) and the ones that fell out of the view.
+func (cv *ChunkView) Update(center ChunkPosition, distance int) *ChunkViewUpdate {
+	cv.m.Lock()
+	defer cv.m.Unlock()
+
+	update := &ChunkViewUpdate{
+		CenterChanged: !cv.initialized || center != cv.center,
+		Center:        center,
+	}
+
+	if cv.initialized && center == cv.center && distance == cv.distance {
+		return update
+	}
+
+	for position := range cv.loaded {
+		if position.DistanceTo(center) > distance {
+			update.ToUnload = append(update.ToUnload, position)
+			delete(cv.loaded, position)
+		}
+	}
+
+	for _, position := range spiralChunkOrder(center, distance) {
+		if _, ok := cv.loaded[position]; !ok {
+			update.ToLoad = append(update.ToLoad, position)
+			cv.loaded[position] = struct{}{}
+		}
+	}
+
+	cv.initialized = true
+	cv.center = center
+	cv.distance = distance
+
+	return update
+}
+
+func (cv *ChunkView) IsLoaded(position ChunkPosition) bool {
+	cv.m.Lock()
+	defer cv.m.Unlock()
+
+	_, ok := cv.loaded[position]
+	return ok
+}
+
+func (cv *ChunkView) Reset() {
+	cv.m.Lock()
+	defer cv.m.Unlock()
+
+	cv.initialized = false
+	cv.loaded = make(map[ChunkPosition]struct{})
+}
+
+func spiralChunkOrder(center ChunkPosition, distance int) []ChunkPosition {
+	size := 2*distance + 1
+	positions := make([]ChunkPosition, 0, size*size)
+	positions = append(positions, center)
+
+	for ring := 1; ring <= distance; ring++ {
+		x, z := center.X-ring, center.Z-ring
+
+		for _, step := range [][2]int{{1, 0}, {0, 1}, {-1, 0}, {0, -1}} {
+			for i := 0; i < 2*ring; i++ {
+				positions = append(positions, ChunkPosition{X: x, Z: z})
+				x += step[0]
+				z += step[1]
+			}
+		}
+	}
+
+	return positions
+}
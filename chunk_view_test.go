@@ -0,0 +1,247 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSpiralChunkOrder(t *testing.T) {
+	center := ChunkPosition{X: 5, Z: -3}
+
+	if order := spiralChunkOrder(center, 0); !reflect.DeepEqual(order, []ChunkPosition{center}) {
+		t.Fatalf("expected only the center, got %v", order)
+	}
+
+	expected := []ChunkPosition{
+		{X: 5, Z: -3},
+		{X: 4, Z: -4}, {X: 5, Z: -4}, {X: 6, Z: -4},
+		{X: 6, Z: -3}, {X: 6, Z: -2},
+		{X: 5, Z: -2}, {X: 4, Z: -2},
+		{X: 4, Z: -3},
+	}
+	if order := spiralChunkOrder(center, 1); !reflect.DeepEqual(order, expected) {
+		t.Fatalf("expected %v, got %v", expected, order)
+	}
+
+	order := spiralChunkOrder(center, 3)
+	if len(order) != 49 {
+		t.Fatalf("expected 49 chunks, got %d", len(order))
+	}
+
+	seen := make(map[ChunkPosition]struct{})
+	for i, position := range order {
+		if _, ok := seen[position]; ok {
+			t.Fatalf("chunk %v appears twice", position)
+		}
+		seen[position] = struct{}{}
+
+		if position.DistanceTo(center) > 3 {
+			t.Fatalf("chunk %v is out of view", position)
+		}
+		if i > 0 && position.DistanceTo(center) < order[i-1].DistanceTo(center) {
+			t.Fatalf("chunk %v comes after the further chunk %v", position, order[i-1])
+		}
+	}
+}
+
+type testViewUpdate struct {
+	center                ChunkPosition
+	distance              int
+	reset                 bool
+	expectedCenterChanged bool
+	expectedToLoad        []ChunkPosition
+	expectedToUnload      []ChunkPosition
+}
+
+func TestChunkViewUpdate(t *testing.T) {
+	origin := ChunkPosition{X: 0, Z: 0}
+
+	cases := []struct {
+		name    string
+		updates []testViewUpdate
+	}{
+		{
+			name: "initial view",
+			updates: []testViewUpdate{
+				{center: origin, distance: 1, expectedCenterChanged: true, expectedToLoad: chunksInRange(origin, 0, 1)},
+			},
+		},
+		{
+			name: "no change",
+			updates: []testViewUpdate{
+				{center: origin, distance: 1, expectedCenterChanged: true, expectedToLoad: chunksInRange(origin, 0, 1)},
+				{center: origin, distance: 1},
+			},
+		},
+		{
+			name: "move across a chunk border",
+			updates: []testViewUpdate{
+				{center: origin, distance: 1, expectedCenterChanged: true, expectedToLoad: chunksInRange(origin, 0, 1)},
+				{
+					center:                ChunkPosition{X: 1, Z: 0},
+					distance:              1,
+					expectedCenterChanged: true,
+					expectedToLoad:        []ChunkPosition{{X: 2, Z: -1}, {X: 2, Z: 0}, {X: 2, Z: 1}},
+					expectedToUnload:      []ChunkPosition{{X: -1, Z: -1}, {X: -1, Z: 0}, {X: -1, Z: 1}},
+				},
+			},
+		},
+		{
+			name: "diagonal move",
+			updates: []testViewUpdate{
+				{center: origin, distance: 1, expectedCenterChanged: true, expectedToLoad: chunksInRange(origin, 0, 1)},
+				{
+					center:                ChunkPosition{X: -1, Z: 1},
+					distance:              1,
+					expectedCenterChanged: true,
+					expectedToLoad: []ChunkPosition{
+						{X: -2, Z: 0}, {X: -2, Z: 1}, {X: -2, Z: 2}, {X: -1, Z: 2}, {X: 0, Z: 2},
+					},
+					expectedToUnload: []ChunkPosition{
+						{X: -1, Z: -1}, {X: 0, Z: -1}, {X: 1, Z: -1}, {X: 1, Z: 0}, {X: 1, Z: 1},
+					},
+				},
+			},
+		},
+		{
+			name: "move out of view",
+			updates: []testViewUpdate{
+				{center: origin, distance: 1, expectedCenterChanged: true, expectedToLoad: chunksInRange(origin, 0, 1)},
+				{
+					center:                ChunkPosition{X: 10, Z: -10},
+					distance:              1,
+					expectedCenterChanged: true,
+					expectedToLoad:        chunksInRange(ChunkPosition{X: 10, Z: -10}, 0, 1),
+					expectedToUnload:      chunksInRange(origin, 0, 1),
+				},
+			},
+		},
+		{
+			name: "view distance shrink",
+			updates: []testViewUpdate{
+				{center: origin, distance: 3, expectedCenterChanged: true, expectedToLoad: chunksInRange(origin, 0, 3)},
+				{center: origin, distance: 1, expectedToUnload: chunksInRange(origin, 2, 3)},
+			},
+		},
+		{
+			name: "view distance grow",
+			updates: []testViewUpdate{
+				{center: origin, distance: 1, expectedCenterChanged: true, expectedToLoad: chunksInRange(origin, 0, 1)},
+				{center: origin, distance: 3, expectedToLoad: chunksInRange(origin, 2, 3)},
+			},
+		},
+		{
+			name: "move and grow",
+			updates: []testViewUpdate{
+				{center: origin, distance: 1, expectedCenterChanged: true, expectedToLoad: chunksInRange(origin, 0, 1)},
+				{
+					center:                ChunkPosition{X: 0, Z: 1},
+					distance:              2,
+					expectedCenterChanged: true,
+					expectedToLoad:        withoutChunks(chunksInRange(ChunkPosition{X: 0, Z: 1}, 0, 2), chunksInRange(origin, 0, 1)),
+				},
+			},
+		},
+		{
+			name: "reset",
+			updates: []testViewUpdate{
+				{center: origin, distance: 1, expectedCenterChanged: true, expectedToLoad: chunksInRange(origin, 0, 1)},
+				{center: origin, distance: 1, reset: true, expectedCenterChanged: true, expectedToLoad: chunksInRange(origin, 0, 1)},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			view := NewChunkView()
+
+			for i, u := range c.updates {
+				if u.reset {
+					view.Reset()
+				}
+
+				update := view.Update(u.center, u.distance)
+
+				if update.CenterChanged != u.expectedCenterChanged || update.Center != u.center {
+					t.Fatalf("update %d: unexpected center %v (changed: %v)", i, update.Center, update.CenterChanged)
+				}
+				if !sameChunks(update.ToLoad, u.expectedToLoad) {
+					t.Fatalf("update %d: expected to load %v, got %v", i, u.expectedToLoad, update.ToLoad)
+				}
+				if !sameChunks(update.ToUnload, u.expectedToUnload) {
+					t.Fatalf("update %d: expected to unload %v, got %v", i, u.expectedToUnload, update.ToUnload)
+				}
+
+				for j := 1; j < len(update.ToLoad); j++ {
+					if update.ToLoad[j].DistanceTo(u.center) < update.ToLoad[j-1].DistanceTo(u.center) {
+						t.Fatalf("update %d: chunks are not loaded closest first: %v", i, update.ToLoad)
+					}
+				}
+
+				for _, position := range chunksInRange(u.center, 0, u.distance) {
+					if !view.IsLoaded(position) {
+						t.Fatalf("update %d: chunk %v is not loaded", i, position)
+					}
+				}
+				for _, position := range u.expectedToUnload {
+					if view.IsLoaded(position) {
+						t.Fatalf("update %d: chunk %v is still loaded", i, position)
+					}
+				}
+			}
+		})
+	}
+}
+
+// chunksInRange returns chunks whose distance from the center is between from and to (inclusive).
+func chunksInRange(center ChunkPosition, from, to int) []ChunkPosition {
+	var positions []ChunkPosition
+	for x := center.X - to; x <= center.X+to; x++ {
+		for z := center.Z - to; z <= center.Z+to; z++ {
+			position := ChunkPosition{X: x, Z: z}
+			if position.DistanceTo(center) >= from {
+				positions = append(positions, position)
+			}
+		}
+	}
+	return positions
+}
+
+func withoutChunks(positions, removed []ChunkPosition) []ChunkPosition {
+	var result []ChunkPosition
+	for _, position := range positions {
+		if !containsChunk(removed, position) {
+			result = append(result, position)
+		}
+	}
+	return result
+}
+
+func containsChunk(positions []ChunkPosition, position ChunkPosition) bool {
+	for _, p := range positions {
+		if p == position {
+			return true
+		}
+	}
+	return false
+}
+
+func sameChunks(actual, expected []ChunkPosition) bool {
+	if len(actual) != len(expected) {
+		return false
+	}
+
+	sorted := func(positions []ChunkPosition) []ChunkPosition {
+		result := append([]ChunkPosition(nil), positions...)
+		sort.Slice(result, func(i, j int) bool {
+			if result[i].X != result[j].X {
+				return result[i].X < result[j].X
+			}
+			return result[i].Z < result[j].Z
+		})
+		return result
+	}
+
+	return reflect.DeepEqual(sorted(actual), sorted(expected))
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// MaxEntityReach is the maximal distance between player's eyes and the bounding box of an entity they attack.
+	MaxEntityReach = 6.0
+
+	// PlayerAttackDamage and PlayerAttackSpeed are attributes of an unarmed player.
+	PlayerAttackDamage float32 = 1
+	PlayerAttackSpeed          = 4.0
+
+	// CriticalHitMultiplier increases damage of a fully charged attack made while falling.
+	CriticalHitMultiplier float32 = 1.5
+
+	// AttackKnockback is the strength of knockback given to every hurt target,
+	// and SprintKnockback the additional one given by a fully charged attack made while sprinting.
+	AttackKnockback = 0.4
+	SprintKnockback = 0.5
+
+	// MaxVelocity is the maximal speed (in blocks per tick) which can be sent to clients.
+	MaxVelocity = 3.9
+)
+
+type InteractType = int
+
+const (
+	InteractTypeInteract   = 0
+	InteractTypeAttack     = 1
+	InteractTypeInteractAt = 2
+)
+
+// Interaction describes a click on an entity.
+type Interaction struct {
+	EntityID int32
+	Type     InteractType
+	Hand     Hand
+	TargetX  float32
+	TargetY  float32
+	TargetZ  float32
+	Sneaking bool
+}
+
+// attackStrength returns how much the attack is charged (0-1), depending on the time since the last attack.
+func attackStrength(lastAttack time.Time) float32 {
+	cooldownTicks := 20 / PlayerAttackSpeed
+	ticks := float64(time.Since(lastAttack)) / float64(TickDuration)
+
+	strength := (ticks + 0.5) / cooldownTicks
+	if strength > 1 {
+		return 1
+	}
+	return float32(strength)
+}
+
+// attackDamage scales damage by the attack strength the way vanilla does, so spamming attacks deals little damage.
+func attackDamage(baseDamage float32, strength float32) float32 {
+	return baseDamage * (0.2 + strength*strength*0.8)
+}
+
+// knockback returns the velocity (in blocks per tick) pushing the target away from the attacker.
+// Sprinting attacks additionally push the target in the direction the attacker is looking.
+func knockback(attacker *Player, target *Player, sprinting bool) (float64, float64, float64) {
+	var vx, vy, vz float64
+
+	targetX, _, targetZ := target.Position()
+	dx, dz := attacker.X-targetX, attacker.Z-targetZ
+	if length := math.Sqrt(dx*dx + dz*dz); length >= 1e-4 {
+		vx -= dx / length * AttackKnockback
+		vz -= dz / length * AttackKnockback
+	}
+	if target.IsOnGround() {
+		vy = AttackKnockback
+	}
+
+	if sprinting {
+		yaw := float64(attacker.Yaw) * math.Pi / 180
+		vx -= math.Sin(yaw) * SprintKnockback
+		vz += math.Cos(yaw) * SprintKnockback
+	}
+
+	return vx, vy, vz
+}
+
+// encodeVelocity converts speed in blocks per tick to units of 1/8000 of a block per tick.
+func encodeVelocity(v float64) int16 {
+	if v > MaxVelocity {
+		v = MaxVelocity
+	} else if v < -MaxVelocity {
+		v = -MaxVelocity
+	}
+
+	return int16(v * 8000)
+}
+
+// distanceToBox returns the distance between the point and the nearest point of the bounding box of a player.
+func distanceToBox(x, y, z float64, player *Player) float64 {
+	playerX, playerY, playerZ := player.Position()
+	dx := distanceToRange(x, playerX-PlayerWidth/2, playerX+PlayerWidth/2)
+	dy := distanceToRange(y, playerY, playerY+PlayerHeight)
+	dz := distanceToRange(z, playerZ-PlayerWidth/2, playerZ+PlayerWidth/2)
+
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
+
+func distanceToRange(v, from, to float64) float64 {
+	if v < from {
+		return from - v
+	}
+	if v > to {
+		return v - to
+	}
+	return 0
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultConfigFile = "settings.json"
+	EnvironmentPrefix = "MC_"
+)
+
+type settingsOption struct {
+	name  string
+	usage string
+	apply func(s *Settings, value string) error
+}
+
+var settingsOptions = []settingsOption{
+	{
+		name:  "server-address",
+		usage: "address to listen on (host:port)",
+		apply: func(s *Settings, value string) error {
+			s.ServerAddress = value
+			return nil
+		},
+	},
+	{
+		name:  "server-ip",
+		usage: "host part of the listen address",
+		apply: func(s *Settings, value string) error {
+			_, port, err := net.SplitHostPort(s.ServerAddress)
+			if err != nil {
+				return err
+			}
+
+			s.ServerAddress = net.JoinHostPort(value, port)
+			return nil
+		},
+	},
+	{
+		name:  "server-port",
+		usage: "port part of the listen address",
+		apply: func(s *Settings, value string) error {
+			if _, err := strconv.Atoi(value); err != nil {
+				return err
+			}
+
+			host, _, err := net.SplitHostPort(s.ServerAddress)
+			if err != nil {
+				return err
+			}
+
+			s.ServerAddress = net.JoinHostPort(host, value)
+			return nil
+		},
+	},
+	{
+		name:  "level-name",
+		usage: "directory of the world to load",
+		apply: func(s *Settings, value string) error {
+			s.WorldDirectory = value
+			return nil
+		},
+	},
+	{
+		name:  "motd",
+		usage: "server description shown in the server list",
+		apply: func(s *Settings, value string) error {
+			s.Description = value
+			return nil
+		},
+	},
+	{
+		name:  "max-players",
+		usage: "maximum number of players",
+		apply: func(s *Settings, value string) (err error) {
+			s.MaxPlayers, err = strconv.Atoi(value)
+			return
+		},
+	},
+	{
+		name:  "online-mode",
+		usage: "verify players against Mojang session servers",
+		apply: func(s *Settings, value string) (err error) {
+			s.OnlineMode, err = strconv.ParseBool(value)
+			return
+		},
+	},
+	{
+		name:  "network-compression-threshold",
+		usage: "minimal size of a packet to be compressed (-1 disables compression)",
+		apply: func(s *Settings, value string) (err error) {
+			s.CompressionThreshold, err = strconv.Atoi(value)
+			return
+		},
+	},
+	{
+		name:  "debug",
+		usage: "send full debug info to clients",
+		apply: func(s *Settings, value string) (err error) {
+			s.IsDebug, err = strconv.ParseBool(value)
+			return
+		},
+	},
+	{
+		name:  "view-distance",
+		usage: "view distance in chunks",
+		apply: func(s *Settings, value string) (err error) {
+			s.ViewDistance, err = strconv.Atoi(value)
+			return
+		},
+	},
+	{
+		name:  "simulation-distance",
+		usage: "simulation distance in chunks",
+		apply: func(s *Settings, value string) (err error) {
+			s.SimulationDistance, err = strconv.Atoi(value)
+			return
+		},
+	},
+	{
+		name:  "keep-alive-interval",
+		usage: "interval between keep alive packets (in seconds)",
+		apply: func(s *Settings, value string) error {
+			seconds, err := strconv.Atoi(value)
+			s.KeepAliveSendInterval = time.Duration(seconds)
+			return err
+		},
+	},
+	{
+		name:  "player-timeout",
+		usage: "time after which unresponsive players are kicked (in seconds)",
+		apply: func(s *Settings, value string) error {
+			seconds, err := strconv.Atoi(value)
+			s.PlayerTimeout = time.Duration(seconds)
+			return err
+		},
+	},
+	{
+		name:  "autosave-interval",
+		usage: "interval between saves of modified chunks (in seconds, 0 disables autosave)",
+		apply: func(s *Settings, value string) error {
+			seconds, err := strconv.Atoi(value)
+			s.AutosaveInterval = time.Duration(seconds)
+			return err
+		},
+	},
+	{
+		name:  "level-type",
+		usage: "type of the generated world (minecraft:normal or minecraft:flat)",
+		apply: func(s *Settings, value string) error {
+			s.LevelType = value
+			return nil
+		},
+	},
+	{
+		name:  "generator-settings",
+		usage: "generator specific settings, e.g. superflat preset",
+		apply: func(s *Settings, value string) error {
+			s.GeneratorSettings = value
+			return nil
+		},
+	},
+	{
+		name:  "level-seed",
+		usage: "seed of the world generator (random if empty)",
+		apply: func(s *Settings, value string) error {
+			s.LevelSeed = value
+			return nil
+		},
+	},
+	{
+		name:  "death-message",
+		usage: "message shown when a player dies, {player} and {cause} are replaced with the name and cause of death",
+		apply: func(s *Settings, value string) error {
+			s.DeathMessage = value
+			return nil
+		},
+	},
+	{
+		name:  "pvp",
+		usage: "allow players to attack each other",
+		apply: func(s *Settings, value string) (err error) {
+			s.PVP, err = strconv.ParseBool(value)
+			return
+		},
+	},
+	{
+		name:  "gamemode",
+		usage: "game mode of players joining for the first time (survival, creative, adventure or spectator)",
+		apply: func(s *Settings, value string) error {
+			s.GameMode = value
+			return nil
+		},
+	},
+}
+
+// LoadSettings builds Settings by merging, in order of increasing priority: defaults, the config file
+// (JSON or server.properties), MC_* environment variables and command line flags.
+func LoadSettings(args []string) (*Settings, error) {
+	flagSet := flag.NewFlagSet("server", flag.ContinueOnError)
+	configFile := flagSet.String("config", DefaultConfigFile, "path to the config file (.json or .properties)")
+
+	flagValues := make(map[string]string)
+	for _, option := range settingsOptions {
+		name := option.name
+		flagSet.Func(name, option.usage, func(value string) error {
+			flagValues[name] = value
+			return nil
+		})
+	}
+
+	err := flagSet.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := DefaultSettings()
+
+	err = loadSettingsFile(settings, *configFile)
+	if err != nil {
+		if !os.IsNotExist(err) || isFlagPassed(flagSet, "config") {
+			return nil, err
+		}
+	}
+
+	for _, option := range settingsOptions {
+		if value, ok := os.LookupEnv(optionEnvironmentName(option.name)); ok {
+			err = option.apply(settings, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of %s: %v", optionEnvironmentName(option.name), err)
+			}
+		}
+	}
+
+	for _, option := range settingsOptions {
+		if value, ok := flagValues[option.name]; ok {
+			err = option.apply(settings, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of -%s: %v", option.name, err)
+			}
+		}
+	}
+
+	settings.normalize()
+
+	err = settings.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+func loadSettingsFile(settings *Settings, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	if filepath.Ext(path) == ".properties" {
+		err = readServerProperties(settings, file)
+	} else {
+		err = json.NewDecoder(file).Decode(settings)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	return nil
+}
+
+func readServerProperties(settings *Settings, reader io.Reader) error {
+	properties, err := parseProperties(reader)
+	if err != nil {
+		return err
+	}
+
+	for _, option := range settingsOptions {
+		if value, ok := properties[option.name]; ok && value != "" {
+			err = option.apply(settings, value)
+			if err != nil {
+				return fmt.Errorf("invalid value of %s: %v", option.name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func parseProperties(reader io.Reader) (map[string]string, error) {
+	properties := make(map[string]string)
+	scanner := bufio.NewScanner(reader)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		separator := strings.IndexAny(line, "=:")
+		if separator == -1 {
+			properties[line] = ""
+			continue
+		}
+
+		key := strings.TrimSpace(line[:separator])
+		value := strings.TrimSpace(line[separator+1:])
+		properties[key] = unescapePropertyValue(value)
+	}
+
+	return properties, scanner.Err()
+}
+
+func unescapePropertyValue(value string) string {
+	if !strings.Contains(value, "\\") {
+		return value
+	}
+
+	var builder strings.Builder
+	escaped := false
+	for _, c := range value {
+		if escaped {
+			switch c {
+			case 'n':
+				builder.WriteRune('\n')
+			case 't':
+				builder.WriteRune('\t')
+			default:
+				builder.WriteRune(c)
+			}
+			escaped = false
+			continue
+		}
+
+		if c == '\\' {
+			escaped = true
+			continue
+		}
+
+		builder.WriteRune(c)
+	}
+
+	return builder.String()
+}
+
+func optionEnvironmentName(name string) string {
+	return EnvironmentPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+func isFlagPassed(flagSet *flag.FlagSet, name string) (passed bool) {
+	flagSet.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			passed = true
+		}
+	})
+	return
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadSettings(t *testing.T) {
+	cases := []struct {
+		name     string
+		file     string
+		content  string
+		env      map[string]string
+		args     []string
+		expected func(s *Settings)
+	}{
+		{
+			name:     "defaults",
+			file:     "settings.json",
+			content:  `{}`,
+			expected: func(s *Settings) {},
+		},
+		{
+			name:    "json file overrides defaults",
+			file:    "settings.json",
+			content: `{"viewDistance": 12, "maxPlayers": 5, "pvp": false}`,
+			expected: func(s *Settings) {
+				s.ViewDistance = 12
+				s.MaxPlayers = 5
+				s.PVP = false
+			},
+		},
+		{
+			name:    "properties file overrides defaults",
+			file:    "server.properties",
+			content: "# comment\nview-distance=8\nserver-port=25570\nmotd=Hello\\nWorld\nlevel-type=default\nlevel-seed=\n",
+			expected: func(s *Settings) {
+				s.ViewDistance = 8
+				s.ServerAddress = "0.0.0.0:25570"
+				s.Description = "Hello\nWorld"
+				s.LevelType = LevelTypeNormal
+			},
+		},
+		{
+			name:    "level type without namespace in json file",
+			file:    "settings.json",
+			content: `{"levelType": "default"}`,
+			expected: func(s *Settings) {
+				s.LevelType = LevelTypeNormal
+			},
+		},
+		{
+			name:    "flat level type in json file",
+			file:    "settings.json",
+			content: `{"levelType": "FLAT"}`,
+			expected: func(s *Settings) {
+				s.LevelType = LevelTypeFlat
+			},
+		},
+		{
+			name:    "environment overrides file",
+			file:    "settings.json",
+			content: `{"viewDistance": 12, "maxPlayers": 5}`,
+			env:     map[string]string{"MC_VIEW_DISTANCE": "14", "MC_GAMEMODE": "creative"},
+			expected: func(s *Settings) {
+				s.ViewDistance = 14
+				s.MaxPlayers = 5
+				s.GameMode = "creative"
+			},
+		},
+		{
+			name:    "flags override environment",
+			file:    "settings.json",
+			content: `{"viewDistance": 12, "maxPlayers": 5}`,
+			env:     map[string]string{"MC_VIEW_DISTANCE": "14", "MC_SERVER_IP": "127.0.0.1"},
+			args:    []string{"-view-distance=16", "-server-port", "25580"},
+			expected: func(s *Settings) {
+				s.ViewDistance = 16
+				s.MaxPlayers = 5
+				s.ServerAddress = "127.0.0.1:25580"
+			},
+		},
+		{
+			name:    "view distance too small",
+			file:    "settings.json",
+			content: `{}`,
+			args:    []string{"-view-distance=1"},
+		},
+		{
+			name:    "view distance too large",
+			file:    "settings.json",
+			content: `{"viewDistance": 33}`,
+		},
+		{
+			name:    "invalid port",
+			file:    "settings.json",
+			content: `{}`,
+			args:    []string{"-server-port=abc"},
+		},
+		{
+			name:    "invalid port in environment",
+			file:    "settings.json",
+			content: `{}`,
+			env:     map[string]string{"MC_SERVER_PORT": "abc"},
+		},
+		{
+			name:    "invalid server address",
+			file:    "settings.json",
+			content: `{"serverAddress": "localhost"}`,
+		},
+		{
+			name:    "no players allowed",
+			file:    "server.properties",
+			content: "max-players=0\n",
+		},
+		{
+			name:    "invalid number in properties",
+			file:    "server.properties",
+			content: "view-distance=far\n",
+		},
+		{
+			name:    "unsupported game mode",
+			file:    "settings.json",
+			content: `{}`,
+			args:    []string{"-gamemode=hardcore"},
+		},
+		{
+			name:    "unsupported level type",
+			file:    "settings.json",
+			content: `{"levelType": "minecraft:amplified"}`,
+		},
+		{
+			name:    "player timeout shorter than keep alive interval",
+			file:    "settings.json",
+			content: `{"keepAliveSendInterval": 10, "playerTimeout": 10}`,
+		},
+		{
+			name:    "malformed json",
+			file:    "settings.json",
+			content: `{"viewDistance": }`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), c.file)
+			err := os.WriteFile(path, []byte(c.content), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for name, value := range c.env {
+				t.Setenv(name, value)
+			}
+
+			settings, err := LoadSettings(append([]string{"-config", path}, c.args...))
+
+			if c.expected == nil {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", settings)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := DefaultSettings()
+			c.expected(expected)
+			if !reflect.DeepEqual(settings, expected) {
+				t.Errorf("expected %+v, got %+v", expected, settings)
+			}
+		})
+	}
+}
+
+func TestLoadSettingsMissingConfig(t *testing.T) {
+	_, err := LoadSettings([]string{"-config", filepath.Join(t.TempDir(), "settings.json")})
+	if err == nil {
+		t.Fatalf("expected an error for a missing config file passed explicitly")
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import "fmt"
+
+type MenuType = int
+
+// Menu types of the Open Screen packet, as in the minecraft:menu registry.
+const (
+	MenuGeneric9x1       = 0
+	MenuGeneric9x2       = 1
+	MenuGeneric9x3       = 2
+	MenuGeneric9x4       = 3
+	MenuGeneric9x5       = 4
+	MenuGeneric9x6       = 5
+	MenuGeneric3x3       = 6
+	MenuAnvil            = 7
+	MenuBeacon           = 8
+	MenuBlastFurnace     = 9
+	MenuBrewingStand     = 10
+	MenuCrafting         = 11
+	MenuEnchantment      = 12
+	MenuFurnace          = 13
+	MenuGrindstone       = 14
+	MenuHopper           = 15
+	MenuLectern          = 16
+	MenuLoom             = 17
+	MenuMerchant         = 18
+	MenuShulkerBox       = 19
+	MenuSmithing         = 20
+	MenuSmoker           = 21
+	MenuCartographyTable = 22
+	MenuStonecutter      = 23
+)
+
+// MaxWindowID is the highest ID given to container windows. IDs are reused in a cycle, like in vanilla.
+const MaxWindowID = 100
+
+type menuLayout struct {
+	// size is the number of slots of the container, shown above the player inventory.
+	size int
+	// result is the slot from which items can only be taken, -1 if there is none.
+	result int
+}
+
+var menuLayouts = map[MenuType]menuLayout{
+	MenuGeneric9x1:       {size: 9, result: -1},
+	MenuGeneric9x2:       {size: 18, result: -1},
+	MenuGeneric9x3:       {size: 27, result: -1},
+	MenuGeneric9x4:       {size: 36, result: -1},
+	MenuGeneric9x5:       {size: 45, result: -1},
+	MenuGeneric9x6:       {size: 54, result: -1},
+	MenuGeneric3x3:       {size: 9, result: -1},
+	MenuAnvil:            {size: 3, result: 2},
+	MenuBeacon:           {size: 1, result: -1},
+	MenuBlastFurnace:     {size: 3, result: 2},
+	MenuBrewingStand:     {size: 5, result: -1},
+	MenuCrafting:         {size: 10, result: 0},
+	MenuEnchantment:      {size: 2, result: -1},
+	MenuFurnace:          {size: 3, result: 2},
+	MenuGrindstone:       {size: 3, result: 2},
+	MenuHopper:           {size: 5, result: -1},
+	MenuLectern:          {size: 1, result: -1},
+	MenuLoom:             {size: 4, result: 3},
+	MenuMerchant:         {size: 3, result: 2},
+	MenuShulkerBox:       {size: 27, result: -1},
+	MenuSmithing:         {size: 3, result: 2},
+	MenuSmoker:           {size: 3, result: 2},
+	MenuCartographyTable: {size: 3, result: 2},
+	MenuStonecutter:      {size: 2, result: 1},
+}
+
+// MenuSize returns the number of slots of the inventory shown in a window of given type.
+func MenuSize(menuType MenuType) (int, bool) {
+	layout, ok := menuLayouts[menuType]
+	return layout.size, ok
+}
+
+// newContainerWindow creates a window showing the inventory above the main inventory and hotbar of the player.
+// Shift clicks move items between the two parts.
+func newContainerWindow(id byte, player *Player, menuType MenuType, title *ChatMessage, inventory *Inventory) (*Window, error) {
+	layout, ok := menuLayouts[menuType]
+	if !ok {
+		return nil, fmt.Errorf("unknown menu type: %d", menuType)
+	}
+	if inventory.Size() != layout.size {
+		return nil, fmt.Errorf("menu type %d needs an inventory of %d slots, got %d", menuType, layout.size, inventory.Size())
+	}
+
+	slots := make([]windowSlot, 0, layout.size+InventorySlotOffhand-InventorySlotMainFirst)
+	for i := 0; i < layout.size; i++ {
+		kind := slotKindNormal
+		if i == layout.result {
+			kind = slotKindResult
+		}
+
+		slots = append(slots, windowSlot{inventory: inventory, index: i, kind: kind})
+	}
+
+	// lecterns show only the book
+	if menuType != MenuLectern {
+		for i := InventorySlotMainFirst; i < InventorySlotOffhand; i++ {
+			slots = append(slots, windowSlot{inventory: player.inventory, index: i, kind: slotKindNormal})
+		}
+	}
+
+	container := slotRange(0, layout.size)
+	playerSlots := slotRange(layout.size, len(slots))
+	quickMove := func(slot int) []int {
+		if slot < layout.size {
+			return reversed(playerSlots)
+		}
+		return container
+	}
+
+	// items from the player inventory go between the main inventory and the hotbar instead of the crafting grid
+	if menuType == MenuCrafting {
+		main, hotbar := playerSlots[:27], playerSlots[27:]
+		quickMove = func(slot int) []int {
+			switch {
+			case slot < layout.size:
+				return reversed(playerSlots)
+			case slot < layout.size+len(main):
+				return hotbar
+			default:
+				return main
+			}
+		}
+	}
+
+	window := newWindow(id, player, slots, quickMove)
+	window.Type = menuType
+	window.Title = title
+
+	if menuType == MenuCrafting {
+		window.crafting = newCraftingGrid(player, 3, 1, 0)
+	}
+
+	return window, nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"github.com/mkorman9/go-minecraft-server/recipes"
+	"github.com/mkorman9/go-minecraft-server/types"
+)
+
+// CraftingTableSize is the number of slots of the crafting table: the result followed by the 3x3 grid.
+const CraftingTableSize = 10
+
+// maxCraftsPerClick limits crafting with a shift click, which can't use up more than a stack of ingredients.
+const maxCraftsPerClick = 64
+
+// craftingGrid describes the crafting slots of a window.
+type craftingGrid struct {
+	// size is the width and height of the grid.
+	size int
+	// grid lists window slots of the grid row by row.
+	grid    []int
+	result  int
+	recipes *recipes.Registry
+}
+
+func (cg *craftingGrid) inGrid(slot int) bool {
+	for _, gridSlot := range cg.grid {
+		if gridSlot == slot {
+			return true
+		}
+	}
+	return false
+}
+
+// updateCraftingResult puts the result of the recipe matching the grid into the result slot.
+// Windows are locked by the caller.
+func (w *Window) updateCraftingResult() {
+	result := w.craftingResult()
+
+	slot := w.slots[w.crafting.result]
+	if !sameStack(result, slot.inventory.get(slot.index)) {
+		slot.inventory.set(slot.index, result, nil)
+	}
+}
+
+func (w *Window) craftingResult() *types.SlotData {
+	grid := make([]int, len(w.crafting.grid))
+	for i, slot := range w.crafting.grid {
+		if item := w.get(slot); item != nil {
+			grid[i] = item.ItemID
+		}
+	}
+
+	recipe, ok := w.crafting.recipes.MatchCrafting(grid, w.crafting.size)
+	if !ok {
+		return nil
+	}
+
+	return &types.SlotData{
+		Present:   true,
+		ItemID:    recipe.Result.ItemID,
+		ItemCount: byte(recipe.Result.Count),
+	}
+}
+
+// resultTaken uses up one item from every slot of the crafting grid, after the crafted item was taken.
+func (w *Window) resultTaken(slot int) {
+	if w.crafting == nil || slot != w.crafting.result {
+		return
+	}
+
+	for _, gridSlot := range w.crafting.grid {
+		if item := w.get(gridSlot); item != nil {
+			w.set(gridSlot, withCount(item, int(item.ItemCount)-1))
+		}
+	}
+}
+
+// craftAll crafts the result repeatedly and moves it to the player inventory, until the ingredients run out
+// or the inventory is full.
+func (w *Window) craftAll() {
+	result := w.crafting.result
+	targets := w.quickMove(result)
+
+	for i := 0; i < maxCraftsPerClick; i++ {
+		item := w.get(result)
+		if item == nil || w.room(item, targets) < int(item.ItemCount) {
+			return
+		}
+
+		w.fill(item, targets)
+		w.set(result, nil)
+		w.resultTaken(result)
+		w.set(result, w.craftingResult())
+	}
+}
+
+// newCraftingGrid returns the crafting grid of the player inventory or the crafting table window.
+func newCraftingGrid(player *Player, size int, first int, result int) *craftingGrid {
+	return &craftingGrid{
+		size:    size,
+		grid:    slotRange(first, first+size*size),
+		result:  result,
+		recipes: player.world.Data().Recipes,
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"github.com/mkorman9/go-minecraft-server/chunk"
+	"strings"
+	"time"
+)
+
+const (
+	MaxHealth         float32 = 20
+	MaxFood                   = 20
+	DefaultSaturation float32 = 5
+)
+
+// DamageInvulnerability is the time after being hurt during which only damage greater than the last one
+// hurts the player, by the difference (10 ticks, like in vanilla).
+const DamageInvulnerability = 10 * TickDuration
+
+// VoidDamage is the damage dealt every time a player moves while being below VoidDamageY.
+const (
+	VoidDamage  float32 = 4
+	VoidDamageY         = chunk.MinY - 64
+)
+
+// DamageSource describes what dealt the damage.
+type DamageSource struct {
+	Type     string
+	Attacker *Player
+}
+
+const (
+	DamageTypeGeneric = "generic"
+	DamageTypeFall    = "fall"
+	DamageTypeVoid    = "outOfWorld"
+	DamageTypePlayer  = "player"
+	DamageTypeKill    = "kill"
+)
+
+var (
+	DamageGeneric = &DamageSource{Type: DamageTypeGeneric}
+	DamageFall    = &DamageSource{Type: DamageTypeFall}
+	DamageVoid    = &DamageSource{Type: DamageTypeVoid}
+	DamageKill    = &DamageSource{Type: DamageTypeKill}
+)
+
+func DamageByPlayer(attacker *Player) *DamageSource {
+	return &DamageSource{Type: DamageTypePlayer, Attacker: attacker}
+}
+
+// BypassesInvulnerability tells whether the damage hurts players in creative and spectator mode.
+func (ds *DamageSource) BypassesInvulnerability() bool {
+	return ds.Type == DamageTypeVoid || ds.Type == DamageTypeKill
+}
+
+// Cause returns the part of the death message describing the source.
+func (ds *DamageSource) Cause() string {
+	switch ds.Type {
+	case DamageTypeFall:
+		return "hit the ground too hard"
+	case DamageTypeVoid:
+		return "fell out of the world"
+	case DamageTypeKill:
+		return "was killed"
+	case DamageTypePlayer:
+		if ds.Attacker != nil {
+			return "was slain by " + ds.Attacker.Name
+		}
+	}
+
+	return "died"
+}
+
+// DeathMessage fills the template from settings with name of the player and the cause of death.
+func DeathMessage(template string, player *Player, source *DamageSource) string {
+	return strings.NewReplacer("{player}", player.Name, "{cause}", source.Cause()).Replace(template)
+}
+
+// lastDamage holds the damage taken recently, used to apply DamageInvulnerability.
+type lastDamage struct {
+	amount float32
+	at     time.Time
+}
@@ -1,9 +1,17 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
 	"encoding/json"
+	"github.com/mkorman9/go-minecraft-server/blocks"
+	"github.com/mkorman9/go-minecraft-server/items"
+	"github.com/mkorman9/go-minecraft-server/recipes"
 	"github.com/mkorman9/go-minecraft-server/types"
+	"math/rand"
 	"os"
+	"strconv"
+	"time"
 )
 
 type Data struct {
@@ -16,6 +24,10 @@ type Data struct {
 	HashedSeed          int64
 	EnableRespawnScreen bool
 	IsFlat              bool
+	Blocks              *blocks.Registry
+	Items               *items.Registry
+	ItemTags            *items.Tags
+	Recipes             *recipes.Registry
 }
 
 func LoadData() (*Data, error) {
@@ -40,5 +52,53 @@ func LoadData() (*Data, error) {
 		return nil, err
 	}
 
+	data.Blocks, err = blocks.LoadRegistry("./data/1_19/blocks.json", "./data/1_19/block_metadata.json")
+	if err != nil {
+		return nil, err
+	}
+
+	data.Items, err = items.LoadRegistry("./data/1_19/items.json")
+	if err != nil {
+		return nil, err
+	}
+
+	data.ItemTags, err = items.LoadTags("./data/1_19/tags/items", data.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	data.Recipes, err = recipes.LoadRegistry("./data/1_19/recipes", data.Items, data.ItemTags)
+	if err != nil {
+		return nil, err
+	}
+
 	return &data, nil
 }
+
+// ParseSeed converts level-seed into a number the same way the vanilla server does: numbers are used as they are,
+// other strings are hashed with Java's String.hashCode. Empty seed is replaced with a random one.
+func ParseSeed(value string) (seed int64, random bool) {
+	if value == "" {
+		return rand.New(rand.NewSource(time.Now().UnixNano())).Int63(), true
+	}
+
+	if seed, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return seed, false
+	}
+
+	var hash int32
+	for _, c := range value {
+		hash = 31*hash + c
+	}
+
+	return int64(hash), false
+}
+
+// HashSeed returns first 8 bytes of SHA-256 of the seed, which is what clients receive as the hashed seed.
+func HashSeed(seed int64) int64 {
+	var bytes [8]byte
+	binary.LittleEndian.PutUint64(bytes[:], uint64(seed))
+
+	hash := sha256.Sum256(bytes[:])
+	return int64(binary.LittleEndian.Uint64(hash[:8]))
+}
@@ -13,6 +13,17 @@ type RegistryValue[E any] struct {
 	Element E      `json:"element" nbt:"element"`
 }
 
+// ID returns the ID of the entry with given name.
+func (r *Registry[E]) ID(name string) (int32, bool) {
+	for _, value := range r.Value {
+		if value.Name == name {
+			return value.ID, true
+		}
+	}
+
+	return 0, false
+}
+
 type DimensionCodec struct {
 	DimensionType Registry[Dimension]     `json:"dimension_type" nbt:"minecraft:dimension_type"`
 	WorldGenBiome Registry[WorldGenBiome] `json:"world_gen_biome" nbt:"minecraft:worldgen/biome"`
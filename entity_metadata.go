@@ -0,0 +1,161 @@
+package main
+
+import (
+	"github.com/mkorman9/go-minecraft-server/types"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Indexes of metadata entries shared by all entities.
+const (
+	MetadataIndexFlags = 0
+	MetadataIndexPose  = 6
+)
+
+// Indexes of metadata entries of living entities.
+const (
+	MetadataIndexHealth = 9
+)
+
+const (
+	EntityFlagOnFire           byte = 0x01
+	EntityFlagCrouching        byte = 0x02
+	EntityFlagSprinting        byte = 0x08
+	EntityFlagSwimming         byte = 0x10
+	EntityFlagInvisible        byte = 0x20
+	EntityFlagGlowing          byte = 0x40
+	EntityFlagFlyingWithElytra byte = 0x80
+)
+
+type Pose = int
+
+const (
+	PoseStanding   = 0
+	PoseFallFlying = 1
+	PoseSleeping   = 2
+	PoseSwimming   = 3
+	PoseSpinAttack = 4
+	PoseSneaking   = 5
+	PoseLongJump   = 6
+	PoseDying      = 7
+)
+
+// EntityMetadata holds the metadata entries of an entity that differ from the defaults,
+// and remembers which of them changed since the viewers were last updated.
+type EntityMetadata struct {
+	m       sync.Mutex
+	entries map[byte]types.MetadataEntry
+	changed map[byte]struct{}
+}
+
+func NewEntityMetadata() *EntityMetadata {
+	return &EntityMetadata{
+		entries: make(map[byte]types.MetadataEntry),
+		changed: make(map[byte]struct{}),
+	}
+}
+
+// Set stores the value of an entry, and marks it as changed if it differs from the current one.
+func (em *EntityMetadata) Set(index byte, valueType int, value any) {
+	em.m.Lock()
+	defer em.m.Unlock()
+
+	em.set(index, valueType, value)
+}
+
+func (em *EntityMetadata) Byte(index byte) byte {
+	em.m.Lock()
+	defer em.m.Unlock()
+
+	value, _ := em.entries[index].Value.(byte)
+	return value
+}
+
+// SetFlag enables or disables a bit of the entity flags.
+func (em *EntityMetadata) SetFlag(flag byte, enabled bool) {
+	em.m.Lock()
+	defer em.m.Unlock()
+
+	flags, _ := em.entries[MetadataIndexFlags].Value.(byte)
+	if enabled {
+		flags |= flag
+	} else {
+		flags &^= flag
+	}
+
+	em.set(MetadataIndexFlags, types.MetadataTypeByte, flags)
+}
+
+func (em *EntityMetadata) HasFlag(flag byte) bool {
+	return em.Byte(MetadataIndexFlags)&flag != 0
+}
+
+func (em *EntityMetadata) SetPose(pose Pose) {
+	em.Set(MetadataIndexPose, types.MetadataTypePose, pose)
+}
+
+// Reset brings back the default values of all entries.
+func (em *EntityMetadata) Reset() {
+	em.m.Lock()
+	defer em.m.Unlock()
+
+	em.entries = make(map[byte]types.MetadataEntry)
+	em.changed = make(map[byte]struct{})
+}
+
+// All returns every stored entry, to be sent along with spawning of the entity. Returns nil if there are none.
+func (em *EntityMetadata) All() *types.Metadata {
+	em.m.Lock()
+	defer em.m.Unlock()
+
+	indexes := make([]byte, 0, len(em.entries))
+	for index := range em.entries {
+		indexes = append(indexes, index)
+	}
+
+	return em.collect(indexes)
+}
+
+// TakeChanges returns the entries changed since the last call and clears them. Returns nil if there are none.
+func (em *EntityMetadata) TakeChanges() *types.Metadata {
+	em.m.Lock()
+	defer em.m.Unlock()
+
+	indexes := make([]byte, 0, len(em.changed))
+	for index := range em.changed {
+		indexes = append(indexes, index)
+	}
+	em.changed = make(map[byte]struct{})
+
+	return em.collect(indexes)
+}
+
+func (em *EntityMetadata) set(index byte, valueType int, value any) {
+	current, ok := em.entries[index]
+	if ok && current.Type == valueType && reflect.DeepEqual(current.Value, value) {
+		return
+	}
+
+	em.entries[index] = types.MetadataEntry{Index: index, Type: valueType, Value: value}
+	em.changed[index] = struct{}{}
+}
+
+func (em *EntityMetadata) collect(indexes []byte) *types.Metadata {
+	if len(indexes) == 0 {
+		return nil
+	}
+
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i] < indexes[j]
+	})
+
+	metadata := &types.Metadata{
+		Entries: make([]types.MetadataEntry, 0, len(indexes)),
+	}
+	for _, index := range indexes {
+		metadata.Entries = append(metadata.Entries, em.entries[index])
+	}
+
+	return metadata
+}
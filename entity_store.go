@@ -1,37 +1,282 @@
 package main
 
 import (
-	"math/rand"
+	"github.com/mkorman9/go-minecraft-server/types"
+	"math"
 	"sync"
+	"time"
 )
 
+// EntityIDReuseDelay is the time after which an ID of a removed entity may be given to a new one.
+// It lets the clients process removal of the old entity before the ID appears again.
+const EntityIDReuseDelay = 10 * time.Second
+
+// Entity is anything that exists in the world and can be seen by players, like a player, a mob or a dropped item.
+type Entity interface {
+	ID() int32
+	UniqueID() types.UUID
+	Position() (x, y, z float64)
+	Metadata() *EntityMetadata
+}
+
+// EntityStore holds entities of the world, indexed by ID and UUID and bucketed by chunk.
+// It also tracks which players have which chunks loaded.
 type EntityStore struct {
-	store map[int32]struct{}
-	m     sync.Mutex
+	m       sync.RWMutex
+	nextID  int32
+	freeIDs []releasedID
+	byID    map[int32]Entity
+	byUUID  map[types.UUID]Entity
+	chunks  map[int32]ChunkPosition
+	byChunk map[ChunkPosition]map[int32]Entity
+	viewers map[ChunkPosition]map[int32]*Player
+}
+
+type releasedID struct {
+	id         int32
+	releasedAt time.Time
 }
 
 func NewEntityStore() *EntityStore {
 	return &EntityStore{
-		store: make(map[int32]struct{}),
+		nextID:  1,
+		byID:    make(map[int32]Entity),
+		byUUID:  make(map[types.UUID]Entity),
+		chunks:  make(map[int32]ChunkPosition),
+		byChunk: make(map[ChunkPosition]map[int32]Entity),
+		viewers: make(map[ChunkPosition]map[int32]*Player),
+	}
+}
+
+// AllocateID returns a new entity ID. IDs of removed entities are reused once EntityIDReuseDelay has passed,
+// or earlier if all the other IDs are exhausted.
+func (es *EntityStore) AllocateID() int32 {
+	es.m.Lock()
+	defer es.m.Unlock()
+
+	if len(es.freeIDs) > 0 && (time.Since(es.freeIDs[0].releasedAt) >= EntityIDReuseDelay || es.nextID == math.MaxInt32) {
+		id := es.freeIDs[0].id
+		es.freeIDs = es.freeIDs[1:]
+		return id
 	}
+
+	if es.nextID == math.MaxInt32 {
+		panic("entity IDs exhausted")
+	}
+
+	id := es.nextID
+	es.nextID++
+	return id
+}
+
+// Add registers the entity, whose ID has to be allocated with AllocateID.
+// Returns false if an entity with the same ID or UUID is already registered.
+func (es *EntityStore) Add(entity Entity) bool {
+	es.m.Lock()
+	defer es.m.Unlock()
+
+	if _, ok := es.byID[entity.ID()]; ok {
+		return false
+	}
+	if _, ok := es.byUUID[entity.UniqueID()]; ok {
+		return false
+	}
+
+	es.byID[entity.ID()] = entity
+	es.byUUID[entity.UniqueID()] = entity
+	es.placeInChunk(entity, entityChunk(entity))
+
+	return true
 }
 
-func (es *EntityStore) GenerateID() int32 {
+// Remove unregisters the entity and releases its ID.
+func (es *EntityStore) Remove(entity Entity) {
 	es.m.Lock()
 	defer es.m.Unlock()
 
-	for {
-		value := rand.Int31()
-		if _, ok := es.store[value]; !ok {
-			es.store[value] = struct{}{}
-			return value
+	id := entity.ID()
+	if current, ok := es.byID[id]; !ok || current != entity {
+		return
+	}
+
+	delete(es.byID, id)
+	delete(es.byUUID, entity.UniqueID())
+	es.removeFromChunk(id)
+
+	for position, viewers := range es.viewers {
+		delete(viewers, id)
+		if len(viewers) == 0 {
+			delete(es.viewers, position)
 		}
 	}
+
+	es.freeIDs = append(es.freeIDs, releasedID{id: id, releasedAt: time.Now()})
+}
+
+// Move puts the entity into the bucket of the chunk it's currently in.
+func (es *EntityStore) Move(entity Entity) {
+	es.m.Lock()
+	defer es.m.Unlock()
+
+	id := entity.ID()
+	if _, ok := es.byID[id]; !ok {
+		return
+	}
+
+	position := entityChunk(entity)
+	if current, ok := es.chunks[id]; ok && current == position {
+		return
+	}
+
+	es.removeFromChunk(id)
+	es.placeInChunk(entity, position)
 }
 
-func (es *EntityStore) RemoveID(id int32) {
+// UpdateView records the chunks loaded and unloaded by the player, so its viewers can be found quickly.
+func (es *EntityStore) UpdateView(player *Player, loaded []ChunkPosition, unloaded []ChunkPosition) {
 	es.m.Lock()
 	defer es.m.Unlock()
 
-	delete(es.store, id)
+	id := player.ID()
+	if _, ok := es.byID[id]; !ok {
+		return
+	}
+
+	for _, position := range unloaded {
+		viewers := es.viewers[position]
+		delete(viewers, id)
+		if len(viewers) == 0 {
+			delete(es.viewers, position)
+		}
+	}
+
+	for _, position := range loaded {
+		viewers, ok := es.viewers[position]
+		if !ok {
+			viewers = make(map[int32]*Player)
+			es.viewers[position] = viewers
+		}
+		viewers[id] = player
+	}
+}
+
+// ResetView forgets all chunks loaded by the player.
+func (es *EntityStore) ResetView(player *Player) {
+	es.m.Lock()
+	defer es.m.Unlock()
+
+	for position, viewers := range es.viewers {
+		delete(viewers, player.ID())
+		if len(viewers) == 0 {
+			delete(es.viewers, position)
+		}
+	}
+}
+
+func (es *EntityStore) Len() int {
+	es.m.RLock()
+	defer es.m.RUnlock()
+
+	return len(es.byID)
+}
+
+func (es *EntityStore) ByID(id int32) (Entity, bool) {
+	es.m.RLock()
+	defer es.m.RUnlock()
+
+	entity, ok := es.byID[id]
+	return entity, ok
+}
+
+func (es *EntityStore) ByUUID(uuid types.UUID) (Entity, bool) {
+	es.m.RLock()
+	defer es.m.RUnlock()
+
+	entity, ok := es.byUUID[uuid]
+	return entity, ok
+}
+
+// InChunk calls the handler for every entity in the chunk.
+// The handler must not modify the store.
+func (es *EntityStore) InChunk(position ChunkPosition, handler func(Entity)) {
+	es.m.RLock()
+	defer es.m.RUnlock()
+
+	for _, entity := range es.byChunk[position] {
+		handler(entity)
+	}
+}
+
+// InRadius calls the handler for every entity within given distance from the point.
+// The handler must not modify the store.
+func (es *EntityStore) InRadius(x, y, z float64, radius float64, handler func(Entity)) {
+	es.m.RLock()
+	defer es.m.RUnlock()
+
+	from := ChunkPositionAt(x-radius, z-radius)
+	to := ChunkPositionAt(x+radius, z+radius)
+
+	for chunkX := from.X; chunkX <= to.X; chunkX++ {
+		for chunkZ := from.Z; chunkZ <= to.Z; chunkZ++ {
+			for _, entity := range es.byChunk[ChunkPosition{X: chunkX, Z: chunkZ}] {
+				entityX, entityY, entityZ := entity.Position()
+				dx, dy, dz := entityX-x, entityY-y, entityZ-z
+
+				if dx*dx+dy*dy+dz*dz <= radius*radius {
+					handler(entity)
+				}
+			}
+		}
+	}
+}
+
+// ViewersOf calls the handler for every player who has the chunk loaded.
+// The handler must not modify the store.
+func (es *EntityStore) ViewersOf(position ChunkPosition, handler func(*Player)) {
+	es.m.RLock()
+	defer es.m.RUnlock()
+
+	for _, player := range es.viewers[position] {
+		handler(player)
+	}
+}
+
+// IsViewed tells whether any player has the chunk loaded.
+func (es *EntityStore) IsViewed(position ChunkPosition) bool {
+	es.m.RLock()
+	defer es.m.RUnlock()
+
+	_, ok := es.viewers[position]
+	return ok
+}
+
+func (es *EntityStore) placeInChunk(entity Entity, position ChunkPosition) {
+	entities, ok := es.byChunk[position]
+	if !ok {
+		entities = make(map[int32]Entity)
+		es.byChunk[position] = entities
+	}
+
+	entities[entity.ID()] = entity
+	es.chunks[entity.ID()] = position
+}
+
+func (es *EntityStore) removeFromChunk(id int32) {
+	position, ok := es.chunks[id]
+	if !ok {
+		return
+	}
+
+	entities := es.byChunk[position]
+	delete(entities, id)
+	if len(entities) == 0 {
+		delete(es.byChunk, position)
+	}
+
+	delete(es.chunks, id)
+}
+
+func entityChunk(entity Entity) ChunkPosition {
+	x, _, z := entity.Position()
+	return ChunkPositionAt(x, z)
 }
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"math"
+	"sync"
+)
+
+// PlayerTrackingRange is the maximal horizontal distance (in blocks) from which other players are visible,
+// the same as the vanilla default. Players are also hidden when their chunk is not loaded by the viewer.
+const PlayerTrackingRange = 48
+
+// EntityTracker keeps track of which players see each other, and sends spawn, movement and removal packets
+// to the viewers in range.
+type EntityTracker struct {
+	m       sync.Mutex
+	tracked map[*Player]*trackedEntity
+}
+
+// trackedEntity holds the last position and rotation sent to the viewers, encoded the way the protocol
+// does it, so the relative moves never drift from what the clients think.
+type trackedEntity struct {
+	x       int64
+	y       int64
+	z       int64
+	yaw     byte
+	pitch   byte
+	viewers map[*Player]struct{}
+}
+
+func NewEntityTracker() *EntityTracker {
+	return &EntityTracker{
+		tracked: make(map[*Player]*trackedEntity),
+	}
+}
+
+// Track starts tracking the player and spawns it for the players in range.
+func (et *EntityTracker) Track(player *Player) {
+	et.m.Lock()
+	defer et.m.Unlock()
+
+	if _, ok := et.tracked[player]; ok {
+		return
+	}
+
+	entity := &trackedEntity{
+		viewers: make(map[*Player]struct{}),
+	}
+	entity.sync(player)
+	et.tracked[player] = entity
+
+	et.updateViewers(player)
+}
+
+// Update broadcasts the movement of the player to its viewers, and spawns or removes players
+// that entered or left the range.
+func (et *EntityTracker) Update(player *Player) {
+	et.m.Lock()
+	defer et.m.Unlock()
+
+	entity, ok := et.tracked[player]
+	if !ok {
+		return
+	}
+
+	et.broadcastMovement(player, entity)
+	et.updateViewers(player)
+}
+
+// Untrack removes the player from its viewers and stops tracking it.
+func (et *EntityTracker) Untrack(player *Player) {
+	et.m.Lock()
+	defer et.m.Unlock()
+
+	entity, ok := et.tracked[player]
+	if !ok {
+		return
+	}
+
+	delete(et.tracked, player)
+
+	for viewer := range entity.viewers {
+		viewer.SendRemoveEntities([]int32{player.EntityID})
+	}
+
+	for _, other := range et.tracked {
+		delete(other.viewers, player)
+	}
+}
+
+// UpdateMetadata sends the metadata entries of the player changed since the last update to its viewers
+// and to the player itself.
+func (et *EntityTracker) UpdateMetadata(player *Player) {
+	et.m.Lock()
+	defer et.m.Unlock()
+
+	entity, ok := et.tracked[player]
+	if !ok {
+		return
+	}
+
+	metadata := player.Metadata().TakeChanges()
+	if metadata == nil {
+		return
+	}
+
+	player.SendEntityMetadata(player.EntityID, metadata)
+	for viewer := range entity.viewers {
+		viewer.SendEntityMetadata(player.EntityID, metadata)
+	}
+}
+
+// BroadcastAnimation plays the animation of the player for its viewers.
+// The player itself is skipped, as its client animates on its own.
+func (et *EntityTracker) BroadcastAnimation(player *Player, animation EntityAnimation) {
+	et.m.Lock()
+	defer et.m.Unlock()
+
+	entity, ok := et.tracked[player]
+	if !ok {
+		return
+	}
+
+	for viewer := range entity.viewers {
+		viewer.SendEntityAnimation(player.EntityID, animation)
+	}
+}
+
+// BroadcastEvent sends the entity event of the player to its viewers and to the player itself.
+func (et *EntityTracker) BroadcastEvent(player *Player, status EntityStatus) {
+	et.m.Lock()
+	defer et.m.Unlock()
+
+	entity, ok := et.tracked[player]
+	if !ok {
+		return
+	}
+
+	player.SendEntityEvent(player.EntityID, status)
+	for viewer := range entity.viewers {
+		viewer.SendEntityEvent(player.EntityID, status)
+	}
+}
+
+// BroadcastVelocity sends the velocity of the player to the player itself and to its viewers.
+func (et *EntityTracker) BroadcastVelocity(player *Player, vx, vy, vz int16) {
+	et.m.Lock()
+	defer et.m.Unlock()
+
+	entity, ok := et.tracked[player]
+	if !ok {
+		return
+	}
+
+	player.SendEntityVelocity(player.EntityID, vx, vy, vz)
+	for viewer := range entity.viewers {
+		viewer.SendEntityVelocity(player.EntityID, vx, vy, vz)
+	}
+}
+
+func (et *EntityTracker) broadcastMovement(player *Player, entity *trackedEntity) {
+	x, y, z := encodePosition(player.X), encodePosition(player.Y), encodePosition(player.Z)
+	yaw, pitch := encodeAngle(player.Yaw), encodeAngle(player.Pitch)
+
+	dx, dy, dz := x-entity.x, y-entity.y, z-entity.z
+	moved := dx != 0 || dy != 0 || dz != 0
+	rotated := yaw != entity.yaw || pitch != entity.pitch
+	teleported := !fitsInt16(dx) || !fitsInt16(dy) || !fitsInt16(dz)
+
+	if !moved && !rotated {
+		return
+	}
+
+	for viewer := range entity.viewers {
+		switch {
+		case teleported:
+			viewer.SendTeleportEntity(player.EntityID, player.X, player.Y, player.Z, yaw, pitch, player.OnGround)
+		case moved && rotated:
+			viewer.SendEntityPositionAndRotation(player.EntityID, int16(dx), int16(dy), int16(dz), yaw, pitch, player.OnGround)
+		case moved:
+			viewer.SendEntityPosition(player.EntityID, int16(dx), int16(dy), int16(dz), player.OnGround)
+		default:
+			viewer.SendEntityRotation(player.EntityID, yaw, pitch, player.OnGround)
+		}
+
+		if yaw != entity.yaw {
+			viewer.SendHeadRotation(player.EntityID, yaw)
+		}
+	}
+
+	entity.sync(player)
+}
+
+func (et *EntityTracker) updateViewers(player *Player) {
+	for other, otherEntity := range et.tracked {
+		if other == player {
+			continue
+		}
+
+		et.updateVisibility(other, player, et.tracked[player])
+		et.updateVisibility(player, other, otherEntity)
+	}
+}
+
+func (et *EntityTracker) updateVisibility(viewer *Player, target *Player, entity *trackedEntity) {
+	_, visible := entity.viewers[viewer]
+	inRange := canSee(viewer, target)
+
+	if inRange && !visible {
+		entity.viewers[viewer] = struct{}{}
+		viewer.SendSpawnPlayer(target, entity.yaw, entity.pitch)
+		viewer.SendHeadRotation(target.EntityID, entity.yaw)
+
+		if metadata := target.Metadata().All(); metadata != nil {
+			viewer.SendEntityMetadata(target.EntityID, metadata)
+		}
+	} else if !inRange && visible {
+		delete(entity.viewers, viewer)
+		viewer.SendRemoveEntities([]int32{target.EntityID})
+	}
+}
+
+func (te *trackedEntity) sync(player *Player) {
+	te.x = encodePosition(player.X)
+	te.y = encodePosition(player.Y)
+	te.z = encodePosition(player.Z)
+	te.yaw = encodeAngle(player.Yaw)
+	te.pitch = encodeAngle(player.Pitch)
+}
+
+func canSee(viewer *Player, target *Player) bool {
+	viewerX, _, viewerZ := viewer.Position()
+	targetX, _, targetZ := target.Position()
+
+	if !viewer.chunkView.IsLoaded(ChunkPositionAt(targetX, targetZ)) {
+		return false
+	}
+
+	dx := viewerX - targetX
+	dz := viewerZ - targetZ
+	return dx*dx+dz*dz <= PlayerTrackingRange*PlayerTrackingRange
+}
+
+// encodePosition converts a coordinate to the fixed-point format used by relative moves (1/4096 of a block).
+func encodePosition(value float64) int64 {
+	return int64(math.Floor(value * 4096))
+}
+
+// encodeAngle converts an angle in degrees to steps of 1/256 of a full turn.
+func encodeAngle(angle float32) byte {
+	return byte(int32(math.Floor(float64(angle) * 256 / 360)))
+}
+
+func fitsInt16(value int64) bool {
+	return value >= math.MinInt16 && value <= math.MaxInt16
+}
@@ -0,0 +1,149 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/mkorman9/go-minecraft-server/chunk"
+	"strconv"
+	"strings"
+)
+
+const DefaultFlatPreset = "minecraft:bedrock,2*minecraft:dirt,minecraft:grass_block;minecraft:plains"
+
+type FlatLayer struct {
+	Block  string `json:"block"`
+	Height int    `json:"height"`
+}
+
+type FlatPreset struct {
+	Layers []FlatLayer `json:"layers"`
+	Biome  string      `json:"biome"`
+}
+
+// ParseFlatPreset accepts either the preset string used by the superflat customization screen
+// (e.g. "minecraft:bedrock,2*minecraft:dirt,minecraft:grass_block;minecraft:plains")
+// or the JSON object used by generator-settings in server.properties.
+func ParseFlatPreset(preset string) (*FlatPreset, error) {
+	preset = strings.TrimSpace(preset)
+	if preset == "" {
+		preset = DefaultFlatPreset
+	}
+
+	if strings.HasPrefix(preset, "{") {
+		var flatPreset FlatPreset
+		err := json.Unmarshal([]byte(preset), &flatPreset)
+		if err != nil {
+			return nil, err
+		}
+
+		if flatPreset.Biome == "" {
+			flatPreset.Biome = "minecraft:plains"
+		}
+
+		return &flatPreset, flatPreset.validate()
+	}
+
+	parts := strings.Split(preset, ";")
+	if _, err := strconv.Atoi(parts[0]); err == nil && len(parts) > 1 {
+		// legacy presets are prefixed with the version number
+		parts = parts[1:]
+	}
+
+	flatPreset := FlatPreset{
+		Biome: "minecraft:plains",
+	}
+
+	for _, layer := range strings.Split(parts[0], ",") {
+		layer = strings.TrimSpace(layer)
+		height := 1
+
+		if separator := strings.IndexAny(layer, "*x"); separator != -1 {
+			if value, err := strconv.Atoi(layer[:separator]); err == nil {
+				height = value
+				layer = layer[separator+1:]
+			}
+		}
+
+		flatPreset.Layers = append(flatPreset.Layers, FlatLayer{
+			Block:  normalizeName(layer),
+			Height: height,
+		})
+	}
+
+	if len(parts) > 1 && strings.TrimSpace(parts[1]) != "" {
+		flatPreset.Biome = normalizeName(strings.TrimSpace(parts[1]))
+	}
+
+	return &flatPreset, flatPreset.validate()
+}
+
+func (fp *FlatPreset) validate() error {
+	total := 0
+	for _, layer := range fp.Layers {
+		if layer.Block == "" || layer.Height < 0 {
+			return fmt.Errorf("invalid layer: %d*%s", layer.Height, layer.Block)
+		}
+
+		total += layer.Height
+	}
+
+	if total > chunk.ChunkHeight {
+		return fmt.Errorf("layers are %d blocks high, the world is only %d", total, chunk.ChunkHeight)
+	}
+
+	return nil
+}
+
+type FlatGenerator struct {
+	template *chunk.Chunk
+}
+
+func NewFlatGenerator(preset *FlatPreset, palette Palette) (*FlatGenerator, error) {
+	biome, ok := palette.BiomeID(preset.Biome)
+	if !ok {
+		return nil, fmt.Errorf("unknown biome: %s", preset.Biome)
+	}
+
+	template := chunk.NewChunk()
+	for i := range template.Sections {
+		template.Sections[i] = chunk.NewSection(chunk.AirState, biome)
+	}
+
+	y := chunk.MinY
+	for _, layer := range preset.Layers {
+		state, ok := palette.BlockStateID(layer.Block, nil)
+		if !ok {
+			return nil, fmt.Errorf("unknown block: %s", layer.Block)
+		}
+
+		for i := 0; i < layer.Height; i++ {
+			for x := 0; x < chunk.ChunkSize; x++ {
+				for z := 0; z < chunk.ChunkSize; z++ {
+					template.SetBlock(x, y, z, state)
+				}
+			}
+
+			y++
+		}
+	}
+
+	for i := range template.Sections {
+		template.Sections[i].BlockStates.Optimize()
+	}
+
+	return &FlatGenerator{
+		template: template,
+	}, nil
+}
+
+func (fg *FlatGenerator) GenerateChunk(_, _ int) *chunk.Chunk {
+	return fg.template.Clone()
+}
+
+func normalizeName(name string) string {
+	if !strings.Contains(name, ":") {
+		return "minecraft:" + name
+	}
+
+	return name
+}
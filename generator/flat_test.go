@@ -0,0 +1,121 @@
+package generator
+
+import (
+	"github.com/mkorman9/go-minecraft-server/chunk"
+	"testing"
+)
+
+type testPalette struct {
+}
+
+var testBlockStates = map[string]int{
+	"minecraft:air":         0,
+	"minecraft:stone":       1,
+	"minecraft:grass_block": 9,
+	"minecraft:dirt":        10,
+	"minecraft:bedrock":     74,
+	"minecraft:sand":        107, "minecraft:red_sand": 108, "minecraft:gravel": 109, "minecraft:water": 75, "minecraft:lava": 91,
+}
+
+var testBiomes = map[string]int{
+	"minecraft:plains": 1,
+	"minecraft:desert": 5,
+}
+
+func (tp *testPalette) BlockStateID(name string, _ map[string]string) (int, bool) {
+	id, ok := testBlockStates[name]
+	return id, ok
+}
+
+func (tp *testPalette) BiomeID(name string) (int, bool) {
+	id, ok := testBiomes[name]
+	return id, ok
+}
+
+func TestParseFlatPreset(t *testing.T) {
+	preset, err := ParseFlatPreset("minecraft:bedrock,2*minecraft:dirt,grass_block;minecraft:desert")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []FlatLayer{
+		{Block: "minecraft:bedrock", Height: 1},
+		{Block: "minecraft:dirt", Height: 2},
+		{Block: "minecraft:grass_block", Height: 1},
+	}
+
+	if len(preset.Layers) != len(expected) {
+		t.Fatalf("expected %d layers, got %d", len(expected), len(preset.Layers))
+	}
+	for i, layer := range expected {
+		if preset.Layers[i] != layer {
+			t.Fatalf("layer %d: expected %v, got %v", i, layer, preset.Layers[i])
+		}
+	}
+	if preset.Biome != "minecraft:desert" {
+		t.Fatalf("expected desert biome, got %s", preset.Biome)
+	}
+}
+
+func TestParseFlatPresetJSON(t *testing.T) {
+	preset, err := ParseFlatPreset(`{"layers":[{"block":"minecraft:stone","height":3}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(preset.Layers) != 1 || preset.Layers[0].Height != 3 || preset.Biome != "minecraft:plains" {
+		t.Fatalf("unexpected preset: %+v", preset)
+	}
+}
+
+func TestParseFlatPresetTooHigh(t *testing.T) {
+	_, err := ParseFlatPreset("385*minecraft:stone")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestFlatGenerator(t *testing.T) {
+	preset, err := ParseFlatPreset(DefaultFlatPreset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flatGenerator, err := NewFlatGenerator(preset, &testPalette{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := flatGenerator.GenerateChunk(-7, 12)
+
+	expected := []int{74, 10, 10, 9, 0}
+	for i, state := range expected {
+		if c.GetBlock(5, chunk.MinY+i, 11) != state {
+			t.Fatalf("expected %d at y=%d, got %d", state, chunk.MinY+i, c.GetBlock(5, chunk.MinY+i, 11))
+		}
+	}
+
+	if c.Sections[0].BlockCount != 4*256 {
+		t.Fatalf("expected block count %d, got %d", 4*256, c.Sections[0].BlockCount)
+	}
+	if c.HighestBlock(0, 0) != chunk.MinY+3 {
+		t.Fatalf("unexpected highest block: %d", c.HighestBlock(0, 0))
+	}
+
+	c.SetBlock(0, chunk.MinY+3, 0, 0)
+	if flatGenerator.GenerateChunk(0, 0).GetBlock(0, chunk.MinY+3, 0) != 9 {
+		t.Fatalf("generated chunks must not share data")
+	}
+}
+
+func TestFlatGeneratorUnknownBlock(t *testing.T) {
+	preset, err := ParseFlatPreset("minecraft:unknown;minecraft:plains")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = NewFlatGenerator(preset, &testPalette{})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,7 @@
+package generator
+
+// Palette resolves block and biome names into protocol IDs.
+type Palette interface {
+	BlockStateID(name string, properties map[string]string) (int, bool)
+	BiomeID(name string) (int, bool)
+}
@@ -0,0 +1,134 @@
+package generator
+
+import (
+	"math"
+	"math/rand"
+)
+
+// perlinNoise is an implementation of Ken Perlin's improved noise, returning values in range [-1, 1].
+type perlinNoise struct {
+	permutation      [512]int
+	offsetX, offsetY float64
+	offsetZ          float64
+}
+
+func newPerlinNoise(random *rand.Rand) *perlinNoise {
+	pn := &perlinNoise{
+		offsetX: random.Float64() * 256,
+		offsetY: random.Float64() * 256,
+		offsetZ: random.Float64() * 256,
+	}
+
+	for i := 0; i < 256; i++ {
+		pn.permutation[i] = i
+	}
+	for i := 255; i > 0; i-- {
+		j := random.Intn(i + 1)
+		pn.permutation[i], pn.permutation[j] = pn.permutation[j], pn.permutation[i]
+	}
+	for i := 0; i < 256; i++ {
+		pn.permutation[i+256] = pn.permutation[i]
+	}
+
+	return pn
+}
+
+func (pn *perlinNoise) noise3(x, y, z float64) float64 {
+	x += pn.offsetX
+	y += pn.offsetY
+	z += pn.offsetZ
+
+	floorX, floorY, floorZ := math.Floor(x), math.Floor(y), math.Floor(z)
+	xi, yi, zi := int(floorX)&255, int(floorY)&255, int(floorZ)&255
+	x, y, z = x-floorX, y-floorY, z-floorZ
+	u, v, w := fade(x), fade(y), fade(z)
+
+	p := &pn.permutation
+	a := p[xi] + yi
+	aa := p[a] + zi
+	ab := p[a+1] + zi
+	b := p[xi+1] + yi
+	ba := p[b] + zi
+	bb := p[b+1] + zi
+
+	return lerp(w,
+		lerp(v,
+			lerp(u, grad(p[aa], x, y, z), grad(p[ba], x-1, y, z)),
+			lerp(u, grad(p[ab], x, y-1, z), grad(p[bb], x-1, y-1, z)),
+		),
+		lerp(v,
+			lerp(u, grad(p[aa+1], x, y, z-1), grad(p[ba+1], x-1, y, z-1)),
+			lerp(u, grad(p[ab+1], x, y-1, z-1), grad(p[bb+1], x-1, y-1, z-1)),
+		),
+	)
+}
+
+// octaveNoise sums several layers of perlin noise, each one with doubled frequency and halved amplitude.
+type octaveNoise struct {
+	octaves []*perlinNoise
+	scale   float64
+}
+
+func newOctaveNoise(random *rand.Rand, octaves int) *octaveNoise {
+	on := &octaveNoise{}
+
+	amplitude := 1.0
+	for i := 0; i < octaves; i++ {
+		on.octaves = append(on.octaves, newPerlinNoise(random))
+		on.scale += amplitude
+		amplitude /= 2
+	}
+
+	return on
+}
+
+func (on *octaveNoise) noise2(x, z float64) float64 {
+	return on.noise3(x, 0, z)
+}
+
+func (on *octaveNoise) noise3(x, y, z float64) float64 {
+	value := 0.0
+	frequency := 1.0
+	amplitude := 1.0
+
+	for _, octave := range on.octaves {
+		value += octave.noise3(x*frequency, y*frequency, z*frequency) * amplitude
+		frequency *= 2
+		amplitude /= 2
+	}
+
+	return value / on.scale
+}
+
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(t, a, b float64) float64 {
+	return a + t*(b-a)
+}
+
+func grad(hash int, x, y, z float64) float64 {
+	h := hash & 15
+
+	u := y
+	if h < 8 {
+		u = x
+	}
+
+	v := z
+	if h < 4 {
+		v = y
+	} else if h == 12 || h == 14 {
+		v = x
+	}
+
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+
+	return u + v
+}
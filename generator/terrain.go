@@ -0,0 +1,374 @@
+package generator
+
+import (
+	"fmt"
+	"github.com/mkorman9/go-minecraft-server/chunk"
+	"math"
+	"math/rand"
+)
+
+const (
+	// SeaLevel is the y of the lowest air block above the oceans.
+	SeaLevel  = 63
+	lavaLevel = chunk.MinY + 10
+)
+
+// Biome describes a candidate biome of the terrain generator, as defined in the worldgen/biome registry.
+type Biome struct {
+	Name        string
+	ID          int
+	Temperature float64
+	Downfall    float64
+}
+
+// nonSurfaceBiomes are never picked by climate. Oceans, rivers and beaches are placed according to terrain height,
+// the rest belongs to caves, the Nether or the End.
+var nonSurfaceBiomes = map[string]bool{
+	"minecraft:the_void":            true,
+	"minecraft:river":               true,
+	"minecraft:frozen_river":        true,
+	"minecraft:beach":               true,
+	"minecraft:snowy_beach":         true,
+	"minecraft:stony_shore":         true,
+	"minecraft:warm_ocean":          true,
+	"minecraft:lukewarm_ocean":      true,
+	"minecraft:deep_lukewarm_ocean": true,
+	"minecraft:ocean":               true,
+	"minecraft:deep_ocean":          true,
+	"minecraft:cold_ocean":          true,
+	"minecraft:deep_cold_ocean":     true,
+	"minecraft:frozen_ocean":        true,
+	"minecraft:deep_frozen_ocean":   true,
+	"minecraft:mushroom_fields":     true,
+	"minecraft:dripstone_caves":     true,
+	"minecraft:lush_caves":          true,
+	"minecraft:deep_dark":           true,
+	"minecraft:nether_wastes":       true,
+	"minecraft:warped_forest":       true,
+	"minecraft:crimson_forest":      true,
+	"minecraft:soul_sand_valley":    true,
+	"minecraft:basalt_deltas":       true,
+	"minecraft:the_end":             true,
+	"minecraft:end_highlands":       true,
+	"minecraft:end_midlands":        true,
+	"minecraft:small_end_islands":   true,
+	"minecraft:end_barrens":         true,
+}
+
+type terrainBlocks struct {
+	air, stone, bedrock, dirt, grass, sand, redSand, gravel, water, lava int
+}
+
+// TerrainGenerator generates hills, oceans and caves out of multi-octave perlin noise.
+// The same seed always produces the same terrain.
+type TerrainGenerator struct {
+	seed            int64
+	continentalness *octaveNoise
+	erosion         *octaveNoise
+	temperature     *octaveNoise
+	humidity        *octaveNoise
+	weirdness       *octaveNoise
+	caves           *octaveNoise
+	tunnels         *octaveNoise
+	tunnelsOffset   *octaveNoise
+	blocks          terrainBlocks
+	landBiomes      []Biome
+	biomes          map[string]Biome
+}
+
+type terrainColumn struct {
+	height  int
+	surface int
+	filler  int
+}
+
+func NewTerrainGenerator(seed int64, biomes []Biome, palette Palette) (*TerrainGenerator, error) {
+	random := rand.New(rand.NewSource(seed))
+
+	tg := &TerrainGenerator{
+		seed:            seed,
+		continentalness: newOctaveNoise(random, 4),
+		erosion:         newOctaveNoise(random, 4),
+		temperature:     newOctaveNoise(random, 2),
+		humidity:        newOctaveNoise(random, 2),
+		weirdness:       newOctaveNoise(random, 2),
+		caves:           newOctaveNoise(random, 3),
+		tunnels:         newOctaveNoise(random, 2),
+		tunnelsOffset:   newOctaveNoise(random, 2),
+		biomes:          make(map[string]Biome),
+	}
+
+	for _, biome := range biomes {
+		tg.biomes[biome.Name] = biome
+		if !nonSurfaceBiomes[biome.Name] {
+			tg.landBiomes = append(tg.landBiomes, biome)
+		}
+	}
+
+	if len(tg.landBiomes) == 0 {
+		return nil, fmt.Errorf("no surface biomes available")
+	}
+	for _, name := range []string{"minecraft:ocean", "minecraft:beach"} {
+		if _, ok := tg.biomes[name]; !ok {
+			return nil, fmt.Errorf("missing biome: %s", name)
+		}
+	}
+
+	blocks := []struct {
+		name  string
+		state *int
+	}{
+		{"minecraft:air", &tg.blocks.air},
+		{"minecraft:stone", &tg.blocks.stone},
+		{"minecraft:bedrock", &tg.blocks.bedrock},
+		{"minecraft:dirt", &tg.blocks.dirt},
+		{"minecraft:grass_block", &tg.blocks.grass},
+		{"minecraft:sand", &tg.blocks.sand},
+		{"minecraft:red_sand", &tg.blocks.redSand},
+		{"minecraft:gravel", &tg.blocks.gravel},
+		{"minecraft:water", &tg.blocks.water},
+		{"minecraft:lava", &tg.blocks.lava},
+	}
+
+	for _, block := range blocks {
+		state, ok := palette.BlockStateID(block.name, nil)
+		if !ok {
+			return nil, fmt.Errorf("unknown block: %s", block.name)
+		}
+
+		*block.state = state
+	}
+
+	return tg, nil
+}
+
+func (tg *TerrainGenerator) GenerateChunk(chunkX, chunkZ int) *chunk.Chunk {
+	originX := chunkX * chunk.ChunkSize
+	originZ := chunkZ * chunk.ChunkSize
+
+	// biomes are stored in 4x4x4 cells, each column of cells shares the biome picked for its center
+	var biomes [16]Biome
+	for cellX := 0; cellX < 4; cellX++ {
+		for cellZ := 0; cellZ < 4; cellZ++ {
+			x, z := originX+cellX*4+2, originZ+cellZ*4+2
+			biomes[cellZ*4+cellX] = tg.biomeAt(x, z, tg.heightAt(x, z))
+		}
+	}
+
+	sections := make([][]int, chunk.SectionsCount)
+	for i := range sections {
+		sections[i] = make([]int, chunk.BlocksPerSection)
+		for j := range sections[i] {
+			sections[i][j] = tg.blocks.air
+		}
+	}
+
+	for x := 0; x < chunk.ChunkSize; x++ {
+		for z := 0; z < chunk.ChunkSize; z++ {
+			column := tg.column(originX+x, originZ+z, biomes[(z>>2)*4+(x>>2)])
+
+			top := column.height
+			if top < SeaLevel-1 {
+				top = SeaLevel - 1
+			}
+
+			for y := chunk.MinY; y <= top; y++ {
+				state := tg.blockAt(originX+x, y, originZ+z, &column)
+				sections[(y-chunk.MinY)/chunk.SectionHeight][(y&15)<<8|z<<4|x] = state
+			}
+		}
+	}
+
+	c := chunk.NewChunk()
+	for i := range c.Sections {
+		biomeValues := make([]int, chunk.BiomesPerSection)
+		for j := range biomeValues {
+			biomeValues[j] = biomes[j&15].ID
+		}
+
+		c.Sections[i] = chunk.Section{
+			BlockStates: chunk.NewPalettedContainerFrom(chunk.BlockStatesKind, sections[i]),
+			Biomes:      chunk.NewPalettedContainerFrom(chunk.BiomesKind, biomeValues),
+		}
+		c.Sections[i].RecalculateBlockCount()
+	}
+
+	return c
+}
+
+func (tg *TerrainGenerator) column(x, z int, biome Biome) terrainColumn {
+	column := terrainColumn{
+		height:  tg.heightAt(x, z),
+		surface: tg.blocks.grass,
+		filler:  tg.blocks.dirt,
+	}
+
+	switch biome.Name {
+	case "minecraft:desert", "minecraft:beach", "minecraft:snowy_beach":
+		column.surface, column.filler = tg.blocks.sand, tg.blocks.sand
+	case "minecraft:badlands", "minecraft:eroded_badlands", "minecraft:wooded_badlands":
+		column.surface, column.filler = tg.blocks.redSand, tg.blocks.redSand
+	case "minecraft:stony_peaks", "minecraft:windswept_gravelly_hills":
+		column.surface, column.filler = tg.blocks.gravel, tg.blocks.stone
+	case "minecraft:frozen_ocean", "minecraft:deep_frozen_ocean", "minecraft:cold_ocean", "minecraft:deep_cold_ocean",
+		"minecraft:deep_ocean":
+		column.surface, column.filler = tg.blocks.gravel, tg.blocks.gravel
+	}
+
+	if column.height < SeaLevel-1 && column.surface == tg.blocks.grass {
+		column.surface, column.filler = tg.blocks.sand, tg.blocks.sand
+	}
+
+	return column
+}
+
+func (tg *TerrainGenerator) blockAt(x, y, z int, column *terrainColumn) int {
+	if y == chunk.MinY {
+		return tg.blocks.bedrock
+	}
+	if y < chunk.MinY+5 && tg.random(x, y, z) < float64(chunk.MinY+5-y)/5 {
+		return tg.blocks.bedrock
+	}
+
+	if y > column.height {
+		if y < SeaLevel {
+			return tg.blocks.water
+		}
+		return tg.blocks.air
+	}
+
+	if tg.isCave(x, y, z, column.height) {
+		if y <= lavaLevel {
+			return tg.blocks.lava
+		}
+		return tg.blocks.air
+	}
+
+	depth := column.height - y
+	if depth == 0 {
+		return column.surface
+	} else if depth <= 3 {
+		return column.filler
+	}
+
+	return tg.blocks.stone
+}
+
+func (tg *TerrainGenerator) isCave(x, y, z int, height int) bool {
+	if y < chunk.MinY+5 {
+		return false
+	}
+
+	// keep the ground under water intact, so the oceans don't drain into caves
+	if height < SeaLevel && y > height-8 {
+		return false
+	}
+
+	fx, fy, fz := float64(x), float64(y), float64(z)
+
+	if y < height-12 && tg.caves.noise3(fx/48, fy/32, fz/48) > 0.3 {
+		return true
+	}
+
+	return math.Abs(tg.tunnels.noise3(fx/64, fy/40, fz/64)) < 0.035 &&
+		math.Abs(tg.tunnelsOffset.noise3(fx/64, fy/40, fz/64)) < 0.035
+}
+
+func (tg *TerrainGenerator) heightAt(x, z int) int {
+	fx, fz := float64(x), float64(z)
+
+	continentalness := tg.continentalness.noise2(fx/640, fz/640) * 1.6
+	erosion := tg.erosion.noise2(fx/160, fz/160)
+
+	height := SeaLevel + 2 + int(continentalness*40+erosion*(6+40*math.Max(continentalness, 0)))
+
+	if height < chunk.MinY+8 {
+		height = chunk.MinY + 8
+	} else if height > chunk.MinY+chunk.ChunkHeight-32 {
+		height = chunk.MinY + chunk.ChunkHeight - 32
+	}
+
+	return height
+}
+
+func (tg *TerrainGenerator) biomeAt(x, z int, height int) Biome {
+	fx, fz := float64(x), float64(z)
+	temperature := tg.temperature.noise2(fx/800, fz/800)*1.8 + 0.7
+	humidity := math.Min(math.Max(tg.humidity.noise2(fx/800, fz/800)*0.8+0.5, 0), 1)
+
+	if height < SeaLevel-1 {
+		return tg.oceanBiome(temperature, height < SeaLevel-18)
+	}
+	if height <= SeaLevel+1 {
+		if temperature < 0.2 {
+			return tg.biomeOr("minecraft:snowy_beach", "minecraft:beach")
+		}
+		return tg.biomes["minecraft:beach"]
+	}
+
+	// many biomes share the same climate, weirdness picks one of them
+	var candidates []Biome
+	bestDistance := math.Inf(1)
+	for _, biome := range tg.landBiomes {
+		distance := math.Pow(biome.Temperature-temperature, 2) + math.Pow(biome.Downfall-humidity, 2)
+		if distance < bestDistance-1e-6 {
+			bestDistance = distance
+			candidates = candidates[:0]
+		}
+		if math.Abs(distance-bestDistance) <= 1e-6 {
+			candidates = append(candidates, biome)
+		}
+	}
+
+	weirdness := (tg.weirdness.noise2(fx/300, fz/300) + 1) / 2
+	index := int(weirdness * float64(len(candidates)))
+	if index < 0 {
+		index = 0
+	} else if index >= len(candidates) {
+		index = len(candidates) - 1
+	}
+
+	return candidates[index]
+}
+
+func (tg *TerrainGenerator) oceanBiome(temperature float64, deep bool) Biome {
+	var name string
+	switch {
+	case temperature < 0.15:
+		name = "frozen_ocean"
+	case temperature < 0.45:
+		name = "cold_ocean"
+	case temperature < 1.1:
+		name = "ocean"
+	case temperature < 1.5:
+		name = "lukewarm_ocean"
+	default:
+		name = "warm_ocean"
+	}
+
+	if deep && name != "warm_ocean" {
+		name = "deep_" + name
+	}
+
+	return tg.biomeOr("minecraft:"+name, "minecraft:ocean")
+}
+
+func (tg *TerrainGenerator) biomeOr(name string, fallback string) Biome {
+	if biome, ok := tg.biomes[name]; ok {
+		return biome
+	}
+
+	return tg.biomes[fallback]
+}
+
+// random returns a number in range [0, 1) derived from the seed and given position.
+func (tg *TerrainGenerator) random(x, y, z int) float64 {
+	h := uint64(tg.seed) ^ uint64(x)*0x9e3779b97f4a7c15 ^ uint64(y)*0xc2b2ae3d27d4eb4f ^ uint64(z)*0x165667b19e3779f9
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+
+	return float64(h>>11) / (1 << 53)
+}
@@ -0,0 +1,109 @@
+package generator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/mkorman9/go-minecraft-server/chunk"
+	"testing"
+)
+
+var testTerrainBiomes = []Biome{
+	{Name: "minecraft:plains", ID: 1, Temperature: 0.8, Downfall: 0.4},
+	{Name: "minecraft:desert", ID: 5, Temperature: 2, Downfall: 0},
+	{Name: "minecraft:forest", ID: 8, Temperature: 0.7, Downfall: 0.8},
+	{Name: "minecraft:beach", ID: 37, Temperature: 0.8, Downfall: 0.4},
+	{Name: "minecraft:ocean", ID: 43, Temperature: 0.5, Downfall: 0.5},
+}
+
+func TestTerrainGeneratorIsDeterministic(t *testing.T) {
+	first := newTestTerrainGenerator(t, 1234)
+	second := newTestTerrainGenerator(t, 1234)
+	other := newTestTerrainGenerator(t, 4321)
+
+	for _, position := range [][2]int{{0, 0}, {-5, 17}, {100, -100}} {
+		expected := encodeChunk(t, first.GenerateChunk(position[0], position[1]))
+
+		if !bytes.Equal(expected, encodeChunk(t, second.GenerateChunk(position[0], position[1]))) {
+			t.Fatalf("chunk %v differs between generators with the same seed", position)
+		}
+		if bytes.Equal(expected, encodeChunk(t, other.GenerateChunk(position[0], position[1]))) {
+			t.Fatalf("chunk %v is the same for different seeds", position)
+		}
+	}
+}
+
+// TestTerrainGeneratorSnapshot guards against unintended changes of the generated terrain.
+// Update the checksums only when the change of the terrain is deliberate.
+func TestTerrainGeneratorSnapshot(t *testing.T) {
+	terrainGenerator := newTestTerrainGenerator(t, 1234)
+
+	snapshots := map[[2]int]string{
+		{0, 0}:   "6c1c4dc097af8a95b53ea1a11a5572c7149e55bcae51b499e2dd14ca4c4d8584",
+		{-5, 17}: "b435bef9cf8574fd5bb264155ac7d6865d8bbade2ba7969931738adc2234176e",
+	}
+
+	for position, expected := range snapshots {
+		checksum := sha256.Sum256(encodeChunk(t, terrainGenerator.GenerateChunk(position[0], position[1])))
+		if hex.EncodeToString(checksum[:]) != expected {
+			t.Fatalf("chunk %v: expected checksum %s, got %x", position, expected, checksum)
+		}
+	}
+}
+
+func TestTerrainGeneratorLayout(t *testing.T) {
+	terrainGenerator := newTestTerrainGenerator(t, 1234)
+	palette := &testPalette{}
+	bedrock, _ := palette.BlockStateID("minecraft:bedrock", nil)
+	water, _ := palette.BlockStateID("minecraft:water", nil)
+
+	sawWater := false
+	for chunkX := -4; chunkX < 4; chunkX++ {
+		for chunkZ := -4; chunkZ < 4; chunkZ++ {
+			c := terrainGenerator.GenerateChunk(chunkX*16, chunkZ*16)
+			for x := 0; x < chunk.ChunkSize; x++ {
+				for z := 0; z < chunk.ChunkSize; z++ {
+					if c.GetBlock(x, chunk.MinY, z) != bedrock {
+						t.Fatalf("expected bedrock at the bottom of the world")
+					}
+
+					highest := c.HighestBlock(x, z)
+					if highest < SeaLevel-1 {
+						t.Fatalf("expected terrain or water up to the sea level, got %d", highest)
+					}
+
+					if c.GetBlock(x, SeaLevel-1, z) == water {
+						sawWater = true
+					}
+				}
+			}
+		}
+	}
+
+	if !sawWater {
+		t.Fatalf("expected some oceans")
+	}
+}
+
+func newTestTerrainGenerator(t *testing.T, seed int64) *TerrainGenerator {
+	t.Helper()
+
+	terrainGenerator, err := NewTerrainGenerator(seed, testTerrainBiomes, &testPalette{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return terrainGenerator
+}
+
+func encodeChunk(t *testing.T, c *chunk.Chunk) []byte {
+	t.Helper()
+
+	var encoded bytes.Buffer
+	_, err := c.WriteTo(&encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return encoded.Bytes()
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"github.com/mkorman9/go-minecraft-server/items"
+	"github.com/mkorman9/go-minecraft-server/nbt"
+	"github.com/mkorman9/go-minecraft-server/types"
+	"sync"
+	"sync/atomic"
+)
+
+// Slots of the player inventory, numbered the same way as in the inventory window.
+const (
+	InventorySlotCraftingResult = 0
+	InventorySlotCraftingFirst  = 1
+	InventorySlotArmorFirst     = 5
+	InventorySlotMainFirst      = 9
+	InventorySlotHotbarFirst    = 36
+	InventorySlotOffhand        = 45
+
+	PlayerInventorySize = 46
+	CraftingGridSize    = 4
+	ArmorSlotsCount     = 4
+	HotbarSize          = 9
+)
+
+// MaxItemNBTSize limits the size of NBT attached to items received from clients.
+const MaxItemNBTSize = 256 * 1024
+
+var lastInventoryID atomic.Uint64
+
+// Inventory is a fixed set of item slots, like the player inventory or contents of a chest.
+// Changes are sent to every window showing the inventory.
+type Inventory struct {
+	id      uint64
+	m       sync.Mutex
+	slots   []*types.SlotData
+	items   *items.Registry
+	windows map[*Window]struct{}
+}
+
+func NewInventory(size int, registry *items.Registry) *Inventory {
+	return &Inventory{
+		id:      lastInventoryID.Add(1),
+		slots:   make([]*types.SlotData, size),
+		items:   registry,
+		windows: make(map[*Window]struct{}),
+	}
+}
+
+func (inv *Inventory) Size() int {
+	return len(inv.slots)
+}
+
+// Get returns a copy of the item in the slot, or nil if the slot is empty.
+func (inv *Inventory) Get(index int) *types.SlotData {
+	inv.m.Lock()
+	defer inv.m.Unlock()
+
+	return copyItem(inv.get(index))
+}
+
+func (inv *Inventory) Set(index int, item *types.SlotData) {
+	inv.m.Lock()
+	defer inv.m.Unlock()
+
+	inv.set(index, copyItem(item), nil)
+}
+
+// Contents returns copies of all items, with nil for empty slots.
+func (inv *Inventory) Contents() []*types.SlotData {
+	inv.m.Lock()
+	defer inv.m.Unlock()
+
+	items := make([]*types.SlotData, len(inv.slots))
+	for i, item := range inv.slots {
+		items[i] = copyItem(item)
+	}
+	return items
+}
+
+// Load replaces all items without notifying the windows, which have to be synchronized by the caller.
+func (inv *Inventory) Load(items []*types.SlotData) {
+	inv.m.Lock()
+	defer inv.m.Unlock()
+
+	for i := range inv.slots {
+		var item *types.SlotData
+		if i < len(items) {
+			item = copyItem(items[i])
+		}
+
+		inv.slots[i] = item
+	}
+}
+
+// Consume removes given number of items from the slot, and returns false if there are not enough of them.
+func (inv *Inventory) Consume(index int, count int) bool {
+	inv.m.Lock()
+	defer inv.m.Unlock()
+
+	item := inv.get(index)
+	if item == nil || int(item.ItemCount) < count {
+		return false
+	}
+
+	inv.set(index, withCount(item, int(item.ItemCount)-count), nil)
+	return true
+}
+
+// Add puts the item into given slots, first topping up stacks of the same item and then filling empty slots,
+// and returns the number of items that didn't fit.
+func (inv *Inventory) Add(item *types.SlotData, indexes []int) int {
+	inv.m.Lock()
+	defer inv.m.Unlock()
+
+	return inv.add(item, indexes, nil)
+}
+
+func (inv *Inventory) get(index int) *types.SlotData {
+	if index < 0 || index >= len(inv.slots) {
+		return nil
+	}
+
+	return inv.slots[index]
+}
+
+func (inv *Inventory) set(index int, item *types.SlotData, source *Window) {
+	if index < 0 || index >= len(inv.slots) {
+		return
+	}
+
+	if isEmpty(item) {
+		item = nil
+	}
+
+	inv.slots[index] = item
+
+	for window := range inv.windows {
+		if window != source {
+			window.slotChanged(inv, index, item)
+		}
+	}
+}
+
+func (inv *Inventory) add(item *types.SlotData, indexes []int, source *Window) int {
+	remaining := 0
+	if !isEmpty(item) {
+		remaining = int(item.ItemCount)
+	}
+
+	for pass := 0; pass < 2 && remaining > 0; pass++ {
+		for _, index := range indexes {
+			current := inv.get(index)
+
+			var room int
+			if pass == 0 && current != nil && sameItem(current, item) {
+				room = inv.maxStackSize(current) - int(current.ItemCount)
+			} else if pass == 1 && current == nil {
+				room = inv.maxStackSize(item)
+			}
+
+			if room <= 0 {
+				continue
+			}
+			if room > remaining {
+				room = remaining
+			}
+
+			existing := 0
+			if current != nil {
+				existing = int(current.ItemCount)
+			}
+
+			inv.set(index, withCount(item, existing+room), source)
+			remaining -= room
+
+			if remaining == 0 {
+				break
+			}
+		}
+	}
+
+	return remaining
+}
+
+// ValidItem tells whether the item received from a client is an existing item with a sane count and NBT.
+// Empty slots are valid.
+func ValidItem(registry *items.Registry, item *types.SlotData) bool {
+	if isEmpty(item) {
+		return true
+	}
+
+	if !registry.IsValid(item.ItemID) || int(item.ItemCount) > registry.MaxStackSize(item.ItemID) {
+		return false
+	}
+
+	if item.NBT != nil && (item.NBT.Type != nbt.TagCompound || len(item.NBT.Data) > MaxItemNBTSize) {
+		return false
+	}
+
+	return true
+}
+
+func isEmpty(item *types.SlotData) bool {
+	return item == nil || !item.Present || item.ItemCount == 0
+}
+
+// sameItem tells whether the items can be stacked together.
+func sameItem(a, b *types.SlotData) bool {
+	if isEmpty(a) || isEmpty(b) || a.ItemID != b.ItemID {
+		return false
+	}
+
+	if a.NBT == nil || b.NBT == nil {
+		return a.NBT == nil && b.NBT == nil
+	}
+
+	return a.NBT.Type == b.NBT.Type && bytes.Equal(a.NBT.Data, b.NBT.Data)
+}
+
+// sameStack tells whether the slots hold the same number of the same items.
+func sameStack(a, b *types.SlotData) bool {
+	if isEmpty(a) || isEmpty(b) {
+		return isEmpty(a) && isEmpty(b)
+	}
+
+	return a.ItemCount == b.ItemCount && sameItem(a, b)
+}
+
+func copyItem(item *types.SlotData) *types.SlotData {
+	if isEmpty(item) {
+		return nil
+	}
+
+	clone := *item
+	return &clone
+}
+
+// withCount returns a copy of the item with given count, or nil if the count is not positive.
+func withCount(item *types.SlotData, count int) *types.SlotData {
+	if isEmpty(item) || count <= 0 {
+		return nil
+	}
+
+	clone := *item
+	clone.ItemCount = byte(count)
+	return &clone
+}
+
+func (inv *Inventory) maxStackSize(item *types.SlotData) int {
+	return inv.items.MaxStackSize(item.ItemID)
+}
+
+// armorSlotEquipment returns the type of armor worn in the armor slot of the player inventory.
+func armorSlotEquipment(index int) items.EquipmentSlot {
+	return items.EquipmentHead + items.EquipmentSlot(index-InventorySlotArmorFirst)
+}
+
+func slotRange(from, to int) []int {
+	indexes := make([]int, 0, to-from)
+	for i := from; i < to; i++ {
+		indexes = append(indexes, i)
+	}
+	return indexes
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/mkorman9/go-minecraft-server/items"
+	"github.com/mkorman9/go-minecraft-server/types"
+)
+
+// ItemStack builds items along with their NBT, e.g.
+//
+//	NewItemStack(sword, 1).Name(NewChatMessage("Excalibur")).Enchant("minecraft:sharpness", 5).Build()
+type ItemStack struct {
+	item *types.SlotData
+	err  error
+}
+
+// NewItemStack starts building a stack of given items. The count is limited to the max stack size of the item.
+func NewItemStack(item *items.Item, count int) *ItemStack {
+	if count > item.MaxStackSize {
+		count = item.MaxStackSize
+	}
+	if count < 1 {
+		count = 1
+	}
+
+	return &ItemStack{
+		item: &types.SlotData{
+			Present:   true,
+			ItemID:    item.ID,
+			ItemCount: byte(count),
+		},
+	}
+}
+
+func (is *ItemStack) Name(name *ChatMessage) *ItemStack {
+	text, err := json.Marshal(name)
+	if err != nil {
+		is.fail(err)
+		return is
+	}
+
+	is.fail(is.item.SetDisplayName(string(text)))
+	return is
+}
+
+func (is *ItemStack) Lore(lines ...*ChatMessage) *ItemStack {
+	lore := make([]string, 0, len(lines))
+	for _, line := range lines {
+		text, err := json.Marshal(line)
+		if err != nil {
+			is.fail(err)
+			return is
+		}
+
+		lore = append(lore, string(text))
+	}
+
+	is.fail(is.item.SetLore(lore))
+	return is
+}
+
+// Enchant adds the enchantment, or changes its level if the item already has it.
+func (is *ItemStack) Enchant(id string, level int) *ItemStack {
+	enchantments := is.item.Enchantments()
+
+	found := false
+	for i := range enchantments {
+		if enchantments[i].ID == id {
+			enchantments[i].Level = int16(level)
+			found = true
+		}
+	}
+	if !found {
+		enchantments = append(enchantments, types.Enchantment{ID: id, Level: int16(level)})
+	}
+
+	is.fail(is.item.SetEnchantments(enchantments))
+	return is
+}
+
+func (is *ItemStack) Damage(damage int) *ItemStack {
+	is.fail(is.item.SetDamage(damage))
+	return is
+}
+
+func (is *ItemStack) CustomModelData(value int) *ItemStack {
+	is.fail(is.item.SetCustomModelData(value))
+	return is
+}
+
+// Build returns the item, or the first error which occurred while encoding its NBT.
+func (is *ItemStack) Build() (*types.SlotData, error) {
+	if is.err != nil {
+		return nil, is.err
+	}
+
+	return copyItem(is.item), nil
+}
+
+func (is *ItemStack) fail(err error) {
+	if is.err == nil {
+		is.err = err
+	}
+}
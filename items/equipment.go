@@ -0,0 +1,39 @@
+package items
+
+import "strings"
+
+// EquipmentSlot is the armor slot in which an item can be worn.
+type EquipmentSlot int
+
+const (
+	EquipmentNone EquipmentSlot = iota
+	EquipmentHead
+	EquipmentChest
+	EquipmentLegs
+	EquipmentFeet
+)
+
+var equipmentSuffixes = []struct {
+	suffix string
+	slot   EquipmentSlot
+}{
+	{"_helmet", EquipmentHead},
+	{"_head", EquipmentHead},
+	{"_skull", EquipmentHead},
+	{"carved_pumpkin", EquipmentHead},
+	{"_chestplate", EquipmentChest},
+	{"elytra", EquipmentChest},
+	{"_leggings", EquipmentLegs},
+	{"_boots", EquipmentFeet},
+}
+
+// equipmentOf recognizes wearable items by their names, e.g. minecraft:iron_helmet.
+func equipmentOf(name string) EquipmentSlot {
+	for _, entry := range equipmentSuffixes {
+		if strings.HasSuffix(name, entry.suffix) {
+			return entry.slot
+		}
+	}
+
+	return EquipmentNone
+}
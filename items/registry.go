@@ -0,0 +1,149 @@
+package items
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+const (
+	// MaxID is the highest item ID known to 1.19 clients. IDs missing from the registry but not greater
+	// than MaxID are still valid, so that an incomplete item list doesn't break inventories.
+	MaxID = 1151
+
+	// DefaultMaxStackSize is the stack size of items missing from the registry.
+	DefaultMaxStackSize = 64
+)
+
+// Item describes an item type.
+type Item struct {
+	ID           int
+	Name         string
+	MaxStackSize int
+	// Block is the name of the block placed by the item. Blocks unknown to the server are not placed.
+	Block string
+	// Tool describes mining properties of tools, nil for other items.
+	Tool *Tool
+	// Equipment is the armor slot in which the item can be worn.
+	Equipment EquipmentSlot
+}
+
+// Registry maps item IDs to names and properties of items.
+type Registry struct {
+	items  map[int]*Item
+	byName map[string]*Item
+}
+
+type itemData struct {
+	ID           int     `json:"id"`
+	Name         string  `json:"name"`
+	MaxStackSize int     `json:"maxStackSize"`
+	Block        *string `json:"block"`
+}
+
+// LoadRegistry reads the list of items. Items place the block with the same name unless the block
+// is given explicitly (e.g. wheat seeds place wheat); an empty block means the item places nothing.
+// Tools and armor are recognized by their names.
+func LoadRegistry(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []itemData
+	err = json.Unmarshal(data, &list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	return newRegistry(list)
+}
+
+func newRegistry(list []itemData) (*Registry, error) {
+	registry := &Registry{
+		items:  make(map[int]*Item),
+		byName: make(map[string]*Item),
+	}
+
+	for _, entry := range list {
+		if entry.ID < 0 || entry.ID > MaxID {
+			return nil, fmt.Errorf("item %s has invalid ID: %d", entry.Name, entry.ID)
+		}
+		if _, ok := registry.items[entry.ID]; ok {
+			return nil, fmt.Errorf("duplicated item ID: %d", entry.ID)
+		}
+		if _, ok := registry.byName[entry.Name]; ok {
+			return nil, fmt.Errorf("duplicated item name: %s", entry.Name)
+		}
+
+		item := &Item{
+			ID:           entry.ID,
+			Name:         entry.Name,
+			MaxStackSize: entry.MaxStackSize,
+			Block:        entry.Name,
+			Tool:         toolOf(entry.Name),
+			Equipment:    equipmentOf(entry.Name),
+		}
+		if entry.Block != nil {
+			item.Block = *entry.Block
+		}
+		if item.MaxStackSize <= 0 {
+			item.MaxStackSize = DefaultMaxStackSize
+		}
+
+		registry.items[item.ID] = item
+		registry.byName[item.Name] = item
+	}
+
+	return registry, nil
+}
+
+func (r *Registry) Item(id int) (*Item, bool) {
+	item, ok := r.items[id]
+	return item, ok
+}
+
+func (r *Registry) ByName(name string) (*Item, bool) {
+	item, ok := r.byName[name]
+	return item, ok
+}
+
+func (r *Registry) Len() int {
+	return len(r.items)
+}
+
+// IsValid tells whether clients know an item with given ID. Air is not an item that can be held.
+func (r *Registry) IsValid(id int) bool {
+	if _, ok := r.items[id]; ok {
+		return id != 0
+	}
+
+	return id > 0 && id <= MaxID
+}
+
+// MaxStackSize returns the maximal number of items of given type in a slot.
+func (r *Registry) MaxStackSize(id int) int {
+	if item, ok := r.items[id]; ok {
+		return item.MaxStackSize
+	}
+
+	return DefaultMaxStackSize
+}
+
+// Tool returns mining properties of the item, or false if it's not a tool or is unknown.
+func (r *Registry) Tool(id int) (*Tool, bool) {
+	if item, ok := r.items[id]; ok && item.Tool != nil {
+		return item.Tool, true
+	}
+
+	return nil, false
+}
+
+// Equipment returns the armor slot in which the item can be worn. Unknown items can't be worn.
+func (r *Registry) Equipment(id int) EquipmentSlot {
+	if item, ok := r.items[id]; ok {
+		return item.Equipment
+	}
+
+	return EquipmentNone
+}
@@ -0,0 +1,204 @@
+package items
+
+import "testing"
+
+func loadTestRegistry(t *testing.T) *Registry {
+	t.Helper()
+
+	registry, err := LoadRegistry("../data/1_19/items.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return registry
+}
+
+func TestItem(t *testing.T) {
+	registry := loadTestRegistry(t)
+
+	item, ok := registry.Item(1)
+	if !ok || item.Name != "minecraft:stone" || item.Block != "minecraft:stone" || item.MaxStackSize != 64 {
+		t.Fatalf("unexpected item: %+v (%v)", item, ok)
+	}
+
+	item, ok = registry.ByName("minecraft:mangrove_propagule")
+	if !ok || item.ID != 38 {
+		t.Fatalf("unexpected item: %+v (%v)", item, ok)
+	}
+
+	if registry.Len() != MaxID+1 {
+		t.Fatalf("expected %d items, got %d", MaxID+1, registry.Len())
+	}
+
+	pickaxe, ok := registry.Tool(752)
+	if !ok || pickaxe.Type != ToolPickaxe || pickaxe.Tier != TierIron || registry.MaxStackSize(752) != 1 {
+		t.Fatalf("expected item 752 to be an iron pickaxe, got %+v (%v)", pickaxe, ok)
+	}
+	if registry.Equipment(782) != EquipmentHead {
+		t.Fatalf("expected item 782 to be worn on the head")
+	}
+}
+
+func TestIsValid(t *testing.T) {
+	registry := loadTestRegistry(t)
+
+	cases := []struct {
+		id       int
+		expected bool
+	}{
+		{0, false},
+		{1, true},
+		{MaxID, true},
+		{MaxID + 1, false},
+		{-1, false},
+	}
+
+	for _, c := range cases {
+		if registry.IsValid(c.id) != c.expected {
+			t.Fatalf("%d: expected %v", c.id, c.expected)
+		}
+	}
+}
+
+func TestBlockOverride(t *testing.T) {
+	none := ""
+	seeds := "minecraft:wheat"
+
+	registry, err := newRegistry([]itemData{
+		{ID: 1, Name: "minecraft:stick"},
+		{ID: 2, Name: "minecraft:wheat_seeds", Block: &seeds},
+		{ID: 3, Name: "minecraft:ender_pearl", MaxStackSize: 16, Block: &none},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if item, _ := registry.Item(2); item.Block != "minecraft:wheat" {
+		t.Fatalf("expected wheat seeds to place wheat, got %s", item.Block)
+	}
+	if item, _ := registry.Item(3); item.Block != "" || registry.MaxStackSize(3) != 16 {
+		t.Fatalf("unexpected ender pearl: %+v", item)
+	}
+	if registry.MaxStackSize(1) != DefaultMaxStackSize || registry.MaxStackSize(100) != DefaultMaxStackSize {
+		t.Fatalf("expected default max stack size")
+	}
+
+	_, err = newRegistry([]itemData{{ID: 1, Name: "minecraft:a"}, {ID: 1, Name: "minecraft:b"}})
+	if err == nil {
+		t.Fatalf("expected duplicated ID to be rejected")
+	}
+}
+
+func TestTools(t *testing.T) {
+	registry, err := newRegistry([]itemData{
+		{ID: 1, Name: "minecraft:iron_pickaxe", MaxStackSize: 1},
+		{ID: 2, Name: "minecraft:golden_axe", MaxStackSize: 1},
+		{ID: 3, Name: "minecraft:diamond_sword", MaxStackSize: 1},
+		{ID: 4, Name: "minecraft:stick"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pickaxe, ok := registry.Tool(1)
+	if !ok || pickaxe.Type != ToolPickaxe || pickaxe.Tier != TierIron || pickaxe.Speed != 6 {
+		t.Fatalf("unexpected pickaxe: %+v (%v)", pickaxe, ok)
+	}
+	if pickaxe.MiningSpeed(ToolPickaxe) != 6 || pickaxe.MiningSpeed(ToolShovel) != 1 {
+		t.Fatalf("unexpected mining speed of pickaxe")
+	}
+	if !pickaxe.CanHarvest(ToolPickaxe, TierIron) || pickaxe.CanHarvest(ToolPickaxe, TierDiamond) || pickaxe.CanHarvest(ToolAxe, TierWood) {
+		t.Fatalf("unexpected harvesting of pickaxe")
+	}
+
+	axe, ok := registry.Tool(2)
+	if !ok || axe.Type != ToolAxe || axe.Tier != TierWood || axe.Speed != 12 {
+		t.Fatalf("unexpected axe: %+v (%v)", axe, ok)
+	}
+
+	sword, ok := registry.Tool(3)
+	if !ok || sword.Type != ToolSword || sword.MiningSpeed(ToolPickaxe) != 1 {
+		t.Fatalf("unexpected sword: %+v (%v)", sword, ok)
+	}
+
+	if _, ok := registry.Tool(4); ok {
+		t.Fatalf("expected stick not to be a tool")
+	}
+	if _, ok := registry.Tool(100); ok {
+		t.Fatalf("expected unknown item not to be a tool")
+	}
+}
+
+func TestEquipment(t *testing.T) {
+	registry, err := newRegistry([]itemData{
+		{ID: 1, Name: "minecraft:iron_helmet", MaxStackSize: 1},
+		{ID: 2, Name: "minecraft:elytra", MaxStackSize: 1},
+		{ID: 3, Name: "minecraft:diamond_boots", MaxStackSize: 1},
+		{ID: 4, Name: "minecraft:dirt"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		id       int
+		expected EquipmentSlot
+	}{
+		{1, EquipmentHead},
+		{2, EquipmentChest},
+		{3, EquipmentFeet},
+		{4, EquipmentNone},
+		{100, EquipmentNone},
+	}
+
+	for _, c := range cases {
+		if slot := registry.Equipment(c.id); slot != c.expected {
+			t.Fatalf("%d: expected %d, got %d", c.id, c.expected, slot)
+		}
+	}
+}
+
+func TestLoadTags(t *testing.T) {
+	registry := loadTestRegistry(t)
+
+	tags, err := LoadTags("../data/1_19/tags/items", registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		tag      string
+		item     string
+		expected bool
+	}{
+		{"minecraft:planks", "minecraft:mangrove_planks", true},
+		{"minecraft:planks", "minecraft:oak_log", false},
+		{"minecraft:logs", "minecraft:stripped_mangrove_wood", true}, // through logs_that_burn and mangrove_logs
+		{"minecraft:logs", "minecraft:crimson_hyphae", true},
+		{"minecraft:logs_that_burn", "minecraft:warped_stem", false},
+	}
+
+	for _, c := range cases {
+		ids, ok := tags.Tag(c.tag)
+		if !ok {
+			t.Fatalf("missing tag %s", c.tag)
+		}
+
+		item, _ := registry.ByName(c.item)
+		found := false
+		for _, id := range ids {
+			found = found || id == item.ID
+		}
+		if found != c.expected {
+			t.Fatalf("expected %s in %s: %v", c.item, c.tag, c.expected)
+		}
+	}
+
+	_, err = newTags(map[string][]string{
+		"minecraft:a": {"#minecraft:b"},
+		"minecraft:b": {"minecraft:stone", "#minecraft:a"},
+	}, registry)
+	if err == nil {
+		t.Fatalf("expected a cycle of tags to be reported")
+	}
+}
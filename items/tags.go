@@ -0,0 +1,108 @@
+package items
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Tags maps names of item tags (e.g. minecraft:planks) to IDs of the items they contain.
+type Tags struct {
+	tags map[string][]int
+}
+
+type tagData struct {
+	Values []string `json:"values"`
+}
+
+// LoadTags reads item tags in the vanilla data pack format from JSON files in the directory, named after
+// the tags (e.g. planks.json holds minecraft:planks). Tags referenced by other tags (#minecraft:logs) are expanded.
+func LoadTags(directory string, registry *Registry) (*Tags, error) {
+	paths, err := filepath.Glob(filepath.Join(directory, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string][]string, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var tag tagData
+		err = json.Unmarshal(data, &tag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+
+		values["minecraft:"+strings.TrimSuffix(filepath.Base(path), ".json")] = tag.Values
+	}
+
+	return newTags(values, registry)
+}
+
+func newTags(values map[string][]string, registry *Registry) (*Tags, error) {
+	tags := &Tags{
+		tags: make(map[string][]int, len(values)),
+	}
+
+	for name := range values {
+		_, err := tags.resolve(name, values, registry, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tags, nil
+}
+
+// resolve returns IDs of the items in the tag, expanding nested tags first. Path holds the tags being
+// resolved, to detect cycles.
+func (t *Tags) resolve(name string, values map[string][]string, registry *Registry, path []string) ([]int, error) {
+	if ids, ok := t.tags[name]; ok {
+		return ids, nil
+	}
+
+	for _, parent := range path {
+		if parent == name {
+			return nil, fmt.Errorf("tag %s references itself", name)
+		}
+	}
+
+	tagValues, ok := values[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown tag: %s", name)
+	}
+
+	var ids []int
+	for _, value := range tagValues {
+		if strings.HasPrefix(value, "#") {
+			nested, err := t.resolve(value[1:], values, registry, append(path, name))
+			if err != nil {
+				return nil, err
+			}
+
+			ids = append(ids, nested...)
+			continue
+		}
+
+		item, ok := registry.ByName(value)
+		if !ok {
+			return nil, fmt.Errorf("tag %s: unknown item %s", name, value)
+		}
+
+		ids = append(ids, item.ID)
+	}
+
+	t.tags[name] = ids
+	return ids, nil
+}
+
+// Tag returns IDs of the items in the tag with given name.
+func (t *Tags) Tag(name string) ([]int, bool) {
+	ids, ok := t.tags[name]
+	return ids, ok
+}
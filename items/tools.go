@@ -0,0 +1,79 @@
+package items
+
+import "strings"
+
+// Tool types, the same as block tags of blocks mined by the tools (e.g. minecraft:mineable/pickaxe).
+const (
+	ToolPickaxe = "pickaxe"
+	ToolAxe     = "axe"
+	ToolShovel  = "shovel"
+	ToolHoe     = "hoe"
+	ToolSword   = "sword"
+)
+
+// Tool tiers, which decide what blocks the tool can harvest. Golden tools have the tier of wooden ones.
+const (
+	TierWood      = 0
+	TierStone     = 1
+	TierIron      = 2
+	TierDiamond   = 3
+	TierNetherite = 4
+)
+
+// Tool describes mining properties of a tool.
+type Tool struct {
+	Type  string
+	Tier  int
+	Speed float64
+}
+
+type toolMaterial struct {
+	tier  int
+	speed float64
+}
+
+var toolMaterials = map[string]toolMaterial{
+	"wooden":    {tier: TierWood, speed: 2},
+	"stone":     {tier: TierStone, speed: 4},
+	"iron":      {tier: TierIron, speed: 6},
+	"diamond":   {tier: TierDiamond, speed: 8},
+	"netherite": {tier: TierNetherite, speed: 9},
+	"golden":    {tier: TierWood, speed: 12},
+}
+
+var toolTypes = []string{ToolPickaxe, ToolAxe, ToolShovel, ToolHoe, ToolSword}
+
+// MiningSpeed returns how fast the tool mines a block, which is mined best with given tool type
+// (empty if there is none).
+func (t *Tool) MiningSpeed(blockTool string) float64 {
+	if t.Type == ToolSword || t.Type != blockTool {
+		return 1
+	}
+
+	return t.Speed
+}
+
+// CanHarvest tells whether the tool can harvest a block, which requires given tool type and tier.
+func (t *Tool) CanHarvest(blockTool string, blockTier int) bool {
+	return t.Type == blockTool && t.Tier >= blockTier
+}
+
+// toolOf recognizes tools by their names, e.g. minecraft:iron_pickaxe.
+func toolOf(name string) *Tool {
+	name = strings.TrimPrefix(name, "minecraft:")
+
+	for _, toolType := range toolTypes {
+		if !strings.HasSuffix(name, "_"+toolType) {
+			continue
+		}
+
+		material, ok := toolMaterials[strings.TrimSuffix(name, "_"+toolType)]
+		if !ok {
+			return nil
+		}
+
+		return &Tool{Type: toolType, Tier: material.tier, Speed: material.speed}
+	}
+
+	return nil
+}
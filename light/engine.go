@@ -0,0 +1,380 @@
+package light
+
+import (
+	"github.com/mkorman9/go-minecraft-server/chunk"
+	"github.com/mkorman9/go-minecraft-server/types"
+)
+
+// BlockProperties describes how block states interact with light.
+type BlockProperties interface {
+	// Opacity returns how much light is lost when passing through the block (0-15).
+	Opacity(state int) int
+	// Emission returns the light level emitted by the block (0-15).
+	Emission(state int) int
+}
+
+// ChunkSource returns lit chunks loaded at given chunk coordinates, or nil.
+type ChunkSource func(x, z int) *chunk.Chunk
+
+type ChunkPosition struct {
+	X int
+	Z int
+}
+
+// Changes maps chunks to bitmasks of light sections (see chunk.LightSectionIndex) modified by an operation.
+type Changes map[ChunkPosition]uint32
+
+type kind int
+
+const (
+	skyLight kind = iota
+	blockLight
+)
+
+type node struct {
+	x, y, z int
+	level   byte
+}
+
+type direction struct {
+	x, y, z int
+}
+
+var directions = []direction{
+	{0, -1, 0}, {0, 1, 0}, {-1, 0, 0}, {1, 0, 0}, {0, 0, -1}, {0, 0, 1},
+}
+
+// Engine computes sky and block light of chunks, spreading it across chunk borders.
+// Engine is not safe for concurrent use.
+type Engine struct {
+	properties BlockProperties
+	chunks     ChunkSource
+	cache      map[ChunkPosition]*chunk.Chunk
+	changes    Changes
+}
+
+func NewEngine(properties BlockProperties, chunks ChunkSource) *Engine {
+	return &Engine{
+		properties: properties,
+		chunks:     chunks,
+	}
+}
+
+// LightChunk computes light of a freshly loaded chunk. Light of the already lit neighbours flows into the chunk,
+// and light of the chunk flows out to them. Returned changes include the lit chunk itself.
+func (e *Engine) LightChunk(chunkX, chunkZ int, c *chunk.Chunk) Changes {
+	e.begin()
+	defer e.end()
+
+	c.Light = chunk.NewLight()
+	e.cache[ChunkPosition{chunkX, chunkZ}] = c
+
+	originX, originZ := chunkX*chunk.ChunkSize, chunkZ*chunk.ChunkSize
+
+	var skyQueue, blockQueue []node
+
+	// sky light falls straight down until it hits the first block obstructing it
+	var tops [chunk.ChunkSize][chunk.ChunkSize]int
+	highest := chunk.MinY
+	for x := 0; x < chunk.ChunkSize; x++ {
+		for z := 0; z < chunk.ChunkSize; z++ {
+			y := chunk.MinY + chunk.ChunkHeight - 1
+			for ; y >= chunk.MinY; y-- {
+				if e.properties.Opacity(c.GetBlock(x, y, z)) > 0 {
+					break
+				}
+				c.Light.SetSky(x, y, z, chunk.MaxLightLevel)
+			}
+
+			tops[x][z] = y + 1
+			if y+1 > highest {
+				highest = y + 1
+			}
+		}
+	}
+
+	for x := 0; x < chunk.ChunkSize; x++ {
+		for z := 0; z < chunk.ChunkSize; z++ {
+			// only cells next to a darker column (or the bottom cell of the column) spread light any further
+			limit := tops[x][z]
+			for _, d := range directions[2:] {
+				nx, nz := x+d.x, z+d.z
+				if nx < 0 || nz < 0 || nx >= chunk.ChunkSize || nz >= chunk.ChunkSize {
+					limit = highest
+					break
+				}
+				if tops[nx][nz] > limit {
+					limit = tops[nx][nz]
+				}
+			}
+
+			for y := tops[x][z]; y <= limit && y < chunk.MinY+chunk.ChunkHeight; y++ {
+				skyQueue = append(skyQueue, node{originX + x, y, originZ + z, chunk.MaxLightLevel})
+			}
+		}
+	}
+
+	for i := range c.Sections {
+		section := &c.Sections[i]
+		if !e.mayEmit(section) {
+			continue
+		}
+
+		for index := 0; index < chunk.BlocksPerSection; index++ {
+			x, y, z := index&15, chunk.MinY+i*chunk.SectionHeight+index>>8, (index>>4)&15
+
+			emission := byte(e.properties.Emission(section.BlockStates.Get(index)))
+			if emission > 0 {
+				c.Light.SetBlock(x, y, z, emission)
+				blockQueue = append(blockQueue, node{originX + x, y, originZ + z, emission})
+			}
+		}
+	}
+
+	// pull the light of lit neighbours through the borders
+	for _, d := range directions[2:] {
+		neighbour := e.chunk(chunkX+d.x, chunkZ+d.z)
+		if neighbour == nil {
+			continue
+		}
+
+		for i := 0; i < chunk.ChunkSize; i++ {
+			x, z := i, i
+			switch {
+			case d.x < 0:
+				x = chunk.ChunkSize - 1
+			case d.x > 0:
+				x = 0
+			case d.z < 0:
+				z = chunk.ChunkSize - 1
+			case d.z > 0:
+				z = 0
+			}
+
+			for y := chunk.MinY; y < chunk.MinY+chunk.ChunkHeight; y++ {
+				worldX := (chunkX+d.x)*chunk.ChunkSize + x
+				worldZ := (chunkZ+d.z)*chunk.ChunkSize + z
+
+				if level := neighbour.Light.GetSky(x, y, z); level > 1 {
+					skyQueue = append(skyQueue, node{worldX, y, worldZ, level})
+				}
+				if level := neighbour.Light.GetBlock(x, y, z); level > 1 {
+					blockQueue = append(blockQueue, node{worldX, y, worldZ, level})
+				}
+			}
+		}
+	}
+
+	e.propagate(skyLight, skyQueue)
+	e.propagate(blockLight, blockQueue)
+
+	changes := e.changes
+	changes[ChunkPosition{chunkX, chunkZ}] = 1<<chunk.LightSectionsCount - 1
+	return changes
+}
+
+// UpdateBlock recalculates light around a block, after its state has been changed.
+func (e *Engine) UpdateBlock(x, y, z int) Changes {
+	return e.UpdateBlocks([]types.Position{{X: x, Y: y, Z: z}})
+}
+
+// UpdateBlocks recalculates light around many blocks at once, after their states have been changed.
+func (e *Engine) UpdateBlocks(positions []types.Position) Changes {
+	e.begin()
+	defer e.end()
+
+	for _, k := range []kind{skyLight, blockLight} {
+		var removalQueue, queue []node
+
+		for _, position := range positions {
+			x, y, z := position.X, position.Y, position.Z
+
+			c := e.chunk(x>>4, z>>4)
+			if c == nil || y < chunk.MinY || y >= chunk.MinY+chunk.ChunkHeight {
+				continue
+			}
+
+			if level := e.get(k, x, y, z); level > 0 {
+				e.set(k, x, y, z, 0)
+				removalQueue = append(removalQueue, node{x, y, z, level})
+			}
+
+			if k == blockLight {
+				if emission := byte(e.properties.Emission(c.GetBlock(x&15, y, z&15))); emission > 0 {
+					e.set(k, x, y, z, emission)
+					queue = append(queue, node{x, y, z, emission})
+				}
+			}
+		}
+
+		// light of the neighbours may now flow into the blocks
+		for _, position := range positions {
+			for _, d := range directions {
+				x, y, z := position.X+d.x, position.Y+d.y, position.Z+d.z
+				if level := e.get(k, x, y, z); level > 0 {
+					queue = append(queue, node{x, y, z, level})
+				}
+			}
+		}
+
+		queue = append(queue, e.unpropagate(k, removalQueue)...)
+		e.propagate(k, queue)
+	}
+
+	return e.changes
+}
+
+// propagate spreads light from queued nodes to their neighbours, as long as it makes them brighter.
+func (e *Engine) propagate(k kind, queue []node) {
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if e.get(k, current.x, current.y, current.z) != current.level {
+			continue
+		}
+
+		for _, d := range directions {
+			nx, ny, nz := current.x+d.x, current.y+d.y, current.z+d.z
+			state, ok := e.block(nx, ny, nz)
+			if !ok {
+				continue
+			}
+
+			opacity := e.properties.Opacity(state)
+			level := int(current.level) - opacity
+			if opacity == 0 {
+				level--
+			}
+			if k == skyLight && d.y < 0 && opacity == 0 && current.level == chunk.MaxLightLevel {
+				level = chunk.MaxLightLevel
+			}
+
+			if level > int(e.get(k, nx, ny, nz)) {
+				e.set(k, nx, ny, nz, byte(level))
+				queue = append(queue, node{nx, ny, nz, byte(level)})
+			}
+		}
+	}
+}
+
+// unpropagate darkens blocks lit by removed light and returns nodes which have to spread their light again.
+func (e *Engine) unpropagate(k kind, queue []node) []node {
+	var relight []node
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, d := range directions {
+			nx, ny, nz := current.x+d.x, current.y+d.y, current.z+d.z
+			state, ok := e.block(nx, ny, nz)
+			if !ok {
+				continue
+			}
+
+			level := e.get(k, nx, ny, nz)
+			if level == 0 {
+				continue
+			}
+
+			litFromAbove := k == skyLight && d.y < 0 && current.level == chunk.MaxLightLevel && level == chunk.MaxLightLevel
+			if level < current.level || litFromAbove {
+				e.set(k, nx, ny, nz, 0)
+				queue = append(queue, node{nx, ny, nz, level})
+
+				if k == blockLight {
+					if emission := byte(e.properties.Emission(state)); emission > 0 {
+						e.set(k, nx, ny, nz, emission)
+						relight = append(relight, node{nx, ny, nz, emission})
+					}
+				}
+			} else {
+				relight = append(relight, node{nx, ny, nz, level})
+			}
+		}
+	}
+
+	return relight
+}
+
+func (e *Engine) begin() {
+	e.cache = make(map[ChunkPosition]*chunk.Chunk)
+	e.changes = make(Changes)
+}
+
+func (e *Engine) end() {
+	e.cache = nil
+	e.changes = nil
+}
+
+func (e *Engine) chunk(chunkX, chunkZ int) *chunk.Chunk {
+	position := ChunkPosition{chunkX, chunkZ}
+	if c, ok := e.cache[position]; ok {
+		return c
+	}
+
+	c := e.chunks(chunkX, chunkZ)
+	if c != nil && c.Light == nil {
+		c = nil
+	}
+
+	e.cache[position] = c
+	return c
+}
+
+func (e *Engine) block(x, y, z int) (int, bool) {
+	if y < chunk.MinY || y >= chunk.MinY+chunk.ChunkHeight {
+		return 0, false
+	}
+
+	c := e.chunk(x>>4, z>>4)
+	if c == nil {
+		return 0, false
+	}
+
+	return c.GetBlock(x&15, y, z&15), true
+}
+
+func (e *Engine) get(k kind, x, y, z int) byte {
+	c := e.chunk(x>>4, z>>4)
+	if c == nil {
+		return 0
+	}
+
+	if k == skyLight {
+		return c.Light.GetSky(x&15, y, z&15)
+	}
+	return c.Light.GetBlock(x&15, y, z&15)
+}
+
+func (e *Engine) set(k kind, x, y, z int, level byte) {
+	c := e.chunk(x>>4, z>>4)
+	if c == nil {
+		return
+	}
+
+	if k == skyLight {
+		c.Light.SetSky(x&15, y, z&15, level)
+	} else {
+		c.Light.SetBlock(x&15, y, z&15, level)
+	}
+
+	position := ChunkPosition{x >> 4, z >> 4}
+	e.changes[position] |= 1 << chunk.LightSectionIndex(y)
+}
+
+// mayEmit checks whether any block of the section could be a light source.
+func (e *Engine) mayEmit(section *chunk.Section) bool {
+	if section.BlockStates.IsDirect() {
+		return true
+	}
+
+	for _, state := range section.BlockStates.Palette() {
+		if e.properties.Emission(state) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
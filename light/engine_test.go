@@ -0,0 +1,265 @@
+package light
+
+import (
+	"github.com/mkorman9/go-minecraft-server/chunk"
+	"github.com/mkorman9/go-minecraft-server/types"
+	"testing"
+)
+
+const (
+	air   = 0
+	stone = 1
+	glass = 2
+	lamp  = 3
+	water = 4
+)
+
+type testProperties struct {
+}
+
+func (tp *testProperties) Opacity(state int) int {
+	switch state {
+	case air, glass:
+		return 0
+	case water:
+		return 1
+	default:
+		return 15
+	}
+}
+
+func (tp *testProperties) Emission(state int) int {
+	if state == lamp {
+		return 15
+	}
+	return 0
+}
+
+type testWorld struct {
+	chunks map[ChunkPosition]*chunk.Chunk
+	engine *Engine
+}
+
+func newTestWorld() *testWorld {
+	tw := &testWorld{
+		chunks: make(map[ChunkPosition]*chunk.Chunk),
+	}
+	tw.engine = NewEngine(&testProperties{}, func(x, z int) *chunk.Chunk {
+		return tw.chunks[ChunkPosition{x, z}]
+	})
+	return tw
+}
+
+// load places a flat chunk with stone up to y=0 and lights it.
+func (tw *testWorld) load(x, z int, modify func(c *chunk.Chunk)) *chunk.Chunk {
+	c := chunk.NewChunk()
+	for i := 0; i < -chunk.MinY/chunk.SectionHeight; i++ {
+		c.Sections[i] = chunk.NewSection(stone, chunk.DefaultBiome)
+	}
+	if modify != nil {
+		modify(c)
+	}
+
+	tw.chunks[ChunkPosition{x, z}] = c
+	tw.engine.LightChunk(x, z, c)
+	return c
+}
+
+func (tw *testWorld) setBlock(x, y, z int, state int) Changes {
+	tw.chunks[ChunkPosition{x >> 4, z >> 4}].SetBlock(x&15, y, z&15, state)
+	return tw.engine.UpdateBlock(x, y, z)
+}
+
+func (tw *testWorld) sky(x, y, z int) byte {
+	return tw.chunks[ChunkPosition{x >> 4, z >> 4}].Light.GetSky(x&15, y, z&15)
+}
+
+func (tw *testWorld) block(x, y, z int) byte {
+	return tw.chunks[ChunkPosition{x >> 4, z >> 4}].Light.GetBlock(x&15, y, z&15)
+}
+
+func TestSkyLight(t *testing.T) {
+	tw := newTestWorld()
+	tw.load(0, 0, nil)
+
+	if tw.sky(3, 0, 3) != 15 || tw.sky(3, 200, 3) != 15 {
+		t.Fatalf("expected full sky light above the ground")
+	}
+	if tw.sky(3, -1, 3) != 0 {
+		t.Fatalf("expected no sky light underground")
+	}
+}
+
+func TestSkyLightUnderRoof(t *testing.T) {
+	tw := newTestWorld()
+	tw.load(0, 0, func(c *chunk.Chunk) {
+		for x := 0; x < 8; x++ {
+			for z := 0; z < 16; z++ {
+				c.SetBlock(x, 5, z, stone)
+			}
+		}
+	})
+
+	for x := 0; x < 8; x++ {
+		expected := byte(15 - (8 - x))
+		if tw.sky(x, 2, 8) != expected {
+			t.Fatalf("expected sky light %d at x=%d under the roof, got %d", expected, x, tw.sky(x, 2, 8))
+		}
+	}
+}
+
+func TestSkyLightThroughWater(t *testing.T) {
+	tw := newTestWorld()
+	tw.load(0, 0, func(c *chunk.Chunk) {
+		for x := 0; x < chunk.ChunkSize; x++ {
+			for z := 0; z < chunk.ChunkSize; z++ {
+				for y := 0; y < 3; y++ {
+					c.SetBlock(x, y, z, water)
+				}
+			}
+		}
+	})
+
+	for y, expected := range []byte{12, 13, 14} {
+		if tw.sky(4, y, 4) != expected {
+			t.Fatalf("expected sky light %d at y=%d, got %d", expected, y, tw.sky(4, y, 4))
+		}
+	}
+}
+
+func TestBlockLightAcrossBorders(t *testing.T) {
+	tw := newTestWorld()
+	tw.load(0, 0, func(c *chunk.Chunk) {
+		c.SetBlock(14, 1, 8, lamp)
+	})
+
+	tw.load(1, 0, nil)
+
+	if tw.block(14, 1, 8) != 15 || tw.block(15, 1, 8) != 14 {
+		t.Fatalf("expected the lamp to light its surroundings")
+	}
+	if tw.block(16, 1, 8) != 13 || tw.block(20, 1, 8) != 9 {
+		t.Fatalf("expected light to flow into the neighbour, got %d", tw.block(16, 1, 8))
+	}
+
+	tw.setBlock(18, 1, 8, lamp)
+	if tw.block(16, 1, 8) != 13 || tw.block(17, 1, 8) != 14 {
+		t.Fatalf("expected the second lamp to light its surroundings")
+	}
+
+	changes := tw.setBlock(14, 1, 8, air)
+	if changes[ChunkPosition{0, 0}] == 0 || changes[ChunkPosition{1, 0}] == 0 {
+		t.Fatalf("expected changes in both chunks, got %v", changes)
+	}
+	if tw.block(14, 1, 8) != 11 || tw.block(15, 1, 8) != 12 {
+		t.Fatalf("expected only the light of the second lamp to remain, got %d", tw.block(15, 1, 8))
+	}
+}
+
+func TestIncrementalUpdatesMatchFullRelight(t *testing.T) {
+	tw := newTestWorld()
+	tw.load(0, 0, nil)
+	tw.load(1, 0, nil)
+	tw.load(0, 1, nil)
+	tw.load(1, 1, nil)
+
+	tw.setBlock(8, 10, 8, stone)
+	for x := 4; x < 28; x++ {
+		for z := 4; z < 28; z++ {
+			tw.setBlock(x, 6, z, glass)
+			tw.setBlock(x, 7, z, stone)
+		}
+	}
+	tw.setBlock(10, 3, 10, lamp)
+	tw.setBlock(16, 0, 16, air)
+	tw.setBlock(20, 2, 5, lamp)
+	tw.setBlock(20, 2, 5, air)
+	tw.setBlock(12, 7, 12, air)
+	tw.setBlock(8, 10, 8, air)
+
+	assertMatchesFullRelight(t, tw)
+}
+
+func TestBatchUpdatesMatchFullRelight(t *testing.T) {
+	tw := newTestWorld()
+	tw.load(0, 0, nil)
+	tw.load(1, 0, nil)
+	tw.load(0, 1, nil)
+	tw.load(1, 1, nil)
+
+	tw.setBlock(15, 2, 15, lamp)
+
+	tw.setBlocks(func(x, y, z int) (int, bool) {
+		if y == 8 && x >= 2 && x < 30 && z >= 2 && z < 30 {
+			return stone, true
+		}
+		if y == 3 && x >= 10 && x < 20 && z >= 10 && z < 20 {
+			return glass, true
+		}
+		return 0, false
+	})
+	tw.setBlocks(func(x, y, z int) (int, bool) {
+		if (y == 8 && x >= 12 && x < 18 && z >= 12 && z < 18) || (x == 15 && y == 2 && z == 15) {
+			return air, true
+		}
+		if x == 4 && y == 5 && z == 20 {
+			return lamp, true
+		}
+		return 0, false
+	})
+
+	assertMatchesFullRelight(t, tw)
+}
+
+// setBlocks changes blocks selected by the function in all loaded chunks, and updates light in one batch.
+func (tw *testWorld) setBlocks(selector func(x, y, z int) (int, bool)) Changes {
+	var positions []types.Position
+
+	for position, c := range tw.chunks {
+		for x := 0; x < chunk.ChunkSize; x++ {
+			for z := 0; z < chunk.ChunkSize; z++ {
+				for y := -10; y < 40; y++ {
+					blockX, blockZ := position.X*chunk.ChunkSize+x, position.Z*chunk.ChunkSize+z
+					if state, ok := selector(blockX, y, blockZ); ok {
+						c.SetBlock(x, y, z, state)
+						positions = append(positions, types.Position{X: blockX, Y: y, Z: blockZ})
+					}
+				}
+			}
+		}
+	}
+
+	return tw.engine.UpdateBlocks(positions)
+}
+
+// assertMatchesFullRelight compares light of all chunks with the one computed from scratch.
+func assertMatchesFullRelight(t *testing.T, tw *testWorld) {
+	fresh := newTestWorld()
+	for position, c := range tw.chunks {
+		clone := c.Clone()
+		clone.Light = nil
+		fresh.chunks[position] = clone
+	}
+	for _, position := range []ChunkPosition{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+		fresh.engine.LightChunk(position.X, position.Z, fresh.chunks[position])
+	}
+
+	for position, c := range tw.chunks {
+		expected := fresh.chunks[position]
+
+		for x := 0; x < chunk.ChunkSize; x++ {
+			for z := 0; z < chunk.ChunkSize; z++ {
+				for y := -10; y < 40; y++ {
+					if c.Light.GetSky(x, y, z) != expected.Light.GetSky(x, y, z) {
+						t.Fatalf("chunk %v: sky light mismatch at %d %d %d: %d != %d",
+							position, x, y, z, c.Light.GetSky(x, y, z), expected.Light.GetSky(x, y, z))
+					}
+					if c.Light.GetBlock(x, y, z) != expected.Light.GetBlock(x, y, z) {
+						t.Fatalf("chunk %v: block light mismatch at %d %d %d: %d != %d",
+							position, x, y, z, c.Light.GetBlock(x, y, z), expected.Light.GetBlock(x, y, z))
+					}
+				}
+			}
+		}
+	}
+}
@@ -14,17 +14,9 @@ func main() {
 	rand.Seed(time.Now().Unix())
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
-	settings := &Settings{
-		ServerAddress:         "0.0.0.0:25565",
-		Description:           "Simple Go Server",
-		MaxPlayers:            2137,
-		OnlineMode:            false,
-		CompressionThreshold:  -1,
-		IsDebug:               true,
-		ViewDistance:          10,
-		SimulationDistance:    10,
-		KeepAliveSendInterval: 5,
-		PlayerTimeout:         15,
+	settings, err := LoadSettings(os.Args[1:])
+	if err != nil {
+		log.Fatalln(err)
 	}
 
 	world, err := NewWorld(settings)
@@ -51,6 +43,8 @@ func main() {
 		log.Fatalln(err)
 	}
 
+	world.Shutdown()
+
 	log.Println("exiting")
 }
 
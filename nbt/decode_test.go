@@ -428,3 +428,24 @@ func TestDecoder_Decode_textUnmarshaler(t *testing.T) {
 		t.Errorf("b should be true")
 	}
 }
+
+func TestDecoder_Decode_omitempty(t *testing.T) {
+	type S struct {
+		Name  string  `nbt:"name,omitempty"`
+		Empty string  `nbt:"empty,omitempty"`
+		Data  []int64 `nbt:"data,omitempty"`
+	}
+
+	data, err := Marshal(S{Name: "test", Data: []int64{1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var s S
+	if err := Unmarshal(data, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Name != "test" || s.Empty != "" || len(s.Data) != 2 {
+		t.Errorf("unexpected result: %+v", s)
+	}
+}
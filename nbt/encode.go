@@ -154,6 +154,14 @@ func (e *Encoder) writeValue(val reflect.Value, tagType byte) error {
 
 		for i := 0; i < val.Len(); i++ {
 			arrType, arrVal := getTagType(val.Index(i))
+			if arrVal.CanInterface() {
+				if encoder, ok := arrVal.Interface().(Marshaler); ok {
+					if err := encoder.MarshalNBT(e.w); err != nil {
+						return err
+					}
+					continue
+				}
+			}
 			err := e.writeValue(arrVal, arrType)
 			if err != nil {
 				return err
@@ -28,6 +28,28 @@ func TestRawMessage_Encode(t *testing.T) {
 	}
 }
 
+func TestRawMessage_EncodeList(t *testing.T) {
+	data := []byte{
+		TagCompound, 0, 0,
+		TagList, 0, 4, 'L', 'i', 's', 't', TagString, 0, 0, 0, 2, 0, 1, 'a', 0, 1, 'b',
+		TagEnd,
+	}
+	var container struct {
+		List []RawMessage
+	}
+	container.List = []RawMessage{
+		{Type: TagString, Data: []byte{0, 1, 'a'}},
+		{Type: TagString, Data: []byte{0, 1, 'b'}},
+	}
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(container, ""); err != nil {
+		t.Fatalf("Encode error: %v", err)
+	} else if !bytes.Equal(data, buf.Bytes()) {
+		t.Fatalf("Encode error: want %v, get: %v", data, buf.Bytes())
+	}
+}
+
 func TestRawMessage_Decode(t *testing.T) {
 	data := []byte{
 		TagCompound, 0, 2, 'a', 'b',
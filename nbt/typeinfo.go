@@ -2,6 +2,7 @@ package nbt
 
 import (
 	"reflect"
+	"strings"
 	"sync"
 )
 
@@ -28,6 +29,7 @@ func getTypeInfo(typ reflect.Type) *typeInfo {
 				continue // Private field
 			}
 
+			tag = strings.TrimSuffix(tag, ",omitempty")
 			tInfo.nameToIndex[tag] = i
 			if _, ok := tInfo.nameToIndex[f.Name]; !ok {
 				tInfo.nameToIndex[f.Name] = i
@@ -18,6 +18,7 @@ const (
 	TypePosition
 	TypeSlot
 	TypeBitSet
+	TypeMetadata
 )
 
 type Field struct {
@@ -111,6 +111,8 @@ func (pd *PacketData) WriteTo(writer io.Writer) (int64, error) {
 			err = types.WriteSlot(writer, field.Value.(*types.SlotData))
 		case TypeBitSet:
 			err = types.WriteBitSet(writer, field.Value.(*types.BitSet))
+		case TypeMetadata:
+			err = types.WriteMetadata(writer, field.Value.(*types.Metadata))
 		}
 
 		if err != nil {
@@ -344,3 +346,15 @@ func (pd *PacketData) BitSet(name string) *types.BitSet {
 
 	return nil
 }
+
+func (pd *PacketData) Metadata(name string) *types.Metadata {
+	if i, ok := pd.namesMapping[name]; ok {
+		if pd.Fields[i].Type == TypeMetadata {
+			if value, ok := pd.Fields[i].Value.(*types.Metadata); ok {
+				return value
+			}
+		}
+	}
+
+	return nil
+}
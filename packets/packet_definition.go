@@ -145,15 +145,19 @@ func (pd *PacketDefinition) Read(reader io.Reader) (*PacketData, error) {
 		case TypePosition:
 			var value types.Position
 			value, err = types.ReadPosition(reader)
-			field.Value = value
+			field.Value = &value
 		case TypeSlot:
 			var value types.SlotData
 			value, err = types.ReadSlot(reader)
-			field.Value = value
+			field.Value = &value
 		case TypeBitSet:
 			var value types.BitSet
 			value, err = types.ReadBitSet(reader)
-			field.Value = value
+			field.Value = &value
+		case TypeMetadata:
+			var value types.Metadata
+			value, err = types.ReadMetadata(reader)
+			field.Value = &value
 		}
 
 		if err != nil {
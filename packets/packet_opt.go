@@ -142,6 +142,13 @@ func BitSetField(name string, opts ...PacketFieldOpt) PacketOpt {
 	}
 }
 
+func MetadataField(name string, opts ...PacketFieldOpt) PacketOpt {
+	return func(packet *PacketDefinition) {
+		packet.AddField(name, TypeMetadata)
+		packet.setFieldOpts(name, opts)
+	}
+}
+
 func OnlyIfTrue(fieldName string) PacketFieldOpt {
 	return func(packet *PacketData) bool {
 		return packet.Bool(fieldName)
@@ -154,8 +161,26 @@ func OnlyIfFalse(fieldName string) PacketFieldOpt {
 	}
 }
 
+func OnlyIfNotEqual(fieldName string, value any) PacketFieldOpt {
+	return func(packet *PacketData) bool {
+		return packet.Any(fieldName) != value
+	}
+}
+
 func OnlyIfEqual(fieldName string, value any) PacketFieldOpt {
 	return func(packet *PacketData) bool {
 		return packet.Any(fieldName) == value
 	}
 }
+
+func OnlyIfOneOf(fieldName string, values ...any) PacketFieldOpt {
+	return func(packet *PacketData) bool {
+		value := packet.Any(fieldName)
+		for _, v := range values {
+			if value == v {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"compress/zlib"
 	"github.com/mkorman9/go-minecraft-server/types"
 	"io"
+	"sync"
 )
 
 type PacketWriter struct {
+	m                    sync.Mutex
 	writer               io.Writer
 	compressionThreshold int
 }
@@ -20,10 +22,16 @@ func NewPacketWriter(writer io.Writer) *PacketWriter {
 }
 
 func (pw *PacketWriter) SetCompression(threshold int) {
+	pw.m.Lock()
+	defer pw.m.Unlock()
+
 	pw.compressionThreshold = threshold
 }
 
 func (pw *PacketWriter) SetEncryption(cipherStream *CipherStream) {
+	pw.m.Lock()
+	defer pw.m.Unlock()
+
 	pw.writer = cipherStream.WrapWriter(pw.writer)
 }
 
@@ -35,6 +43,10 @@ func (pw *PacketWriter) Write(packet *PacketData) error {
 		return err
 	}
 
+	// packets may be sent concurrently by broadcasts, their frames must not interleave
+	pw.m.Lock()
+	defer pw.m.Unlock()
+
 	switch pw.compressionThreshold {
 	case -1:
 		// no compression
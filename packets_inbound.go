@@ -208,3 +208,104 @@ var CloseWindowPacket = packets.Packet(
 	packets.ID(0x0b),
 	packets.Byte("windowId"),
 )
+
+/*
+	0x1c: Player Action
+*/
+
+var PlayerActionPacket = packets.Packet(
+	packets.ID(0x1c),
+	packets.VarInt("status"),
+	packets.PositionField("location"),
+	packets.Byte("face"),
+	packets.VarInt("sequence"),
+)
+
+/*
+	0x30: Use Item On
+*/
+
+var UseItemOnPacket = packets.Packet(
+	packets.ID(0x30),
+	packets.VarInt("hand"),
+	packets.PositionField("location"),
+	packets.VarInt("face"),
+	packets.Float32("cursorX"),
+	packets.Float32("cursorY"),
+	packets.Float32("cursorZ"),
+	packets.Bool("insideBlock"),
+	packets.VarInt("sequence"),
+)
+
+/*
+	0x27: Set Held Item
+*/
+
+var SetHeldItemPacket = packets.Packet(
+	packets.ID(0x27),
+	packets.Int16("slot"),
+)
+
+/*
+	0x06: Client Command
+*/
+
+var ClientCommandPacket = packets.Packet(
+	packets.ID(0x06),
+	packets.VarInt("actionId"),
+)
+
+/*
+	0x0f: Interact
+*/
+
+var InteractPacket = packets.Packet(
+	packets.ID(0x0f),
+	packets.VarInt("entityId"),
+	packets.VarInt("type"),
+	packets.Float32("targetX", packets.OnlyIfEqual("type", InteractTypeInteractAt)),
+	packets.Float32("targetY", packets.OnlyIfEqual("type", InteractTypeInteractAt)),
+	packets.Float32("targetZ", packets.OnlyIfEqual("type", InteractTypeInteractAt)),
+	packets.VarInt("hand", packets.OnlyIfNotEqual("type", InteractTypeAttack)),
+	packets.Bool("sneaking"),
+)
+
+/*
+	0x0a: Click Container
+*/
+
+var ClickContainerPacket = packets.Packet(
+	packets.ID(0x0a),
+	packets.Byte("windowId"),
+	packets.VarInt("stateId"),
+	packets.Int16("slot"),
+	packets.Byte("button"),
+	packets.VarInt("mode"),
+	packets.Array(
+		"changedSlots",
+		packets.ArrayLengthPrefixed,
+		packets.Int16("slot"),
+		packets.Slot("item"),
+	),
+	packets.Slot("carriedItem"),
+)
+
+/*
+	0x20: Change Recipe Book Settings
+*/
+
+var ChangeRecipeBookSettingsPacket = packets.Packet(
+	packets.ID(0x20),
+	packets.VarInt("bookId"),
+	packets.Bool("bookOpen"),
+	packets.Bool("filterActive"),
+)
+
+/*
+	0x21: Set Seen Recipe
+*/
+
+var SetSeenRecipePacket = packets.Packet(
+	packets.ID(0x21),
+	packets.String("recipeId"),
+)
@@ -4,6 +4,7 @@ import (
 	"github.com/mkorman9/go-minecraft-server/chunk"
 	"github.com/mkorman9/go-minecraft-server/nbt"
 	"github.com/mkorman9/go-minecraft-server/packets"
+	"github.com/mkorman9/go-minecraft-server/recipes"
 )
 
 /*
@@ -130,6 +131,25 @@ var KeepAlivePacket = packets.Packet(
 	packets.Int64("keepAliveId"),
 )
 
+/*
+	0x30: Player Chat Message
+*/
+
+var PlayerChatMessagePacket = packets.Packet(
+	packets.ID(0x30),
+	packets.String("signedContent"),
+	packets.Bool("hasUnsignedContent"),
+	packets.String("unsignedContent", packets.OnlyIfEqual("hasUnsignedContent", true)),
+	packets.VarInt("type"),
+	packets.UUIDField("senderUUID"),
+	packets.String("senderName"),
+	packets.Bool("hasTeamName"),
+	packets.String("teamName", packets.OnlyIfEqual("hasTeamName", true)),
+	packets.Int64("timestamp"),
+	packets.Int64("salt"),
+	packets.ByteArray("signature"),
+)
+
 /*
 	0x5f: System Chat
 */
@@ -260,3 +280,407 @@ var MapChunkPacket = packets.Packet(
 		packets.ByteArray("value"),
 	),
 )
+
+/*
+	0x22: Update Light
+*/
+
+var UpdateLightPacket = packets.Packet(
+	packets.ID(0x22),
+	packets.VarInt("x"),
+	packets.VarInt("z"),
+	packets.Bool("trustEdges"),
+	packets.BitSetField("skyLightMask"),
+	packets.BitSetField("blockLightMask"),
+	packets.BitSetField("emptySkyLightMask"),
+	packets.BitSetField("emptyBlockLightMask"),
+	packets.Array(
+		"skyLights",
+		packets.ArrayLengthPrefixed,
+		packets.ByteArray("value"),
+	),
+	packets.Array(
+		"blockLights",
+		packets.ArrayLengthPrefixed,
+		packets.ByteArray("value"),
+	),
+)
+
+/*
+	0x48: Set Center Chunk
+*/
+
+var SetCenterChunkPacket = packets.Packet(
+	packets.ID(0x48),
+	packets.VarInt("x"),
+	packets.VarInt("z"),
+)
+
+/*
+	0x1a: Unload Chunk
+*/
+
+var UnloadChunkPacket = packets.Packet(
+	packets.ID(0x1a),
+	packets.Int32("x"),
+	packets.Int32("z"),
+)
+
+/*
+	0x09: Block Update
+*/
+
+var BlockUpdatePacket = packets.Packet(
+	packets.ID(0x09),
+	packets.PositionField("location"),
+	packets.VarInt("blockId"),
+)
+
+/*
+	0x05: Acknowledge Block Change
+*/
+
+var AckBlockChangePacket = packets.Packet(
+	packets.ID(0x05),
+	packets.VarInt("sequence"),
+)
+
+/*
+	0x3d: Update Section Blocks
+*/
+
+var UpdateSectionBlocksPacket = packets.Packet(
+	packets.ID(0x3d),
+	packets.Int64("sectionPosition"),
+	packets.Bool("suppressLightUpdates"),
+	packets.Array(
+		"blocks",
+		packets.ArrayLengthPrefixed,
+		packets.VarLong("block"),
+	),
+)
+
+/*
+	0x07: Block Entity Data
+*/
+
+var BlockEntityDataPacket = packets.Packet(
+	packets.ID(0x07),
+	packets.PositionField("location"),
+	packets.VarInt("type"),
+	packets.NBT("data", &nbt.RawMessage{}),
+)
+
+/*
+	0x02: Spawn Player
+*/
+
+var SpawnPlayerPacket = packets.Packet(
+	packets.ID(0x02),
+	packets.VarInt("entityId"),
+	packets.UUIDField("uuid"),
+	packets.Float64("x"),
+	packets.Float64("y"),
+	packets.Float64("z"),
+	packets.Byte("yaw"),
+	packets.Byte("pitch"),
+)
+
+/*
+	0x26: Update Entity Position
+*/
+
+var UpdateEntityPositionPacket = packets.Packet(
+	packets.ID(0x26),
+	packets.VarInt("entityId"),
+	packets.Int16("deltaX"),
+	packets.Int16("deltaY"),
+	packets.Int16("deltaZ"),
+	packets.Bool("onGround"),
+)
+
+/*
+	0x27: Update Entity Position and Rotation
+*/
+
+var UpdateEntityPositionAndRotationPacket = packets.Packet(
+	packets.ID(0x27),
+	packets.VarInt("entityId"),
+	packets.Int16("deltaX"),
+	packets.Int16("deltaY"),
+	packets.Int16("deltaZ"),
+	packets.Byte("yaw"),
+	packets.Byte("pitch"),
+	packets.Bool("onGround"),
+)
+
+/*
+	0x28: Update Entity Rotation
+*/
+
+var UpdateEntityRotationPacket = packets.Packet(
+	packets.ID(0x28),
+	packets.VarInt("entityId"),
+	packets.Byte("yaw"),
+	packets.Byte("pitch"),
+	packets.Bool("onGround"),
+)
+
+/*
+	0x3c: Set Head Rotation
+*/
+
+var SetHeadRotationPacket = packets.Packet(
+	packets.ID(0x3c),
+	packets.VarInt("entityId"),
+	packets.Byte("headYaw"),
+)
+
+/*
+	0x63: Teleport Entity
+*/
+
+var TeleportEntityPacket = packets.Packet(
+	packets.ID(0x63),
+	packets.VarInt("entityId"),
+	packets.Float64("x"),
+	packets.Float64("y"),
+	packets.Float64("z"),
+	packets.Byte("yaw"),
+	packets.Byte("pitch"),
+	packets.Bool("onGround"),
+)
+
+/*
+	0x38: Remove Entities
+*/
+
+var RemoveEntitiesPacket = packets.Packet(
+	packets.ID(0x38),
+	packets.Array(
+		"entities",
+		packets.ArrayLengthPrefixed,
+		packets.VarInt("entityId"),
+	),
+)
+
+/*
+	0x4d: Set Entity Metadata
+*/
+
+var SetEntityMetadataPacket = packets.Packet(
+	packets.ID(0x4d),
+	packets.VarInt("entityId"),
+	packets.MetadataField("metadata"),
+)
+
+/*
+	0x03: Entity Animation
+*/
+
+var EntityAnimationPacket = packets.Packet(
+	packets.ID(0x03),
+	packets.VarInt("entityId"),
+	packets.Byte("animation"),
+)
+
+/*
+	0x18: Entity Event
+*/
+
+var EntityEventPacket = packets.Packet(
+	packets.ID(0x18),
+	packets.Int32("entityId"),
+	packets.Byte("status"),
+)
+
+/*
+	0x52: Set Health
+*/
+
+var SetHealthPacket = packets.Packet(
+	packets.ID(0x52),
+	packets.Float32("health"),
+	packets.VarInt("food"),
+	packets.Float32("saturation"),
+)
+
+/*
+	0x33: Combat Death
+*/
+
+var CombatDeathPacket = packets.Packet(
+	packets.ID(0x33),
+	packets.VarInt("playerId"),
+	packets.Int32("entityId"),
+	packets.String("message"),
+)
+
+/*
+	0x3b: Respawn
+*/
+
+var RespawnPacket = packets.Packet(
+	packets.ID(0x3b),
+	packets.String("dimensionType"),
+	packets.String("dimensionName"),
+	packets.Int64("hashedSeed"),
+	packets.Byte("gameMode"),
+	packets.Byte("previousGameMode"),
+	packets.Bool("isDebug"),
+	packets.Bool("isFlat"),
+	packets.Bool("copyMetadata"),
+	packets.Bool("hasDeath"),
+	packets.String("deathDimension", packets.OnlyIfTrue("hasDeath")),
+	packets.PositionField("deathLocation", packets.OnlyIfTrue("hasDeath")),
+)
+
+/*
+	0x4f: Set Entity Velocity
+*/
+
+var SetEntityVelocityPacket = packets.Packet(
+	packets.ID(0x4f),
+	packets.VarInt("entityId"),
+	packets.Int16("velocityX"),
+	packets.Int16("velocityY"),
+	packets.Int16("velocityZ"),
+)
+
+/*
+	0x11: Set Container Content
+*/
+
+var SetContainerContentPacket = packets.Packet(
+	packets.ID(0x11),
+	packets.Byte("windowId"),
+	packets.VarInt("stateId"),
+	packets.Array(
+		"slots",
+		packets.ArrayLengthPrefixed,
+		packets.Slot("item"),
+	),
+	packets.Slot("carriedItem"),
+)
+
+/*
+	0x13: Set Container Slot
+*/
+
+var SetContainerSlotPacket = packets.Packet(
+	packets.ID(0x13),
+	packets.Byte("windowId"),
+	packets.VarInt("stateId"),
+	packets.Int16("slot"),
+	packets.Slot("item"),
+)
+
+/*
+	0x47: Set Held Item
+*/
+
+var SetCarriedItemPacket = packets.Packet(
+	packets.ID(0x47),
+	packets.Byte("slot"),
+)
+
+/*
+	0x2b: Open Screen
+*/
+
+var OpenScreenPacket = packets.Packet(
+	packets.ID(0x2b),
+	packets.VarInt("windowId"),
+	packets.VarInt("windowType"),
+	packets.String("title"),
+)
+
+/*
+	0x10: Close Container
+*/
+
+var CloseContainerPacket = packets.Packet(
+	packets.ID(0x10),
+	packets.Byte("windowId"),
+)
+
+/*
+	0x67: Update Recipes
+*/
+
+var onlyIfShaped = packets.OnlyIfEqual("type", recipes.TypeShaped)
+var onlyIfShapeless = packets.OnlyIfEqual("type", recipes.TypeShapeless)
+var onlyIfCooking = packets.OnlyIfOneOf(
+	"type",
+	recipes.TypeSmelting,
+	recipes.TypeBlasting,
+	recipes.TypeSmoking,
+	recipes.TypeCampfireCooking,
+)
+
+var ingredientFields = packets.Fields(
+	packets.Array(
+		"items",
+		packets.ArrayLengthPrefixed,
+		packets.Slot("item"),
+	),
+)
+
+var UpdateRecipesPacket = packets.Packet(
+	packets.ID(0x67),
+	packets.Array(
+		"recipes",
+		packets.ArrayLengthPrefixed,
+		packets.String("type"),
+		packets.String("recipeId"),
+		packets.VarInt("width", onlyIfShaped),
+		packets.VarInt("height", onlyIfShaped),
+		packets.String("group"),
+		packets.ArrayWithOptions(
+			"shapedIngredients",
+			func(packet *packets.PacketData) int {
+				return packet.VarInt("width") * packet.VarInt("height")
+			},
+			ingredientFields,
+			onlyIfShaped,
+		),
+		packets.ArrayWithOptions("ingredients", packets.ArrayLengthPrefixed, ingredientFields, onlyIfShapeless),
+		packets.ArrayWithOptions(
+			"ingredient",
+			packets.ArrayLengthPrefixed,
+			packets.Fields(packets.Slot("item")),
+			onlyIfCooking,
+		),
+		packets.Slot("result"),
+		packets.Float32("experience", onlyIfCooking),
+		packets.VarInt("cookingTime", onlyIfCooking),
+	),
+)
+
+/*
+	0x37: Update Recipe Book
+*/
+
+var UpdateRecipeBookPacket = packets.Packet(
+	packets.ID(0x37),
+	packets.VarInt("action"),
+	packets.Bool("craftingBookOpen"),
+	packets.Bool("craftingBookFilter"),
+	packets.Bool("smeltingBookOpen"),
+	packets.Bool("smeltingBookFilter"),
+	packets.Bool("blastFurnaceBookOpen"),
+	packets.Bool("blastFurnaceBookFilter"),
+	packets.Bool("smokerBookOpen"),
+	packets.Bool("smokerBookFilter"),
+	packets.Array(
+		"recipeIds",
+		packets.ArrayLengthPrefixed,
+		packets.String("id"),
+	),
+	packets.ArrayWithOptions(
+		"highlightedRecipeIds",
+		packets.ArrayLengthPrefixed,
+		packets.Fields(packets.String("id")),
+		packets.OnlyIfEqual("action", RecipeBookActionInit),
+	),
+)
@@ -11,12 +11,21 @@ const (
 	SystemChatMessageTypeGameInfo = 2
 )
 
+type ChatMode = int
+
+const (
+	ChatModeEnabled      = 0
+	ChatModeCommandsOnly = 1
+	ChatModeHidden       = 2
+)
+
 type GameMode = byte
 
 const (
 	GameModeSurvival  byte = 0
 	GameModeCreative  byte = 1
 	GameModeAdventure byte = 2
+	GameModeSpectator byte = 3
 	GameModeUnknown   byte = 255
 )
 
@@ -33,3 +42,91 @@ const (
 	EntityActionOpenHorseInventory    = 7
 	EntityActionStartFlyingWithElytra = 8
 )
+
+type DiggingStatus = int
+
+const (
+	DiggingStatusStarted       = 0
+	DiggingStatusCancelled     = 1
+	DiggingStatusFinished      = 2
+	DiggingStatusDropItemStack = 3
+	DiggingStatusDropItem      = 4
+	DiggingStatusReleaseItem   = 5
+	DiggingStatusSwapItem      = 6
+)
+
+type BlockFace = int
+
+const (
+	BlockFaceBottom = 0
+	BlockFaceTop    = 1
+	BlockFaceNorth  = 2
+	BlockFaceSouth  = 3
+	BlockFaceWest   = 4
+	BlockFaceEast   = 5
+)
+
+type Hand = int
+
+const (
+	HandMain = 0
+	HandOff  = 1
+)
+
+type ClientCommand = int
+
+const (
+	ClientCommandRespawn      = 0
+	ClientCommandRequestStats = 1
+)
+
+type RecipeBookAction = int
+
+const (
+	RecipeBookActionInit   = 0
+	RecipeBookActionAdd    = 1
+	RecipeBookActionRemove = 2
+)
+
+type RecipeBookType = int
+
+const (
+	RecipeBookCrafting     = 0
+	RecipeBookFurnace      = 1
+	RecipeBookBlastFurnace = 2
+	RecipeBookSmoker       = 3
+
+	RecipeBookTypesCount = 4
+)
+
+type EntityAnimation = byte
+
+const (
+	EntityAnimationSwingMainArm        byte = 0
+	EntityAnimationTakeDamage          byte = 1
+	EntityAnimationLeaveBed            byte = 2
+	EntityAnimationSwingOffhand        byte = 3
+	EntityAnimationCriticalEffect      byte = 4
+	EntityAnimationMagicCriticalEffect byte = 5
+)
+
+type EntityStatus = byte
+
+const (
+	EntityStatusHurt                byte = 2
+	EntityStatusDeath               byte = 3
+	EntityStatusFinishUsingItem     byte = 9
+	EntityStatusEnableReducedDebug  byte = 22
+	EntityStatusDisableReducedDebug byte = 23
+	EntityStatusOpPermissionLevel0  byte = 24
+	EntityStatusOpPermissionLevel4  byte = 28
+	EntityStatusShieldBlock         byte = 29
+	EntityStatusShieldBreak         byte = 30
+	EntityStatusThornsHurt          byte = 33
+	EntityStatusTotemOfUndying      byte = 35
+	EntityStatusDrownHurt           byte = 36
+	EntityStatusBurnHurt            byte = 37
+	EntityStatusSpawnCloudParticles byte = 43
+	EntityStatusSweetBerryBushHurt  byte = 44
+	EntityStatusFreezeHurt          byte = 57
+)
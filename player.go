@@ -2,8 +2,14 @@ package main
 
 import (
 	"crypto/rsa"
+	"github.com/mkorman9/go-minecraft-server/blocks"
+	"github.com/mkorman9/go-minecraft-server/chunk"
+	"github.com/mkorman9/go-minecraft-server/items"
+	"github.com/mkorman9/go-minecraft-server/recipes"
 	"github.com/mkorman9/go-minecraft-server/types"
 	"log"
+	"math"
+	"sync"
 	"time"
 )
 
@@ -28,12 +34,33 @@ type Player struct {
 	Textures          string
 	TexturesSignature string
 	Ping              int
+	Health            float32
+	Food              int
+	Saturation        float32
 
 	packetHandler     *PlayerPacketHandler
 	world             *World
+	chunkView         *ChunkView
 	lastKeepAliveID   int64
 	lastHeartbeat     time.Time
 	lastHeartbeatSent time.Time
+	heldItemSlot      int
+	inventory         *Inventory
+	inventoryWindow   *Window
+	openWindow        *Window
+	lastWindowID      byte
+	windowMutex       sync.Mutex
+	positionMutex     sync.RWMutex
+	healthMutex       sync.Mutex
+	recipeBook        *RecipeBook
+	digging           *diggingProgress
+	metadata          *EntityMetadata
+	dead              bool
+	deathLocation     *types.Position
+	lastDamage        lastDamage
+	lastAttack        time.Time
+	lastChatTimestamp time.Time
+	falling           bool
 }
 
 type PlayerClientSettings struct {
@@ -48,15 +75,54 @@ type PlayerClientSettings struct {
 }
 
 func NewPlayer(world *World, ip string) *Player {
-	return &Player{
+	player := &Player{
 		Name:        "",
 		DisplayName: NewChatMessage(""),
 		UUID:        types.GetRandomUUID(),
 		EntityID:    -1,
 		IP:          ip,
 		GameMode:    GameModeUnknown,
+		Health:      MaxHealth,
+		Food:        MaxFood,
+		Saturation:  DefaultSaturation,
 		world:       world,
+		chunkView:   NewChunkView(),
+		metadata:    NewEntityMetadata(),
+		inventory:   NewInventory(PlayerInventorySize, world.Data().Items),
+		recipeBook:  NewRecipeBook(),
 	}
+	player.inventoryWindow = newPlayerInventoryWindow(player)
+
+	return player
+}
+
+func (p *Player) ID() int32 {
+	return p.EntityID
+}
+
+func (p *Player) UniqueID() types.UUID {
+	return p.UUID
+}
+
+// Position returns coordinates of the player. Other goroutines than the one of the player's connection
+// have to use it instead of reading X, Y and Z directly.
+func (p *Player) Position() (x, y, z float64) {
+	p.positionMutex.RLock()
+	defer p.positionMutex.RUnlock()
+
+	return p.X, p.Y, p.Z
+}
+
+// IsOnGround tells whether the player stands on the ground, and is safe to call from any goroutine.
+func (p *Player) IsOnGround() bool {
+	p.positionMutex.RLock()
+	defer p.positionMutex.RUnlock()
+
+	return p.OnGround
+}
+
+func (p *Player) Metadata() *EntityMetadata {
+	return p.metadata
 }
 
 func (p *Player) Kick(reason *ChatMessage) {
@@ -74,12 +140,44 @@ func (p *Player) SendSystemChatMessage(message *ChatMessage) {
 	}
 }
 
+func (p *Player) SendPlayerChatMessage(message *PlayerChatMessage, unsignedContent *ChatMessage, chatType int32) {
+	err := p.packetHandler.sendPlayerChatMessage(message, unsignedContent, chatType)
+	if err != nil {
+		log.Printf("Failed to send player chat message: %v\n", err)
+	}
+}
+
+// ChatMode returns which chat messages the player wants to receive, as set in the client options.
+func (p *Player) ChatMode() ChatMode {
+	if p.ClientSettings == nil {
+		return ChatModeEnabled
+	}
+
+	return p.ClientSettings.ChatFlags
+}
+
 func (p *Player) SetPosition(x, y, z float64) {
+	p.setPosition(x, y, z)
+	p.world.Entities().Move(p)
+
+	_ = p.packetHandler.SynchronizePosition(x, y, z)
+
+	err := p.UpdateChunkView()
+	if err != nil {
+		log.Printf("Failed to update chunk view: %v\n", err)
+	}
+
+	p.world.EntityTracker().Update(p)
+}
+
+// setPosition changes coordinates of the player, without telling anyone about it.
+func (p *Player) setPosition(x, y, z float64) {
+	p.positionMutex.Lock()
+	defer p.positionMutex.Unlock()
+
 	p.X = x
 	p.Y = y
 	p.Z = z
-
-	_ = p.packetHandler.SynchronizePosition(x, y, z)
 }
 
 func (p *Player) SendKeepAlive(keepAliveID int64) {
@@ -88,6 +186,435 @@ func (p *Player) SendKeepAlive(keepAliveID int64) {
 	_ = p.packetHandler.SendKeepAlive(keepAliveID)
 }
 
+func (p *Player) SendUpdateLight(position ChunkPosition, light *chunk.Light, sections uint32) {
+	err := p.packetHandler.sendUpdateLight(position, light, sections)
+	if err != nil {
+		log.Printf("Failed to send light update: %v\n", err)
+	}
+}
+
+func (p *Player) SendBlockUpdate(x, y, z int, state int) {
+	err := p.packetHandler.sendBlockUpdate(x, y, z, state)
+	if err != nil {
+		log.Printf("Failed to send block update: %v\n", err)
+	}
+}
+
+func (p *Player) SendSectionBlocksUpdate(position SectionPosition, changes []BlockChange) {
+	err := p.packetHandler.sendSectionBlocksUpdate(position, changes)
+	if err != nil {
+		log.Printf("Failed to send section blocks update: %v\n", err)
+	}
+}
+
+func (p *Player) SendBlockEntityData(x, y, z int, blockEntity *chunk.BlockEntity) {
+	err := p.packetHandler.sendBlockEntityData(x, y, z, blockEntity)
+	if err != nil {
+		log.Printf("Failed to send block entity data: %v\n", err)
+	}
+}
+
+func (p *Player) AcknowledgeBlockChange(sequence int) {
+	err := p.packetHandler.sendAckBlockChange(sequence)
+	if err != nil {
+		log.Printf("Failed to acknowledge block change: %v\n", err)
+	}
+}
+
+func (p *Player) SendSpawnPlayer(player *Player, yaw, pitch byte) {
+	err := p.packetHandler.sendSpawnPlayer(player, yaw, pitch)
+	if err != nil {
+		log.Printf("Failed to send spawn player: %v\n", err)
+	}
+}
+
+func (p *Player) SendEntityPosition(entityID int32, dx, dy, dz int16, onGround bool) {
+	err := p.packetHandler.sendEntityPosition(entityID, dx, dy, dz, onGround)
+	if err != nil {
+		log.Printf("Failed to send entity position: %v\n", err)
+	}
+}
+
+func (p *Player) SendEntityPositionAndRotation(entityID int32, dx, dy, dz int16, yaw, pitch byte, onGround bool) {
+	err := p.packetHandler.sendEntityPositionAndRotation(entityID, dx, dy, dz, yaw, pitch, onGround)
+	if err != nil {
+		log.Printf("Failed to send entity position and rotation: %v\n", err)
+	}
+}
+
+func (p *Player) SendEntityRotation(entityID int32, yaw, pitch byte, onGround bool) {
+	err := p.packetHandler.sendEntityRotation(entityID, yaw, pitch, onGround)
+	if err != nil {
+		log.Printf("Failed to send entity rotation: %v\n", err)
+	}
+}
+
+func (p *Player) SendHeadRotation(entityID int32, headYaw byte) {
+	err := p.packetHandler.sendHeadRotation(entityID, headYaw)
+	if err != nil {
+		log.Printf("Failed to send head rotation: %v\n", err)
+	}
+}
+
+func (p *Player) SendTeleportEntity(entityID int32, x, y, z float64, yaw, pitch byte, onGround bool) {
+	err := p.packetHandler.sendTeleportEntity(entityID, x, y, z, yaw, pitch, onGround)
+	if err != nil {
+		log.Printf("Failed to send entity teleport: %v\n", err)
+	}
+}
+
+func (p *Player) SendEntityMetadata(entityID int32, metadata *types.Metadata) {
+	err := p.packetHandler.sendEntityMetadata(entityID, metadata)
+	if err != nil {
+		log.Printf("Failed to send entity metadata: %v\n", err)
+	}
+}
+
+func (p *Player) SendEntityAnimation(entityID int32, animation EntityAnimation) {
+	err := p.packetHandler.sendEntityAnimation(entityID, animation)
+	if err != nil {
+		log.Printf("Failed to send entity animation: %v\n", err)
+	}
+}
+
+func (p *Player) SendEntityEvent(entityID int32, status EntityStatus) {
+	err := p.packetHandler.sendEntityEvent(entityID, status)
+	if err != nil {
+		log.Printf("Failed to send entity event: %v\n", err)
+	}
+}
+
+func (p *Player) SendHealth() {
+	p.healthMutex.Lock()
+	health, food, saturation := p.Health, p.Food, p.Saturation
+	p.healthMutex.Unlock()
+
+	err := p.packetHandler.sendHealth(health, food, saturation)
+	if err != nil {
+		log.Printf("Failed to send health: %v\n", err)
+	}
+}
+
+func (p *Player) SendEntityVelocity(entityID int32, vx, vy, vz int16) {
+	err := p.packetHandler.sendEntityVelocity(entityID, vx, vy, vz)
+	if err != nil {
+		log.Printf("Failed to send entity velocity: %v\n", err)
+	}
+}
+
+func (p *Player) SendRemoveEntities(entityIDs []int32) {
+	err := p.packetHandler.sendRemoveEntities(entityIDs)
+	if err != nil {
+		log.Printf("Failed to send remove entities: %v\n", err)
+	}
+}
+
+// HeldItem returns a copy of the item in the selected hotbar slot, or nil if the slot is empty.
+func (p *Player) HeldItem() *types.SlotData {
+	return p.inventory.Get(InventorySlotHotbarFirst + p.heldItemSlot)
+}
+
+// Inventory returns the window of the player inventory, which is always open.
+func (p *Player) Inventory() *Window {
+	return p.inventoryWindow
+}
+
+// OpenContainer opens a window showing the inventory above the player inventory, closing the window opened
+// before. The size of the inventory has to match the menu type.
+func (p *Player) OpenContainer(menuType MenuType, title *ChatMessage, inventory *Inventory) (*Window, error) {
+	return p.openContainer(menuType, title, inventory, nil)
+}
+
+// OpenMenu opens a read-only window, in which clicks run the handler instead of moving items.
+func (p *Player) OpenMenu(
+	menuType MenuType,
+	title *ChatMessage,
+	inventory *Inventory,
+	onClick func(window *Window, click *ContainerClick),
+) (*Window, error) {
+	return p.openContainer(menuType, title, inventory, onClick)
+}
+
+// OpenWindow returns the container window opened by the player, or nil if there is none.
+func (p *Player) OpenWindow() *Window {
+	p.windowMutex.Lock()
+	defer p.windowMutex.Unlock()
+
+	return p.openWindow
+}
+
+// CloseWindow closes the container window opened by the player, if there is one.
+func (p *Player) CloseWindow() {
+	window := p.takeOpenWindow(nil)
+	if window == nil {
+		return
+	}
+
+	err := p.packetHandler.sendCloseContainer(window.ID)
+	if err != nil {
+		log.Printf("Failed to send close container: %v\n", err)
+	}
+
+	window.Close()
+}
+
+// OpenCraftingTable opens a crafting table window. Items left in the grid go back to the player when it's closed.
+func (p *Player) OpenCraftingTable() (*Window, error) {
+	return p.OpenContainer(MenuCrafting, NewChatMessage("Crafting"), NewInventory(CraftingTableSize, p.world.Data().Items))
+}
+
+// RecipeBook returns the recipes unlocked by the player.
+func (p *Player) RecipeBook() *RecipeBook {
+	return p.recipeBook
+}
+
+// UnlockRecipes adds the recipes to the recipe book of the player, and highlights them as new.
+func (p *Player) UnlockRecipes(ids []string) {
+	added := p.recipeBook.Unlock(ids, true)
+	if len(added) == 0 {
+		return
+	}
+
+	err := p.packetHandler.sendRecipeBook(RecipeBookActionAdd, p.recipeBook.AllSettings(), added, nil)
+	if err != nil {
+		log.Printf("Failed to send recipe book: %v\n", err)
+	}
+}
+
+// LockRecipes removes the recipes from the recipe book of the player.
+func (p *Player) LockRecipes(ids []string) {
+	removed := p.recipeBook.Lock(ids)
+	if len(removed) == 0 {
+		return
+	}
+
+	err := p.packetHandler.sendRecipeBook(RecipeBookActionRemove, p.recipeBook.AllSettings(), removed, nil)
+	if err != nil {
+		log.Printf("Failed to send recipe book: %v\n", err)
+	}
+}
+
+func (p *Player) SendRecipes(all []*recipes.Recipe) {
+	err := p.packetHandler.sendRecipes(all)
+	if err != nil {
+		log.Printf("Failed to send recipes: %v\n", err)
+	}
+}
+
+// SetHeldItemSlot selects the hotbar slot held by the player.
+func (p *Player) SetHeldItemSlot(slot int) {
+	if slot < 0 || slot >= HotbarSize {
+		return
+	}
+
+	p.heldItemSlot = slot
+
+	err := p.packetHandler.sendHeldItem(slot)
+	if err != nil {
+		log.Printf("Failed to send held item: %v\n", err)
+	}
+}
+
+func (p *Player) SendContainerContent(windowID byte, stateID int, items []*types.SlotData, cursor *types.SlotData) {
+	err := p.packetHandler.sendContainerContent(windowID, stateID, items, cursor)
+	if err != nil {
+		log.Printf("Failed to send container content: %v\n", err)
+	}
+}
+
+func (p *Player) SendContainerSlot(windowID byte, stateID int, slot int, item *types.SlotData) {
+	err := p.packetHandler.sendContainerSlot(windowID, stateID, slot, item)
+	if err != nil {
+		log.Printf("Failed to send container slot: %v\n", err)
+	}
+}
+
+// PlayAnimation shows the animation of the player to other players.
+func (p *Player) PlayAnimation(animation EntityAnimation) {
+	p.world.EntityTracker().BroadcastAnimation(p, animation)
+}
+
+// PlayEvent sends the entity event of the player to the player and to other players.
+func (p *Player) PlayEvent(status EntityStatus) {
+	p.world.EntityTracker().BroadcastEvent(p, status)
+}
+
+func (p *Player) SwingHand(hand Hand) {
+	if hand == HandOff {
+		p.PlayAnimation(EntityAnimationSwingOffhand)
+	} else {
+		p.PlayAnimation(EntityAnimationSwingMainArm)
+	}
+}
+
+func (p *Player) IsDead() bool {
+	p.healthMutex.Lock()
+	defer p.healthMutex.Unlock()
+
+	return p.dead
+}
+
+// SetHealth changes health of the player, killing it if health drops to zero.
+func (p *Player) SetHealth(health float32) {
+	if health > MaxHealth {
+		health = MaxHealth
+	}
+
+	p.healthMutex.Lock()
+	if p.dead {
+		p.healthMutex.Unlock()
+		return
+	}
+
+	killed := health <= 0
+	if killed {
+		p.kill()
+	} else {
+		p.Health = health
+	}
+	p.healthMutex.Unlock()
+
+	if killed {
+		p.die(DamageGeneric)
+		return
+	}
+
+	p.SendHealth()
+	p.updateHealthMetadata()
+}
+
+func (p *Player) SetFood(food int, saturation float32) {
+	if food < 0 {
+		food = 0
+	} else if food > MaxFood {
+		food = MaxFood
+	}
+	if saturation < 0 {
+		saturation = 0
+	} else if saturation > float32(food) {
+		saturation = float32(food)
+	}
+
+	p.healthMutex.Lock()
+	p.Food = food
+	p.Saturation = saturation
+	p.healthMutex.Unlock()
+
+	p.SendHealth()
+}
+
+// Damage hurts the player, and returns false if the damage had no effect.
+// Players in creative and spectator mode are hurt only by sources bypassing invulnerability.
+// It is safe to call from any goroutine, e.g. from the connection of an attacking player.
+func (p *Player) Damage(amount float32, source *DamageSource) bool {
+	if amount <= 0 {
+		return false
+	}
+
+	if (p.GameMode == GameModeCreative || p.GameMode == GameModeSpectator) && !source.BypassesInvulnerability() {
+		return false
+	}
+
+	p.healthMutex.Lock()
+	applied, killed := p.takeDamage(amount)
+	p.healthMutex.Unlock()
+
+	if !applied {
+		return false
+	}
+
+	if killed {
+		p.die(source)
+		return true
+	}
+
+	p.SendHealth()
+	p.updateHealthMetadata()
+	p.PlayEvent(EntityStatusHurt)
+
+	return true
+}
+
+// takeDamage lowers health of the player, taking into account the damage it has recently taken.
+// It has to be called with healthMutex locked.
+func (p *Player) takeDamage(amount float32) (applied bool, killed bool) {
+	if p.dead {
+		return false, false
+	}
+
+	now := time.Now()
+	damage := amount
+	if now.Sub(p.lastDamage.at) < DamageInvulnerability {
+		if amount <= p.lastDamage.amount {
+			return false, false
+		}
+
+		damage = amount - p.lastDamage.amount
+		p.lastDamage.amount = amount
+	} else {
+		p.lastDamage = lastDamage{amount: amount, at: now}
+	}
+
+	if p.Health-damage <= 0 {
+		p.kill()
+		return true, true
+	}
+
+	p.Health -= damage
+	return true, false
+}
+
+// SetVelocity pushes the player, with speed given in blocks per tick.
+func (p *Player) SetVelocity(vx, vy, vz float64) {
+	p.world.EntityTracker().BroadcastVelocity(p, encodeVelocity(vx), encodeVelocity(vy), encodeVelocity(vz))
+}
+
+// Respawn brings a dead player back to life at the spawn position of the world.
+func (p *Player) Respawn() {
+	p.healthMutex.Lock()
+	if !p.dead {
+		p.healthMutex.Unlock()
+		return
+	}
+
+	p.dead = false
+	p.Health = MaxHealth
+	p.Food = MaxFood
+	p.Saturation = DefaultSaturation
+	p.lastDamage = lastDamage{}
+	deathLocation := p.deathLocation
+	p.healthMutex.Unlock()
+
+	p.digging = nil
+	p.metadata.Reset()
+
+	p.world.EntityTracker().Untrack(p)
+
+	spawnPosition := p.world.Data().SpawnPosition
+	p.setPosition(float64(spawnPosition.X), float64(spawnPosition.Y), float64(spawnPosition.Z))
+	p.world.Entities().Move(p)
+
+	err := p.packetHandler.sendRespawn(deathLocation)
+	if err != nil {
+		log.Printf("Failed to send respawn: %v\n", err)
+		return
+	}
+
+	// the client drops all chunks on respawn, so the view has to be sent from scratch
+	p.world.Entities().ResetView(p)
+	p.chunkView.Reset()
+
+	err = p.UpdateChunkView()
+	if err != nil {
+		log.Printf("Failed to update chunk view: %v\n", err)
+	}
+
+	_ = p.packetHandler.SynchronizePosition(p.X, p.Y, p.Z)
+	p.SendHealth()
+
+	p.world.EntityTracker().Track(p)
+}
+
 func (p *Player) SendAnotherPlayerJoined(player *Player) {
 	_ = p.packetHandler.sendPlayersAdded([]*Player{player})
 }
@@ -96,7 +623,60 @@ func (p *Player) SendAnotherPlayerDisconnected(player *Player) {
 	_ = p.packetHandler.sendPlayersRemoved([]*Player{player})
 }
 
+func (p *Player) ViewDistance() int {
+	viewDistance := p.world.Settings().ViewDistance
+
+	if p.ClientSettings != nil && int(p.ClientSettings.ViewDistance) < viewDistance {
+		viewDistance = int(p.ClientSettings.ViewDistance)
+		if viewDistance < MinViewDistance {
+			viewDistance = MinViewDistance
+		}
+	}
+
+	return viewDistance
+}
+
+func (p *Player) UpdateChunkView() error {
+	if p.packetHandler.state != PlayerStatePlay {
+		return nil
+	}
+
+	update := p.chunkView.Update(ChunkPositionAt(p.X, p.Z), p.ViewDistance())
+	p.world.Entities().UpdateView(p, update.ToLoad, update.ToUnload)
+
+	if update.CenterChanged {
+		err := p.packetHandler.sendCenterChunk(update.Center)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, position := range update.ToUnload {
+		err := p.packetHandler.sendUnloadChunk(position)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, position := range update.ToLoad {
+		err := p.packetHandler.sendMapChunk(position, p.world.GetChunk(position))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (p *Player) OnJoin(gameMode GameMode) {
+	if data, ok := p.world.PlayerData().Load(p.UUID); ok {
+		p.inventory.Load(data.Inventory)
+		p.heldItemSlot = data.HeldItemSlot
+		p.recipeBook = data.RecipeBook
+	} else {
+		p.recipeBook.Unlock(recipeIDs(p.world.Data().Recipes.All()), false)
+	}
+
 	p.world.BroadcastPlayerJoined(p)
 
 	p.world.JoinPlayer(p)
@@ -105,28 +685,81 @@ func (p *Player) OnJoin(gameMode GameMode) {
 }
 
 func (p *Player) OnDisconnect() {
+	if window := p.takeOpenWindow(nil); window != nil {
+		window.Close()
+	}
+	p.inventoryWindow.Close()
+	p.world.PlayerData().Save(p.UUID, &PlayerData{
+		Inventory:    p.inventory.Contents(),
+		HeldItemSlot: p.heldItemSlot,
+		RecipeBook:   p.recipeBook,
+		GameMode:     p.GameMode,
+	})
+
+	p.world.EntityTracker().Untrack(p)
 	p.world.RemovePlayer(p)
 
 	p.world.BroadcastPlayerDisconnected(p)
 }
 
+func (p *Player) OnClientCommand(action ClientCommand) {
+	switch action {
+	case ClientCommandRespawn:
+		p.Respawn()
+	}
+}
+
 func (p *Player) OnClientSettings(clientSettings *PlayerClientSettings) {
 	p.ClientSettings = clientSettings
+
+	err := p.UpdateChunkView()
+	if err != nil {
+		log.Printf("Failed to update chunk view: %v\n", err)
+	}
+
+	p.world.EntityTracker().Update(p)
 }
 
 func (p *Player) OnPositionUpdate(x float64, y float64, z float64) {
-	p.X = x
-	p.Y = y
-	p.Z = z
+	previousChunk := ChunkPositionAt(p.X, p.Z)
+	p.falling = y < p.Y
+
+	p.setPosition(x, y, z)
+	p.world.Entities().Move(p)
+
+	if y < VoidDamageY {
+		p.Damage(VoidDamage, DamageVoid)
+	}
+
+	if ChunkPositionAt(x, z) != previousChunk {
+		err := p.UpdateChunkView()
+		if err != nil {
+			log.Printf("Failed to update chunk view: %v\n", err)
+		}
+	}
+
+	p.world.EntityTracker().Update(p)
 }
 
 func (p *Player) OnLookUpdate(yaw float32, pitch float32) {
+	p.positionMutex.Lock()
 	p.Yaw = yaw
 	p.Pitch = pitch
+	p.positionMutex.Unlock()
+
+	p.world.EntityTracker().Update(p)
 }
 
 func (p *Player) OnGroundUpdate(onGround bool) {
+	p.positionMutex.Lock()
 	p.OnGround = onGround
+	p.positionMutex.Unlock()
+
+	if onGround && p.metadata.HasFlag(EntityFlagFlyingWithElytra) {
+		p.metadata.SetFlag(EntityFlagFlyingWithElytra, false)
+		p.metadata.SetPose(p.pose())
+		p.world.EntityTracker().UpdateMetadata(p)
+	}
 }
 
 func (p *Player) OnPluginChannel(channel string, data []byte) {
@@ -134,19 +767,127 @@ func (p *Player) OnPluginChannel(channel string, data []byte) {
 }
 
 func (p *Player) OnArmAnimation(hand int) {
+	p.SwingHand(hand)
+}
+
+func (p *Player) OnSetHeldItem(slot int) {
+	if slot < 0 || slot >= HotbarSize {
+		return
+	}
+
+	p.heldItemSlot = slot
+	p.lastAttack = time.Now()
+}
+
+func (p *Player) OnSetCreativeSlot(slot int, item *types.SlotData) {
+	if p.GameMode != GameModeCreative {
+		return
+	}
+
+	if slot < InventorySlotCraftingFirst || slot >= PlayerInventorySize {
+		return
+	}
+
+	if !ValidItem(p.world.Data().Items, item) {
+		log.Printf("Player %s tried to set invalid item in slot %d\n", p.Name, slot)
+		p.inventoryWindow.Resync()
+		return
+	}
+
+	p.inventory.Set(slot, item)
+}
 
+func (p *Player) OnDigging(status DiggingStatus, x, y, z int, sequence int) {
+	switch status {
+	case DiggingStatusStarted, DiggingStatusFinished:
+		if !p.dig(status, x, y, z) {
+			p.SendBlockUpdate(x, y, z, p.world.GetBlock(x, y, z))
+		}
+	case DiggingStatusCancelled:
+		p.digging = nil
+	default:
+		return
+	}
+
+	p.AcknowledgeBlockChange(sequence)
+}
+
+func (p *Player) OnUseItemOn(placement *BlockPlacement, sequence int) {
+	if p.useBlock(placement) {
+		p.AcknowledgeBlockChange(sequence)
+		return
+	}
+
+	if !p.placeBlock(placement) {
+		x, y, z := placement.Adjacent()
+		p.SendBlockUpdate(placement.X, placement.Y, placement.Z, p.world.GetBlock(placement.X, placement.Y, placement.Z))
+		p.SendBlockUpdate(x, y, z, p.world.GetBlock(x, y, z))
+	}
+
+	p.AcknowledgeBlockChange(sequence)
 }
 
 func (p *Player) OnCloseWindow(windowId byte) {
+	if windowId == PlayerInventoryWindowID {
+		p.inventoryWindow.Close()
+		return
+	}
 
+	if window := p.takeOpenWindow(&windowId); window != nil {
+		window.Close()
+	}
+}
+
+func (p *Player) OnClickContainer(click *ContainerClick) {
+	window := p.inventoryWindow
+	if click.WindowID != PlayerInventoryWindowID {
+		window = p.OpenWindow()
+		if window == nil || window.ID != click.WindowID {
+			return
+		}
+	}
+
+	if !window.Click(click) {
+		window.Resync()
+	}
+}
+
+func (p *Player) OnChangeRecipeBookSettings(bookType RecipeBookType, settings RecipeBookSettings) {
+	p.recipeBook.SetSettings(bookType, settings)
+}
+
+func (p *Player) OnSetSeenRecipe(id string) {
+	p.recipeBook.MarkSeen(id)
 }
 
 func (p *Player) OnChatCommand(command string, timestamp time.Time) {
 
 }
 
-func (p *Player) OnChatMessage(message string, timestamp time.Time) {
+func (p *Player) OnChatMessage(message string, timestamp time.Time, salt int64, signature []byte) {
+	if len([]rune(message)) > MaxChatMessageLength || !isAllowedInChat(message) {
+		p.Kick(NewChatMessage("Illegal characters in chat"))
+		return
+	}
 
+	if timestamp.Before(p.lastChatTimestamp) {
+		p.Kick(NewChatMessage("Out-of-order chat packet received"))
+		return
+	}
+	p.lastChatTimestamp = timestamp
+
+	if p.ChatMode() != ChatModeEnabled {
+		p.SendSystemChatMessage(NewChatMessage("You can't send chat messages while chat is hidden in options"))
+		return
+	}
+
+	p.world.BroadcastChatMessage(&PlayerChatMessage{
+		Sender:    p,
+		Content:   message,
+		Timestamp: timestamp,
+		Salt:      salt,
+		Signature: signature,
+	})
 }
 
 func (p *Player) OnKeepAliveResponse(keepAliveID int64) {
@@ -158,6 +899,339 @@ func (p *Player) OnKeepAliveResponse(keepAliveID int64) {
 	}
 }
 
+func (p *Player) OnInteract(interaction *Interaction) {
+	if p.IsDead() || p.GameMode == GameModeSpectator {
+		return
+	}
+
+	entity, ok := p.world.Entities().ByID(interaction.EntityID)
+	if !ok {
+		return
+	}
+
+	target, ok := entity.(*Player)
+	if !ok || target == p || !p.canReachEntity(target) {
+		return
+	}
+
+	// players don't react to being clicked with an item, so only attacks have an effect
+	if interaction.Type == InteractTypeAttack {
+		p.attack(target)
+	}
+}
+
 func (p *Player) OnAction(entityID int, actionID EntityAction, jumpBoost int) {
+	switch actionID {
+	case EntityActionStartSneaking:
+		p.metadata.SetFlag(EntityFlagCrouching, true)
+	case EntityActionStopSneaking:
+		p.metadata.SetFlag(EntityFlagCrouching, false)
+	case EntityActionStartSprinting:
+		p.metadata.SetFlag(EntityFlagSprinting, true)
+	case EntityActionStopSprinting:
+		p.metadata.SetFlag(EntityFlagSprinting, false)
+	case EntityActionStartFlyingWithElytra:
+		if p.OnGround {
+			return
+		}
+		p.metadata.SetFlag(EntityFlagFlyingWithElytra, true)
+	default:
+		return
+	}
+
+	p.metadata.SetPose(p.pose())
+	p.world.EntityTracker().UpdateMetadata(p)
+}
+
+// kill marks the player as dead. It has to be called with healthMutex locked, and followed by die.
+func (p *Player) kill() {
+	x, y, z := p.Position()
+
+	p.dead = true
+	p.Health = 0
+	p.deathLocation = types.NewPosition(int(math.Floor(x)), int(math.Floor(y)), int(math.Floor(z)))
+}
+
+// die tells the player and everyone else that the player has been killed.
+func (p *Player) die(source *DamageSource) {
+	p.SendHealth()
+	p.updateHealthMetadata()
+	p.metadata.SetPose(PoseDying)
+	p.world.EntityTracker().UpdateMetadata(p)
+	p.PlayEvent(EntityStatusDeath)
+
+	killerID := int32(-1)
+	if source.Attacker != nil {
+		killerID = source.Attacker.EntityID
+	}
+
+	message := NewChatMessage(DeathMessage(p.world.Settings().DeathMessage, p, source))
+
+	err := p.packetHandler.sendCombatDeath(killerID, message)
+	if err != nil {
+		log.Printf("Failed to send combat death: %v\n", err)
+	}
+
+	p.world.PlayerList().All(func(player *Player) {
+		player.SendSystemChatMessage(message)
+	})
+}
+
+func (p *Player) updateHealthMetadata() {
+	p.healthMutex.Lock()
+	health := p.Health
+	p.healthMutex.Unlock()
+
+	p.metadata.Set(MetadataIndexHealth, types.MetadataTypeFloat, health)
+	p.world.EntityTracker().UpdateMetadata(p)
+}
+
+// attack hits the target with damage depending on how long the player waited since the last attack.
+// Fully charged attacks made while falling are critical hits, and those made while sprinting knock the target back
+// further.
+func (p *Player) attack(target *Player) {
+	if !p.world.Settings().PVP || target.IsDead() {
+		return
+	}
+
+	strength := attackStrength(p.lastAttack)
+	p.lastAttack = time.Now()
+
+	charged := strength > 0.9
+	sprinting := charged && p.metadata.HasFlag(EntityFlagSprinting)
+	critical := charged && p.falling && !p.OnGround && !sprinting
+
+	damage := attackDamage(PlayerAttackDamage, strength)
+	if critical {
+		damage *= CriticalHitMultiplier
+	}
+
+	if !target.Damage(damage, DamageByPlayer(p)) {
+		return
+	}
+
+	if critical {
+		target.PlayAnimation(EntityAnimationCriticalEffect)
+	}
+
+	if !target.IsDead() {
+		target.SetVelocity(knockback(p, target, sprinting))
+	}
+}
+
+// canReachEntity tells whether the target is close enough and not hidden behind blocks.
+func (p *Player) canReachEntity(target *Player) bool {
+	eyeY := p.Y + PlayerEyeHeight
+	if distanceToBox(p.X, eyeY, p.Z, target) > MaxEntityReach {
+		return false
+	}
+
+	targetX, targetY, targetZ := target.Position()
+	return p.world.HasLineOfSight(p.X, eyeY, p.Z, targetX, targetY+PlayerEyeHeight, targetZ) ||
+		p.world.HasLineOfSight(p.X, eyeY, p.Z, targetX, targetY+PlayerHeight/2, targetZ)
+}
+
+// pose derives the pose of the player from its entity flags.
+func (p *Player) pose() Pose {
+	switch {
+	case p.metadata.HasFlag(EntityFlagFlyingWithElytra):
+		return PoseFallFlying
+	case p.metadata.HasFlag(EntityFlagCrouching):
+		return PoseSneaking
+	default:
+		return PoseStanding
+	}
+}
+
+// dig breaks the block instantly in creative mode, and in survival mode once the player has been digging it
+// long enough. It returns false if the client has to be told that the block is still there.
+func (p *Player) dig(status DiggingStatus, x, y, z int) bool {
+	if p.IsDead() || !p.canReach(x, y, z) {
+		return false
+	}
+
+	switch p.GameMode {
+	case GameModeCreative:
+		// like in vanilla, swords can't break blocks in creative mode
+		if tool, ok := p.heldTool(); ok && tool.Type == items.ToolSword {
+			return false
+		}
+
+		return status == DiggingStatusStarted && p.world.SetBlock(x, y, z, chunk.AirState)
+	case GameModeSurvival:
+	default:
+		return false
+	}
+
+	state, ok := p.world.Palette().BlockState(p.world.GetBlock(x, y, z))
+	if !ok {
+		return false
+	}
+
+	if status == DiggingStatusStarted {
+		ticks := state.Block.BreakTicks(p.toolSpeed(state.Block))
+		if ticks == 0 {
+			return p.world.SetBlock(x, y, z, chunk.AirState)
+		} else if ticks < 0 {
+			return false
+		}
+
+		p.digging = &diggingProgress{x: x, y: y, z: z, started: time.Now(), ticks: ticks}
+		return true
+	}
+
+	digging := p.digging
+	p.digging = nil
+
+	if digging == nil || !digging.isAt(x, y, z) || !digging.isComplete() {
+		return false
+	}
+
+	return p.world.SetBlock(x, y, z, chunk.AirState)
+}
+
+// placeBlock places the block of the held item against the clicked face, or in place of the clicked block
+// if it's replaceable (like air or water).
+func (p *Player) placeBlock(placement *BlockPlacement) bool {
+	if placement.Hand != HandMain || (p.GameMode != GameModeSurvival && p.GameMode != GameModeCreative) {
+		return false
+	}
+
+	item := p.HeldItem()
+	if item == nil {
+		return false
+	}
+
+	palette := p.world.Palette()
+
+	block, ok := palette.ItemBlock(item.ItemID)
+	if !ok {
+		return false
+	}
+
+	x, y, z := placement.X, placement.Y, placement.Z
+	if !palette.IsReplaceable(p.world.GetBlock(x, y, z)) {
+		x, y, z = placement.Adjacent()
+	}
+
+	if !p.canReach(x, y, z) {
+		return false
+	}
+
+	replaced, ok := palette.BlockState(p.world.GetBlock(x, y, z))
+	if !ok || !replaced.Block.Replaceable {
+		return false
+	}
+
+	state, ok := palette.BlockStateID(block.Name, placementProperties(block, placement, p.Yaw, p.Pitch, replaced))
+	if !ok {
+		state = block.DefaultState.ID
+	}
+
+	if palette.IsSolid(state) && p.world.IsOccupiedByPlayer(x, y, z) {
+		return false
+	}
+
+	if p.GameMode == GameModeSurvival && !p.inventory.Consume(InventorySlotHotbarFirst+p.heldItemSlot, 1) {
+		return false
+	}
+
+	return p.world.SetBlock(x, y, z, state)
+}
+
+func (p *Player) openContainer(
+	menuType MenuType,
+	title *ChatMessage,
+	inventory *Inventory,
+	onClick func(window *Window, click *ContainerClick),
+) (*Window, error) {
+	if title == nil {
+		title = NewChatMessage("")
+	}
+
+	p.CloseWindow()
+
+	p.windowMutex.Lock()
+	id := p.lastWindowID%MaxWindowID + 1
+
+	window, err := newContainerWindow(id, p, menuType, title, inventory)
+	if err != nil {
+		p.windowMutex.Unlock()
+		return nil, err
+	}
+
+	window.onClick = onClick
+	p.lastWindowID = id
+	p.openWindow = window
+	p.windowMutex.Unlock()
+
+	err = p.packetHandler.sendOpenScreen(id, menuType, title)
+	if err != nil {
+		log.Printf("Failed to send open screen: %v\n", err)
+	}
+
+	window.Resync()
+	return window, nil
+}
+
+// takeOpenWindow forgets the open container window and returns it, unless its ID differs from the given one.
+func (p *Player) takeOpenWindow(id *byte) *Window {
+	p.windowMutex.Lock()
+	defer p.windowMutex.Unlock()
+
+	window := p.openWindow
+	if window == nil || (id != nil && window.ID != *id) {
+		return nil
+	}
+
+	p.openWindow = nil
+	return window
+}
+
+// useBlock opens the window of the clicked block, and returns false if the block has none. Crouching players
+// place blocks against it instead.
+func (p *Player) useBlock(placement *BlockPlacement) bool {
+	if p.IsDead() || p.GameMode == GameModeSpectator || p.metadata.HasFlag(EntityFlagCrouching) {
+		return false
+	}
+
+	state, ok := p.world.Palette().BlockState(p.world.GetBlock(placement.X, placement.Y, placement.Z))
+	if !ok || !p.canReach(placement.X, placement.Y, placement.Z) {
+		return false
+	}
+
+	switch state.Block.Name {
+	case "minecraft:crafting_table":
+		_, err := p.OpenCraftingTable()
+		if err != nil {
+			log.Printf("Failed to open crafting table: %v\n", err)
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *Player) canReach(x, y, z int) bool {
+	return blockDistanceSquared(p.X, p.Y+PlayerEyeHeight, p.Z, x, y, z) <= MaxBlockReach*MaxBlockReach
+}
+
+// toolSpeed returns mining speed of the held item on the block, and whether it can harvest the block.
+// Items other than tools mine like a bare hand.
+func (p *Player) toolSpeed(block *blocks.Block) (float64, bool) {
+	tool, ok := p.heldTool()
+	if !ok {
+		return 1, false
+	}
+
+	return tool.MiningSpeed(block.Tool), tool.CanHarvest(block.Tool, block.ToolTier)
+}
+
+func (p *Player) heldTool() (*items.Tool, bool) {
+	item := p.HeldItem()
+	if item == nil {
+		return nil, false
+	}
 
+	return p.world.Data().Items.Tool(item.ItemID)
 }
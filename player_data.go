@@ -0,0 +1,43 @@
+package main
+
+import (
+	"github.com/mkorman9/go-minecraft-server/types"
+	"sync"
+)
+
+// PlayerData is the state of a player kept between connections.
+type PlayerData struct {
+	Inventory    []*types.SlotData
+	HeldItemSlot int
+	RecipeBook   *RecipeBook
+	GameMode     GameMode
+}
+
+// PlayerDataStore keeps the state of players who left the server, so it can be restored when they join again.
+// Players are identified by UUIDs, which in offline mode are derived from names.
+// The data is kept in memory and doesn't survive restarts of the server.
+type PlayerDataStore struct {
+	m    sync.Mutex
+	data map[types.UUID]*PlayerData
+}
+
+func NewPlayerDataStore() *PlayerDataStore {
+	return &PlayerDataStore{
+		data: make(map[types.UUID]*PlayerData),
+	}
+}
+
+func (pds *PlayerDataStore) Load(uuid types.UUID) (*PlayerData, bool) {
+	pds.m.Lock()
+	defer pds.m.Unlock()
+
+	data, ok := pds.data[uuid]
+	return data, ok
+}
+
+func (pds *PlayerDataStore) Save(uuid types.UUID, data *PlayerData) {
+	pds.m.Lock()
+	defer pds.m.Unlock()
+
+	pds.data[uuid] = data
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/x509"
 	"fmt"
 	"github.com/mkorman9/go-minecraft-server/packets"
+	"github.com/mkorman9/go-minecraft-server/types"
 	"io"
 	"log"
 	"time"
@@ -61,6 +62,8 @@ func (pph *PlayerPacketHandler) OnPlayPacket(packetId int, packetReader io.Reade
 		return pph.OnChatCommand(packetReader)
 	case 0x04:
 		return pph.OnChatMessage(packetReader)
+	case 0x06:
+		return pph.OnClientCommand(packetReader)
 	case 0x07:
 		return pph.OnSettings(packetReader)
 	case 0x0c:
@@ -75,14 +78,28 @@ func (pph *PlayerPacketHandler) OnPlayPacket(packetId int, packetReader io.Reade
 		return pph.OnLook(packetReader)
 	case 0x1b:
 		return pph.OnAbilities(packetReader)
+	case 0x1c:
+		return pph.OnPlayerAction(packetReader)
 	case 0x1d:
 		return pph.OnEntityAction(packetReader)
+	case 0x27:
+		return pph.OnSetHeldItem(packetReader)
 	case 0x2a:
 		return pph.OnSetCreativeSlot(packetReader)
 	case 0x2e:
 		return pph.OnArmAnimation(packetReader)
+	case 0x30:
+		return pph.OnUseItemOn(packetReader)
 	case 0x0b:
 		return pph.OnCloseWindow(packetReader)
+	case 0x0f:
+		return pph.OnInteract(packetReader)
+	case 0x0a:
+		return pph.OnClickContainer(packetReader)
+	case 0x20:
+		return pph.OnChangeRecipeBookSettings(packetReader)
+	case 0x21:
+		return pph.OnSetSeenRecipe(packetReader)
 	default:
 		log.Printf("unrecognized packet id: 0x%x in play state\n", packetId)
 		return nil
@@ -167,6 +184,8 @@ func (pph *PlayerPacketHandler) OnLoginStartRequest(packetReader io.Reader) erro
 		pph.state = PlayerStateEncryption
 		return pph.sendEncryptionRequest()
 	} else {
+		pph.player.UUID = types.GetOfflineUUID(pph.player.Name)
+
 		err := pph.setupCompression()
 		if err != nil {
 			return err
@@ -268,6 +287,19 @@ func (pph *PlayerPacketHandler) OnTeleportConfirm(packetReader io.Reader) error
 	return nil
 }
 
+func (pph *PlayerPacketHandler) OnClientCommand(packetReader io.Reader) error {
+	log.Println("received ClientCommand")
+
+	clientCommandPacket, err := ClientCommandPacket.Read(packetReader)
+	if err != nil {
+		return err
+	}
+
+	pph.player.OnClientCommand(clientCommandPacket.VarInt("actionId"))
+
+	return nil
+}
+
 func (pph *PlayerPacketHandler) OnSettings(packetReader io.Reader) error {
 	log.Println("received Settings")
 
@@ -392,12 +424,73 @@ func (pph *PlayerPacketHandler) OnAbilities(packetReader io.Reader) error {
 func (pph *PlayerPacketHandler) OnSetCreativeSlot(packetReader io.Reader) error {
 	log.Println("received SetCreativeSlot")
 
-	_, err := SetCreativeSlotPacket.Read(packetReader)
+	setCreativeSlotPacket, err := SetCreativeSlotPacket.Read(packetReader)
 	if err != nil {
 		return err
 	}
 
-	// TODO
+	pph.player.OnSetCreativeSlot(
+		int(setCreativeSlotPacket.Int16("slot")),
+		setCreativeSlotPacket.Slot("item"),
+	)
+
+	return nil
+}
+
+func (pph *PlayerPacketHandler) OnSetHeldItem(packetReader io.Reader) error {
+	setHeldItemPacket, err := SetHeldItemPacket.Read(packetReader)
+	if err != nil {
+		return err
+	}
+
+	pph.player.OnSetHeldItem(int(setHeldItemPacket.Int16("slot")))
+
+	return nil
+}
+
+func (pph *PlayerPacketHandler) OnPlayerAction(packetReader io.Reader) error {
+	playerActionPacket, err := PlayerActionPacket.Read(packetReader)
+	if err != nil {
+		return err
+	}
+
+	location := playerActionPacket.Position("location")
+	if location == nil {
+		return nil
+	}
+
+	pph.player.OnDigging(
+		playerActionPacket.VarInt("status"),
+		location.X,
+		location.Y,
+		location.Z,
+		playerActionPacket.VarInt("sequence"),
+	)
+
+	return nil
+}
+
+func (pph *PlayerPacketHandler) OnUseItemOn(packetReader io.Reader) error {
+	useItemOnPacket, err := UseItemOnPacket.Read(packetReader)
+	if err != nil {
+		return err
+	}
+
+	location := useItemOnPacket.Position("location")
+	if location == nil {
+		return nil
+	}
+
+	pph.player.OnUseItemOn(&BlockPlacement{
+		Hand:    useItemOnPacket.VarInt("hand"),
+		X:       location.X,
+		Y:       location.Y,
+		Z:       location.Z,
+		Face:    useItemOnPacket.VarInt("face"),
+		CursorX: useItemOnPacket.Float32("cursorX"),
+		CursorY: useItemOnPacket.Float32("cursorY"),
+		CursorZ: useItemOnPacket.Float32("cursorZ"),
+	}, useItemOnPacket.VarInt("sequence"))
 
 	return nil
 }
@@ -413,6 +506,27 @@ func (pph *PlayerPacketHandler) OnKeepAliveResponse(packetReader io.Reader) erro
 	return nil
 }
 
+func (pph *PlayerPacketHandler) OnInteract(packetReader io.Reader) error {
+	log.Println("received Interact")
+
+	interactPacket, err := InteractPacket.Read(packetReader)
+	if err != nil {
+		return err
+	}
+
+	pph.player.OnInteract(&Interaction{
+		EntityID: int32(interactPacket.VarInt("entityId")),
+		Type:     interactPacket.VarInt("type"),
+		Hand:     interactPacket.VarInt("hand"),
+		TargetX:  interactPacket.Float32("targetX"),
+		TargetY:  interactPacket.Float32("targetY"),
+		TargetZ:  interactPacket.Float32("targetZ"),
+		Sneaking: interactPacket.Bool("sneaking"),
+	})
+
+	return nil
+}
+
 func (pph *PlayerPacketHandler) OnEntityAction(packetReader io.Reader) error {
 	log.Println("received EntityAction")
 
@@ -457,6 +571,8 @@ func (pph *PlayerPacketHandler) OnChatMessage(packetReader io.Reader) error {
 	pph.player.OnChatMessage(
 		chatMessagePacket.String("message"),
 		time.UnixMilli(chatMessagePacket.Int64("timestamp")),
+		chatMessagePacket.Int64("salt"),
+		chatMessagePacket.ByteArray("signature"),
 	)
 
 	return nil
@@ -465,7 +581,13 @@ func (pph *PlayerPacketHandler) OnChatMessage(packetReader io.Reader) error {
 func (pph *PlayerPacketHandler) OnJoin() error {
 	pph.player.EntityID = pph.world.GenerateEntityID()
 
-	err := pph.sendPlayPacket(pph.player.EntityID)
+	// players who were on the server before keep their game mode
+	gameMode := pph.world.Data().GameMode
+	if data, ok := pph.world.PlayerData().Load(pph.player.UUID); ok {
+		gameMode = data.GameMode
+	}
+
+	err := pph.sendPlayPacket(pph.player.EntityID, gameMode)
 	if err != nil {
 		return err
 	}
@@ -475,18 +597,106 @@ func (pph *PlayerPacketHandler) OnJoin() error {
 		return err
 	}
 
+	spawnPosition := pph.world.Data().SpawnPosition
+	pph.player.X = float64(spawnPosition.X)
+	pph.player.Y = float64(spawnPosition.Y)
+	pph.player.Z = float64(spawnPosition.Z)
+
 	pph.state = PlayerStatePlay
-	pph.player.OnJoin(GameModeSurvival)
+	pph.player.OnJoin(gameMode)
 
 	err = pph.sendPlayersAdded(pph.world.PlayerList().Copy())
 	if err != nil {
 		return err
 	}
 
-	err = pph.sendMapChunk()
+	err = pph.player.UpdateChunkView()
+	if err != nil {
+		return err
+	}
+
+	err = pph.SynchronizePosition(pph.player.X, pph.player.Y, pph.player.Z)
+	if err != nil {
+		return err
+	}
+
+	pph.player.Inventory().Resync()
+
+	err = pph.sendHeldItem(pph.player.heldItemSlot)
+	if err != nil {
+		return err
+	}
+
+	err = pph.sendRecipes(pph.world.Data().Recipes.All())
+	if err != nil {
+		return err
+	}
+
+	recipeBook := pph.player.RecipeBook()
+	unlocked, highlighted := recipeBook.Unlocked()
+	err = pph.sendRecipeBook(RecipeBookActionInit, recipeBook.AllSettings(), unlocked, highlighted)
+	if err != nil {
+		return err
+	}
+
+	pph.world.EntityTracker().Track(pph.player)
+	return nil
+}
+
+func (pph *PlayerPacketHandler) OnClickContainer(packetReader io.Reader) error {
+	log.Println("received ClickContainer")
+
+	clickContainerPacket, err := ClickContainerPacket.Read(packetReader)
+	if err != nil {
+		return err
+	}
+
+	changedSlots := make(map[int]*types.SlotData)
+	for _, changedSlot := range clickContainerPacket.Array("changedSlots") {
+		changedSlots[int(changedSlot.Int16("slot"))] = changedSlot.Slot("item")
+	}
+
+	pph.player.OnClickContainer(&ContainerClick{
+		WindowID:     clickContainerPacket.Byte("windowId"),
+		StateID:      clickContainerPacket.VarInt("stateId"),
+		Slot:         int(clickContainerPacket.Int16("slot")),
+		Button:       int(clickContainerPacket.Byte("button")),
+		Mode:         clickContainerPacket.VarInt("mode"),
+		ChangedSlots: changedSlots,
+		Carried:      clickContainerPacket.Slot("carriedItem"),
+	})
+
+	return nil
+}
+
+func (pph *PlayerPacketHandler) OnChangeRecipeBookSettings(packetReader io.Reader) error {
+	log.Println("received ChangeRecipeBookSettings")
+
+	changeRecipeBookSettingsPacket, err := ChangeRecipeBookSettingsPacket.Read(packetReader)
+	if err != nil {
+		return err
+	}
+
+	pph.player.OnChangeRecipeBookSettings(
+		changeRecipeBookSettingsPacket.VarInt("bookId"),
+		RecipeBookSettings{
+			Open:   changeRecipeBookSettingsPacket.Bool("bookOpen"),
+			Filter: changeRecipeBookSettingsPacket.Bool("filterActive"),
+		},
+	)
+
+	return nil
+}
+
+func (pph *PlayerPacketHandler) OnSetSeenRecipe(packetReader io.Reader) error {
+	log.Println("received SetSeenRecipe")
+
+	setSeenRecipePacket, err := SetSeenRecipePacket.Read(packetReader)
 	if err != nil {
 		return err
 	}
 
+	pph.player.OnSetSeenRecipe(setSeenRecipePacket.String("recipeId"))
+
 	return nil
 }
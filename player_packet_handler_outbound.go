@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"github.com/mkorman9/go-minecraft-server/chunk"
 	"github.com/mkorman9/go-minecraft-server/packets"
+	"github.com/mkorman9/go-minecraft-server/recipes"
 	"github.com/mkorman9/go-minecraft-server/types"
 	"log"
 )
@@ -82,12 +83,12 @@ func (pph *PlayerPacketHandler) sendLoginSuccessResponse() error {
 	return pph.packetWriter.Write(loginSuccessResponse)
 }
 
-func (pph *PlayerPacketHandler) sendPlayPacket(entityID int32) error {
+func (pph *PlayerPacketHandler) sendPlayPacket(entityID int32, gameMode GameMode) error {
 	playPacket := PlayPacket.
 		New().
 		Set("entityID", entityID).
 		Set("isHardcore", pph.world.Data().IsHardcore).
-		Set("gameMode", pph.world.Data().GameMode).
+		Set("gameMode", gameMode).
 		Set("previousGameMode", GameModeUnknown).
 		SetArray(
 			"worldNames",
@@ -135,6 +136,26 @@ func (pph *PlayerPacketHandler) sendSystemChatMessage(message *ChatMessage) erro
 	return pph.packetWriter.Write(systemChatPacket)
 }
 
+func (pph *PlayerPacketHandler) sendPlayerChatMessage(message *PlayerChatMessage, unsignedContent *ChatMessage, chatType int32) error {
+	playerChatMessagePacket := PlayerChatMessagePacket.
+		New().
+		Set("signedContent", message.SignedContent().Encode()).
+		Set("hasUnsignedContent", unsignedContent != nil).
+		Set("type", int(chatType)).
+		Set("senderUUID", message.Sender.UUID).
+		Set("senderName", message.Sender.DisplayName.Encode()).
+		Set("hasTeamName", false).
+		Set("timestamp", message.Timestamp.UnixMilli()).
+		Set("salt", message.Salt).
+		Set("signature", message.Signature)
+
+	if unsignedContent != nil {
+		playerChatMessagePacket.Set("unsignedContent", unsignedContent.Encode())
+	}
+
+	return pph.packetWriter.Write(playerChatMessagePacket)
+}
+
 func (pph *PlayerPacketHandler) sendSpawnPosition() error {
 	spawnPositionPacket := SpawnPositionPacket.
 		New().
@@ -225,39 +246,480 @@ func (pph *PlayerPacketHandler) sendPlayersRemoved(players []*Player) error {
 	return pph.packetWriter.Write(playerInfoPacket)
 }
 
-func (pph *PlayerPacketHandler) sendMapChunk() error {
+func (pph *PlayerPacketHandler) sendMapChunk(position ChunkPosition, c *chunk.Chunk) error {
 	var data bytes.Buffer
-	_, err := chunk.GenerateExampleChunk().WriteTo(&data)
+	var heightmaps *chunk.Heightmap
+	var blockEntities []*chunk.BlockEntity
+	var err error
+
+	pph.world.ViewChunks(func() {
+		_, err = c.WriteTo(&data)
+		if c.Heightmaps != nil {
+			heightmaps = c.Heightmaps.Clone()
+		}
+		for _, blockEntity := range c.BlockEntities {
+			blockEntities = append(blockEntities, blockEntity.Clone())
+		}
+	})
 	if err != nil {
 		return err
 	}
 
+	light := newLightData(pph.world.ChunkLight(c), 1<<chunk.LightSectionsCount-1)
+
 	mapChunkPacket := MapChunkPacket.
 		New().
-		Set("x", int32(0)).
-		Set("z", int32(0)).
-		Set("heightmaps", chunk.GenerateExampleHeightmap()).
+		Set("x", int32(position.X)).
+		Set("z", int32(position.Z)).
+		Set("heightmaps", heightmaps).
 		Set("data", data.Bytes()).
 		SetArray(
 			"blockEntities",
-			packets.ConvertArrayValue(nil, func(block *chunk.BlockEntity, packet *packets.PacketData) {
+			packets.ConvertArrayValue(blockEntities, func(blockEntity *chunk.BlockEntity, packet *packets.PacketData) {
+				packet.
+					Set("xz", blockEntity.PackedXZ).
+					Set("y", blockEntity.Y).
+					Set("type", blockEntity.Type).
+					Set("data", &blockEntity.Data)
 			}),
 		).
-		Set("trustEdges", true).
-		Set("skyLightMask", types.NewBitSet(64)).
-		Set("blockLightMask", types.NewBitSet(64)).
-		Set("emptySkyLightMask", types.NewBitSet(64)).
-		Set("emptyBlockLightMask", types.NewBitSet(64)).
+		Set("trustEdges", true)
+
+	light.set(mapChunkPacket)
+
+	return pph.packetWriter.Write(mapChunkPacket)
+}
+
+func (pph *PlayerPacketHandler) sendUpdateLight(position ChunkPosition, l *chunk.Light, sections uint32) error {
+	updateLightPacket := UpdateLightPacket.
+		New().
+		Set("x", position.X).
+		Set("z", position.Z).
+		Set("trustEdges", true)
+
+	newLightData(l, sections).set(updateLightPacket)
+
+	return pph.packetWriter.Write(updateLightPacket)
+}
+
+// lightData holds light arrays of chunk sections selected by a bitmask, the way both chunk and light packets send them.
+type lightData struct {
+	skyLightMask        *types.BitSet
+	blockLightMask      *types.BitSet
+	emptySkyLightMask   *types.BitSet
+	emptyBlockLightMask *types.BitSet
+	skyLights           [][]byte
+	blockLights         [][]byte
+}
+
+func newLightData(l *chunk.Light, sections uint32) *lightData {
+	ld := &lightData{
+		skyLightMask:        types.NewBitSet(chunk.LightSectionsCount),
+		blockLightMask:      types.NewBitSet(chunk.LightSectionsCount),
+		emptySkyLightMask:   types.NewBitSet(chunk.LightSectionsCount),
+		emptyBlockLightMask: types.NewBitSet(chunk.LightSectionsCount),
+	}
+
+	if l == nil {
+		return ld
+	}
+
+	for i := 0; i < chunk.LightSectionsCount; i++ {
+		if sections&(1<<i) == 0 {
+			continue
+		}
+
+		if array := l.Sky[i]; array != nil && !array.IsEmpty() {
+			ld.skyLightMask.Set1(i)
+			ld.skyLights = append(ld.skyLights, array[:])
+		} else {
+			ld.emptySkyLightMask.Set1(i)
+		}
+
+		if array := l.Block[i]; array != nil && !array.IsEmpty() {
+			ld.blockLightMask.Set1(i)
+			ld.blockLights = append(ld.blockLights, array[:])
+		} else {
+			ld.emptyBlockLightMask.Set1(i)
+		}
+	}
+
+	return ld
+}
+
+func (ld *lightData) set(packet *packets.PacketData) {
+	packet.
+		Set("skyLightMask", ld.skyLightMask).
+		Set("blockLightMask", ld.blockLightMask).
+		Set("emptySkyLightMask", ld.emptySkyLightMask).
+		Set("emptyBlockLightMask", ld.emptyBlockLightMask).
 		SetArray(
 			"skyLights",
-			packets.ConvertArrayValue(nil, func(block *chunk.BlockEntity, packet *packets.PacketData) {
+			packets.ConvertArrayValue(ld.skyLights, func(array []byte, packet *packets.PacketData) {
+				packet.Set("value", array)
 			}),
 		).
 		SetArray(
 			"blockLights",
-			packets.ConvertArrayValue(nil, func(block *chunk.BlockEntity, packet *packets.PacketData) {
+			packets.ConvertArrayValue(ld.blockLights, func(array []byte, packet *packets.PacketData) {
+				packet.Set("value", array)
 			}),
 		)
+}
 
-	return pph.packetWriter.Write(mapChunkPacket)
+func (pph *PlayerPacketHandler) sendCenterChunk(position ChunkPosition) error {
+	setCenterChunkPacket := SetCenterChunkPacket.
+		New().
+		Set("x", position.X).
+		Set("z", position.Z)
+
+	return pph.packetWriter.Write(setCenterChunkPacket)
+}
+
+func (pph *PlayerPacketHandler) sendUnloadChunk(position ChunkPosition) error {
+	unloadChunkPacket := UnloadChunkPacket.
+		New().
+		Set("x", int32(position.X)).
+		Set("z", int32(position.Z))
+
+	return pph.packetWriter.Write(unloadChunkPacket)
+}
+
+func (pph *PlayerPacketHandler) sendBlockUpdate(x, y, z int, state int) error {
+	blockUpdatePacket := BlockUpdatePacket.
+		New().
+		Set("location", types.NewPosition(x, y, z)).
+		Set("blockId", state)
+
+	return pph.packetWriter.Write(blockUpdatePacket)
+}
+
+func (pph *PlayerPacketHandler) sendAckBlockChange(sequence int) error {
+	ackBlockChangePacket := AckBlockChangePacket.
+		New().
+		Set("sequence", sequence)
+
+	return pph.packetWriter.Write(ackBlockChangePacket)
+}
+
+func (pph *PlayerPacketHandler) sendSectionBlocksUpdate(position SectionPosition, changes []BlockChange) error {
+	sectionPosition := int64(position.X&0x3FFFFF)<<42 | int64(position.Z&0x3FFFFF)<<20 | int64(position.Y&0xFFFFF)
+
+	updateSectionBlocksPacket := UpdateSectionBlocksPacket.
+		New().
+		Set("sectionPosition", sectionPosition).
+		Set("suppressLightUpdates", true).
+		SetArray(
+			"blocks",
+			packets.ConvertArrayValue(changes, func(change BlockChange, packet *packets.PacketData) {
+				local := (change.X&15)<<8 | (change.Z&15)<<4 | (change.Y & 15)
+				packet.Set("block", int64(change.State)<<12|int64(local))
+			}),
+		)
+
+	return pph.packetWriter.Write(updateSectionBlocksPacket)
+}
+
+func (pph *PlayerPacketHandler) sendBlockEntityData(x, y, z int, blockEntity *chunk.BlockEntity) error {
+	blockEntityDataPacket := BlockEntityDataPacket.
+		New().
+		Set("location", types.NewPosition(x, y, z)).
+		Set("type", blockEntity.Type).
+		Set("data", &blockEntity.Data)
+
+	return pph.packetWriter.Write(blockEntityDataPacket)
+}
+
+func (pph *PlayerPacketHandler) sendSpawnPlayer(player *Player, yaw, pitch byte) error {
+	x, y, z := player.Position()
+
+	spawnPlayerPacket := SpawnPlayerPacket.
+		New().
+		Set("entityId", int(player.EntityID)).
+		Set("uuid", player.UUID).
+		Set("x", x).
+		Set("y", y).
+		Set("z", z).
+		Set("yaw", yaw).
+		Set("pitch", pitch)
+
+	return pph.packetWriter.Write(spawnPlayerPacket)
+}
+
+func (pph *PlayerPacketHandler) sendEntityPosition(entityID int32, dx, dy, dz int16, onGround bool) error {
+	updateEntityPositionPacket := UpdateEntityPositionPacket.
+		New().
+		Set("entityId", int(entityID)).
+		Set("deltaX", dx).
+		Set("deltaY", dy).
+		Set("deltaZ", dz).
+		Set("onGround", onGround)
+
+	return pph.packetWriter.Write(updateEntityPositionPacket)
+}
+
+func (pph *PlayerPacketHandler) sendEntityPositionAndRotation(
+	entityID int32,
+	dx, dy, dz int16,
+	yaw, pitch byte,
+	onGround bool,
+) error {
+	updateEntityPositionAndRotationPacket := UpdateEntityPositionAndRotationPacket.
+		New().
+		Set("entityId", int(entityID)).
+		Set("deltaX", dx).
+		Set("deltaY", dy).
+		Set("deltaZ", dz).
+		Set("yaw", yaw).
+		Set("pitch", pitch).
+		Set("onGround", onGround)
+
+	return pph.packetWriter.Write(updateEntityPositionAndRotationPacket)
+}
+
+func (pph *PlayerPacketHandler) sendEntityRotation(entityID int32, yaw, pitch byte, onGround bool) error {
+	updateEntityRotationPacket := UpdateEntityRotationPacket.
+		New().
+		Set("entityId", int(entityID)).
+		Set("yaw", yaw).
+		Set("pitch", pitch).
+		Set("onGround", onGround)
+
+	return pph.packetWriter.Write(updateEntityRotationPacket)
+}
+
+func (pph *PlayerPacketHandler) sendHeadRotation(entityID int32, headYaw byte) error {
+	setHeadRotationPacket := SetHeadRotationPacket.
+		New().
+		Set("entityId", int(entityID)).
+		Set("headYaw", headYaw)
+
+	return pph.packetWriter.Write(setHeadRotationPacket)
+}
+
+func (pph *PlayerPacketHandler) sendTeleportEntity(
+	entityID int32,
+	x, y, z float64,
+	yaw, pitch byte,
+	onGround bool,
+) error {
+	teleportEntityPacket := TeleportEntityPacket.
+		New().
+		Set("entityId", int(entityID)).
+		Set("x", x).
+		Set("y", y).
+		Set("z", z).
+		Set("yaw", yaw).
+		Set("pitch", pitch).
+		Set("onGround", onGround)
+
+	return pph.packetWriter.Write(teleportEntityPacket)
+}
+
+func (pph *PlayerPacketHandler) sendRemoveEntities(entityIDs []int32) error {
+	removeEntitiesPacket := RemoveEntitiesPacket.
+		New().
+		SetArray(
+			"entities",
+			packets.ConvertArrayValue(entityIDs, func(entityID int32, packet *packets.PacketData) {
+				packet.Set("entityId", int(entityID))
+			}),
+		)
+
+	return pph.packetWriter.Write(removeEntitiesPacket)
+}
+
+func (pph *PlayerPacketHandler) sendEntityMetadata(entityID int32, metadata *types.Metadata) error {
+	setEntityMetadataPacket := SetEntityMetadataPacket.
+		New().
+		Set("entityId", int(entityID)).
+		Set("metadata", metadata)
+
+	return pph.packetWriter.Write(setEntityMetadataPacket)
+}
+
+func (pph *PlayerPacketHandler) sendEntityAnimation(entityID int32, animation EntityAnimation) error {
+	entityAnimationPacket := EntityAnimationPacket.
+		New().
+		Set("entityId", int(entityID)).
+		Set("animation", animation)
+
+	return pph.packetWriter.Write(entityAnimationPacket)
+}
+
+func (pph *PlayerPacketHandler) sendEntityEvent(entityID int32, status EntityStatus) error {
+	entityEventPacket := EntityEventPacket.
+		New().
+		Set("entityId", entityID).
+		Set("status", status)
+
+	return pph.packetWriter.Write(entityEventPacket)
+}
+
+func (pph *PlayerPacketHandler) sendHealth(health float32, food int, saturation float32) error {
+	setHealthPacket := SetHealthPacket.
+		New().
+		Set("health", health).
+		Set("food", food).
+		Set("saturation", saturation)
+
+	return pph.packetWriter.Write(setHealthPacket)
+}
+
+func (pph *PlayerPacketHandler) sendCombatDeath(killerID int32, message *ChatMessage) error {
+	combatDeathPacket := CombatDeathPacket.
+		New().
+		Set("playerId", int(pph.player.EntityID)).
+		Set("entityId", killerID).
+		Set("message", message.Encode())
+
+	return pph.packetWriter.Write(combatDeathPacket)
+}
+
+func (pph *PlayerPacketHandler) sendRespawn(deathLocation *types.Position) error {
+	respawnPacket := RespawnPacket.
+		New().
+		Set("dimensionType", pph.world.Data().SpawnDimension).
+		Set("dimensionName", pph.world.Data().SpawnDimension).
+		Set("hashedSeed", pph.world.Data().HashedSeed).
+		Set("gameMode", pph.player.GameMode).
+		Set("previousGameMode", GameModeUnknown).
+		Set("isDebug", pph.world.Settings().IsDebug).
+		Set("isFlat", pph.world.Data().IsFlat).
+		Set("copyMetadata", false).
+		Set("hasDeath", deathLocation != nil)
+
+	if deathLocation != nil {
+		respawnPacket.
+			Set("deathDimension", pph.world.Data().SpawnDimension).
+			Set("deathLocation", deathLocation)
+	}
+
+	return pph.packetWriter.Write(respawnPacket)
+}
+
+func (pph *PlayerPacketHandler) sendEntityVelocity(entityID int32, vx, vy, vz int16) error {
+	setEntityVelocityPacket := SetEntityVelocityPacket.
+		New().
+		Set("entityId", int(entityID)).
+		Set("velocityX", vx).
+		Set("velocityY", vy).
+		Set("velocityZ", vz)
+
+	return pph.packetWriter.Write(setEntityVelocityPacket)
+}
+
+func (pph *PlayerPacketHandler) sendContainerContent(windowID byte, stateID int, items []*types.SlotData, cursor *types.SlotData) error {
+	setContainerContentPacket := SetContainerContentPacket.
+		New().
+		Set("windowId", windowID).
+		Set("stateId", stateID).
+		SetArray("slots", packets.ConvertArrayValue(items, func(item *types.SlotData, packet *packets.PacketData) {
+			packet.Set("item", item)
+		})).
+		Set("carriedItem", cursor)
+
+	return pph.packetWriter.Write(setContainerContentPacket)
+}
+
+func (pph *PlayerPacketHandler) sendContainerSlot(windowID byte, stateID int, slot int, item *types.SlotData) error {
+	setContainerSlotPacket := SetContainerSlotPacket.
+		New().
+		Set("windowId", windowID).
+		Set("stateId", stateID).
+		Set("slot", int16(slot)).
+		Set("item", item)
+
+	return pph.packetWriter.Write(setContainerSlotPacket)
+}
+
+func (pph *PlayerPacketHandler) sendHeldItem(slot int) error {
+	setCarriedItemPacket := SetCarriedItemPacket.
+		New().
+		Set("slot", byte(slot))
+
+	return pph.packetWriter.Write(setCarriedItemPacket)
+}
+
+func (pph *PlayerPacketHandler) sendOpenScreen(windowID byte, menuType MenuType, title *ChatMessage) error {
+	openScreenPacket := OpenScreenPacket.
+		New().
+		Set("windowId", int(windowID)).
+		Set("windowType", menuType).
+		Set("title", title.Encode())
+
+	return pph.packetWriter.Write(openScreenPacket)
+}
+
+func (pph *PlayerPacketHandler) sendCloseContainer(windowID byte) error {
+	closeContainerPacket := CloseContainerPacket.
+		New().
+		Set("windowId", windowID)
+
+	return pph.packetWriter.Write(closeContainerPacket)
+}
+
+func (pph *PlayerPacketHandler) sendRecipes(all []*recipes.Recipe) error {
+	ingredientValue := func(ingredient recipes.Ingredient, packet *packets.PacketData) {
+		packet.SetArray("items", packets.ConvertArrayValue(ingredient, func(itemID int, packet *packets.PacketData) {
+			packet.Set("item", &types.SlotData{Present: true, ItemID: itemID, ItemCount: 1})
+		}))
+	}
+
+	updateRecipesPacket := UpdateRecipesPacket.
+		New().
+		SetArray("recipes", packets.ConvertArrayValue(all, func(recipe *recipes.Recipe, packet *packets.PacketData) {
+			packet.Set("type", recipe.Type).
+				Set("recipeId", recipe.ID).
+				Set("width", recipe.Width).
+				Set("height", recipe.Height).
+				Set("group", recipe.Group).
+				Set("result", &types.SlotData{
+					Present:   true,
+					ItemID:    recipe.Result.ItemID,
+					ItemCount: byte(recipe.Result.Count),
+				}).
+				Set("experience", recipe.Experience).
+				Set("cookingTime", recipe.CookingTime)
+
+			switch {
+			case recipe.Type == recipes.TypeShaped:
+				packet.SetArray("shapedIngredients", packets.ConvertArrayValue(recipe.Ingredients, ingredientValue))
+			case recipe.Type == recipes.TypeShapeless:
+				packet.SetArray("ingredients", packets.ConvertArrayValue(recipe.Ingredients, ingredientValue))
+			case recipe.IsCooking():
+				packet.SetArray("ingredient", packets.ConvertArrayValue(recipe.Ingredients[0], func(itemID int, packet *packets.PacketData) {
+					packet.Set("item", &types.SlotData{Present: true, ItemID: itemID, ItemCount: 1})
+				}))
+			}
+		}))
+
+	return pph.packetWriter.Write(updateRecipesPacket)
+}
+
+func (pph *PlayerPacketHandler) sendRecipeBook(
+	action RecipeBookAction,
+	settings [RecipeBookTypesCount]RecipeBookSettings,
+	recipeIDs []string,
+	highlightedIDs []string,
+) error {
+	idValue := func(id string, packet *packets.PacketData) {
+		packet.Set("id", id)
+	}
+
+	updateRecipeBookPacket := UpdateRecipeBookPacket.
+		New().
+		Set("action", action).
+		Set("craftingBookOpen", settings[RecipeBookCrafting].Open).
+		Set("craftingBookFilter", settings[RecipeBookCrafting].Filter).
+		Set("smeltingBookOpen", settings[RecipeBookFurnace].Open).
+		Set("smeltingBookFilter", settings[RecipeBookFurnace].Filter).
+		Set("blastFurnaceBookOpen", settings[RecipeBookBlastFurnace].Open).
+		Set("blastFurnaceBookFilter", settings[RecipeBookBlastFurnace].Filter).
+		Set("smokerBookOpen", settings[RecipeBookSmoker].Open).
+		Set("smokerBookFilter", settings[RecipeBookSmoker].Filter).
+		SetArray("recipeIds", packets.ConvertArrayValue(recipeIDs, idValue)).
+		SetArray("highlightedRecipeIds", packets.ConvertArrayValue(highlightedIDs, idValue))
+
+	return pph.packetWriter.Write(updateRecipeBookPacket)
 }
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"github.com/mkorman9/go-minecraft-server/recipes"
+	"sort"
+	"sync"
+)
+
+// RecipeBookSettings tells whether a recipe book is open, and whether it shows only recipes
+// which can be crafted from the items at hand.
+type RecipeBookSettings struct {
+	Open   bool
+	Filter bool
+}
+
+// RecipeBook holds the recipes unlocked by a player, and the ones which are highlighted as new
+// until the player sees them.
+type RecipeBook struct {
+	m           sync.Mutex
+	settings    [RecipeBookTypesCount]RecipeBookSettings
+	unlocked    map[string]struct{}
+	highlighted map[string]struct{}
+}
+
+func NewRecipeBook() *RecipeBook {
+	return &RecipeBook{
+		unlocked:    make(map[string]struct{}),
+		highlighted: make(map[string]struct{}),
+	}
+}
+
+func (rb *RecipeBook) Settings(bookType RecipeBookType) RecipeBookSettings {
+	rb.m.Lock()
+	defer rb.m.Unlock()
+
+	if bookType < 0 || bookType >= RecipeBookTypesCount {
+		return RecipeBookSettings{}
+	}
+
+	return rb.settings[bookType]
+}
+
+// AllSettings returns settings of all recipe books, indexed by RecipeBookType.
+func (rb *RecipeBook) AllSettings() [RecipeBookTypesCount]RecipeBookSettings {
+	rb.m.Lock()
+	defer rb.m.Unlock()
+
+	return rb.settings
+}
+
+func (rb *RecipeBook) SetSettings(bookType RecipeBookType, settings RecipeBookSettings) {
+	rb.m.Lock()
+	defer rb.m.Unlock()
+
+	if bookType < 0 || bookType >= RecipeBookTypesCount {
+		return
+	}
+
+	rb.settings[bookType] = settings
+}
+
+func (rb *RecipeBook) IsUnlocked(id string) bool {
+	rb.m.Lock()
+	defer rb.m.Unlock()
+
+	_, ok := rb.unlocked[id]
+	return ok
+}
+
+// Unlock adds the recipes to the book, and returns the ones which were not unlocked before.
+// Newly unlocked recipes are highlighted if highlight is set.
+func (rb *RecipeBook) Unlock(ids []string, highlight bool) []string {
+	rb.m.Lock()
+	defer rb.m.Unlock()
+
+	var added []string
+	for _, id := range ids {
+		if _, ok := rb.unlocked[id]; ok {
+			continue
+		}
+
+		rb.unlocked[id] = struct{}{}
+		if highlight {
+			rb.highlighted[id] = struct{}{}
+		}
+		added = append(added, id)
+	}
+
+	return added
+}
+
+// Lock removes the recipes from the book, and returns the ones which were unlocked before.
+func (rb *RecipeBook) Lock(ids []string) []string {
+	rb.m.Lock()
+	defer rb.m.Unlock()
+
+	var removed []string
+	for _, id := range ids {
+		if _, ok := rb.unlocked[id]; !ok {
+			continue
+		}
+
+		delete(rb.unlocked, id)
+		delete(rb.highlighted, id)
+		removed = append(removed, id)
+	}
+
+	return removed
+}
+
+// MarkSeen stops highlighting the recipe.
+func (rb *RecipeBook) MarkSeen(id string) {
+	rb.m.Lock()
+	defer rb.m.Unlock()
+
+	delete(rb.highlighted, id)
+}
+
+// Unlocked returns IDs of unlocked recipes, and IDs of highlighted ones.
+func (rb *RecipeBook) Unlocked() (unlocked []string, highlighted []string) {
+	rb.m.Lock()
+	defer rb.m.Unlock()
+
+	return sortedKeys(rb.unlocked), sortedKeys(rb.highlighted)
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+func recipeIDs(all []*recipes.Recipe) []string {
+	ids := make([]string, len(all))
+	for i, recipe := range all {
+		ids[i] = recipe.ID
+	}
+	return ids
+}
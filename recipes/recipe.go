@@ -0,0 +1,267 @@
+package recipes
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Recipe types, as in the minecraft:recipe_serializer registry.
+const (
+	TypeShaped          = "minecraft:crafting_shaped"
+	TypeShapeless       = "minecraft:crafting_shapeless"
+	TypeSmelting        = "minecraft:smelting"
+	TypeBlasting        = "minecraft:blasting"
+	TypeSmoking         = "minecraft:smoking"
+	TypeCampfireCooking = "minecraft:campfire_cooking"
+)
+
+// Ingredient lists IDs of the items accepted in a place of a recipe. Empty ingredient matches an empty slot.
+type Ingredient []int
+
+// Result is the item produced by a recipe.
+type Result struct {
+	ItemID int
+	Count  int
+}
+
+// Recipe is a crafting or cooking recipe.
+type Recipe struct {
+	ID    string
+	Type  string
+	Group string
+	// Width and Height are dimensions of shaped recipes, with Ingredients listed row by row.
+	Width       int
+	Height      int
+	Ingredients []Ingredient
+	Result      Result
+	// Experience and CookingTime (in ticks) are properties of cooking recipes, which have a single ingredient.
+	Experience  float32
+	CookingTime int
+}
+
+// NewShaped creates a shaped recipe from rows of the pattern and items assigned to the characters
+// (space is an empty slot), like in the vanilla JSON format.
+func NewShaped(id string, pattern []string, key map[rune]Ingredient, result Result) (*Recipe, error) {
+	if len(pattern) == 0 || len(pattern) > 3 {
+		return nil, fmt.Errorf("recipe %s: pattern must have 1-3 rows", id)
+	}
+
+	width := len([]rune(pattern[0]))
+	if width == 0 || width > 3 {
+		return nil, fmt.Errorf("recipe %s: pattern must have 1-3 columns", id)
+	}
+
+	recipe := &Recipe{
+		ID:     id,
+		Type:   TypeShaped,
+		Width:  width,
+		Height: len(pattern),
+		Result: result,
+	}
+
+	for _, row := range pattern {
+		runes := []rune(row)
+		if len(runes) != width {
+			return nil, fmt.Errorf("recipe %s: rows of the pattern have different lengths", id)
+		}
+
+		for _, r := range runes {
+			if r == ' ' {
+				recipe.Ingredients = append(recipe.Ingredients, nil)
+				continue
+			}
+
+			ingredient, ok := key[r]
+			if !ok || len(ingredient) == 0 {
+				return nil, fmt.Errorf("recipe %s: undefined key '%c'", id, r)
+			}
+
+			recipe.Ingredients = append(recipe.Ingredients, ingredient)
+		}
+	}
+
+	return recipe, recipe.validate()
+}
+
+// NewShapeless creates a recipe whose ingredients may be placed anywhere in the crafting grid.
+func NewShapeless(id string, ingredients []Ingredient, result Result) (*Recipe, error) {
+	recipe := &Recipe{
+		ID:          id,
+		Type:        TypeShapeless,
+		Ingredients: ingredients,
+		Result:      result,
+	}
+
+	return recipe, recipe.validate()
+}
+
+// NewCooking creates a smelting, blasting, smoking or campfire cooking recipe.
+func NewCooking(id string, recipeType string, ingredient Ingredient, result Result, experience float32, cookingTime int) (*Recipe, error) {
+	recipe := &Recipe{
+		ID:          id,
+		Type:        recipeType,
+		Ingredients: []Ingredient{ingredient},
+		Result:      result,
+		Experience:  experience,
+		CookingTime: cookingTime,
+	}
+
+	return recipe, recipe.validate()
+}
+
+// IsCrafting tells whether the recipe is made in a crafting grid.
+func (r *Recipe) IsCrafting() bool {
+	return r.Type == TypeShaped || r.Type == TypeShapeless
+}
+
+// IsCooking tells whether the recipe is made in a furnace or on a campfire.
+func (r *Recipe) IsCooking() bool {
+	return r.Type == TypeSmelting || r.Type == TypeBlasting || r.Type == TypeSmoking || r.Type == TypeCampfireCooking
+}
+
+// Matches tells whether items in the square crafting grid (listed row by row, 0 for empty slots) make the recipe.
+func (r *Recipe) Matches(grid []int, size int) bool {
+	switch r.Type {
+	case TypeShaped:
+		return r.matchesShaped(grid, size)
+	case TypeShapeless:
+		return r.matchesShapeless(grid)
+	default:
+		return false
+	}
+}
+
+func (r *Recipe) matchesShaped(grid []int, size int) bool {
+	minX, minY, maxX, maxY := size, size, -1, -1
+	for i, item := range grid {
+		if item == 0 {
+			continue
+		}
+
+		x, y := i%size, i/size
+		if x < minX {
+			minX = x
+		}
+		if x > maxX {
+			maxX = x
+		}
+		if y < minY {
+			minY = y
+		}
+		if y > maxY {
+			maxY = y
+		}
+	}
+
+	if maxX < 0 || maxX-minX+1 != r.Width || maxY-minY+1 != r.Height {
+		return false
+	}
+
+	// patterns can also be placed mirrored
+	for _, mirrored := range []bool{false, true} {
+		matches := true
+
+		for y := 0; y < r.Height && matches; y++ {
+			for x := 0; x < r.Width && matches; x++ {
+				patternX := x
+				if mirrored {
+					patternX = r.Width - 1 - x
+				}
+
+				item := grid[(minY+y)*size+minX+x]
+				matches = r.Ingredients[y*r.Width+patternX].accepts(item)
+			}
+		}
+
+		if matches {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Recipe) matchesShapeless(grid []int) bool {
+	var items []int
+	for _, item := range grid {
+		if item != 0 {
+			items = append(items, item)
+		}
+	}
+
+	if len(items) != len(r.Ingredients) {
+		return false
+	}
+
+	used := make([]bool, len(items))
+	return r.assign(0, items, used)
+}
+
+// assign looks for a way to give every ingredient a different item, as some items may be accepted
+// by more than one ingredient.
+func (r *Recipe) assign(ingredient int, items []int, used []bool) bool {
+	if ingredient == len(r.Ingredients) {
+		return true
+	}
+
+	for i, item := range items {
+		if used[i] || !r.Ingredients[ingredient].accepts(item) {
+			continue
+		}
+
+		used[i] = true
+		if r.assign(ingredient+1, items, used) {
+			return true
+		}
+		used[i] = false
+	}
+
+	return false
+}
+
+func (r *Recipe) validate() error {
+	if !strings.Contains(r.ID, ":") {
+		return fmt.Errorf("recipe ID must be namespaced: %s", r.ID)
+	}
+	if r.Result.ItemID <= 0 || r.Result.Count <= 0 {
+		return fmt.Errorf("recipe %s: invalid result", r.ID)
+	}
+
+	switch {
+	case r.Type == TypeShaped:
+		if len(r.Ingredients) != r.Width*r.Height {
+			return fmt.Errorf("recipe %s: expected %d ingredients", r.ID, r.Width*r.Height)
+		}
+	case r.Type == TypeShapeless:
+		if len(r.Ingredients) == 0 || len(r.Ingredients) > 9 {
+			return fmt.Errorf("recipe %s: shapeless recipes need 1-9 ingredients", r.ID)
+		}
+		for _, ingredient := range r.Ingredients {
+			if len(ingredient) == 0 {
+				return fmt.Errorf("recipe %s: empty ingredient", r.ID)
+			}
+		}
+	case r.IsCooking():
+		if len(r.Ingredients) != 1 || len(r.Ingredients[0]) == 0 {
+			return fmt.Errorf("recipe %s: cooking recipes need a single ingredient", r.ID)
+		}
+	default:
+		return fmt.Errorf("recipe %s: unsupported type %s", r.ID, r.Type)
+	}
+
+	return nil
+}
+
+func (i Ingredient) accepts(item int) bool {
+	if len(i) == 0 {
+		return item == 0
+	}
+
+	for _, id := range i {
+		if id == item {
+			return true
+		}
+	}
+
+	return false
+}
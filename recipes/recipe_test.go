@@ -0,0 +1,142 @@
+package recipes
+
+import (
+	"github.com/mkorman9/go-minecraft-server/items"
+	"testing"
+)
+
+const (
+	dirt           = 15
+	gravel         = 42
+	cobblestone    = 22
+	diorite        = 4
+	andesite       = 6
+	oakPlanks      = 23
+	mangroveLog    = 110
+	mangrovePlanks = 29
+	crimsonPlanks  = 30
+	chest          = 256
+	charcoal       = 721
+)
+
+func loadTestRegistry(t *testing.T) *Registry {
+	t.Helper()
+
+	itemRegistry, err := items.LoadRegistry("../data/1_19/items.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	itemTags, err := items.LoadTags("../data/1_19/tags/items", itemRegistry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := LoadRegistry("../data/1_19/recipes", itemRegistry, itemTags)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return registry
+}
+
+func TestLoadRegistry(t *testing.T) {
+	registry := loadTestRegistry(t)
+
+	recipe, ok := registry.Recipe("minecraft:coarse_dirt")
+	if !ok || recipe.Type != TypeShaped || recipe.Width != 2 || recipe.Height != 2 || recipe.Result.Count != 4 {
+		t.Fatalf("unexpected recipe: %+v (%v)", recipe, ok)
+	}
+
+	recipe, ok = registry.Recipe("minecraft:stone")
+	if !ok || recipe.Type != TypeSmelting || recipe.CookingTime != 200 || recipe.Ingredients[0][0] != cobblestone {
+		t.Fatalf("unexpected recipe: %+v (%v)", recipe, ok)
+	}
+}
+
+func TestMatchShaped(t *testing.T) {
+	registry := loadTestRegistry(t)
+
+	cases := []struct {
+		grid     []int
+		size     int
+		expected bool
+	}{
+		{[]int{dirt, gravel, gravel, dirt}, 2, true},
+		{[]int{gravel, dirt, dirt, gravel}, 2, true}, // mirrored
+		{[]int{0, 0, 0, 0, dirt, gravel, 0, gravel, dirt}, 3, true},
+		{[]int{dirt, gravel, 0, 0, 0, 0, gravel, dirt, 0}, 3, false},
+		{[]int{dirt, gravel, gravel, 0}, 2, false},
+	}
+
+	for i, c := range cases {
+		recipe, ok := registry.MatchCrafting(c.grid, c.size)
+		matched := ok && recipe.ID == "minecraft:coarse_dirt"
+		if matched != c.expected {
+			t.Fatalf("case %d: expected %v, got %+v", i, c.expected, recipe)
+		}
+	}
+}
+
+func TestMatchShapeless(t *testing.T) {
+	registry := loadTestRegistry(t)
+
+	recipe, ok := registry.MatchCrafting([]int{0, cobblestone, 0, 0, 0, 0, diorite, 0, 0}, 3)
+	if !ok || recipe.Result.ItemID != andesite || recipe.Result.Count != 2 {
+		t.Fatalf("unexpected recipe: %+v (%v)", recipe, ok)
+	}
+
+	if _, ok := registry.MatchCrafting([]int{cobblestone, diorite, diorite, 0}, 2); ok {
+		t.Fatalf("expected extra items to be rejected")
+	}
+}
+
+func TestMatchTags(t *testing.T) {
+	registry := loadTestRegistry(t)
+
+	// minecraft:planks accepts planks of every wood, so they can be mixed
+	grid := []int{oakPlanks, mangrovePlanks, crimsonPlanks, oakPlanks, 0, oakPlanks, crimsonPlanks, mangrovePlanks, oakPlanks}
+	recipe, ok := registry.MatchCrafting(grid, 3)
+	if !ok || recipe.ID != "minecraft:chest" || recipe.Result.ItemID != chest {
+		t.Fatalf("unexpected recipe: %+v (%v)", recipe, ok)
+	}
+
+	recipe, ok = registry.Recipe("minecraft:charcoal")
+	if !ok || recipe.Type != TypeSmelting || recipe.Result.ItemID != charcoal {
+		t.Fatalf("unexpected recipe: %+v (%v)", recipe, ok)
+	}
+
+	found := false
+	for _, id := range recipe.Ingredients[0] {
+		found = found || id == mangroveLog
+	}
+	if !found {
+		t.Fatalf("expected mangrove logs to burn into charcoal")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	registry := NewRegistry()
+
+	recipe, err := NewShaped("custom:pillar", []string{"C", "C"}, map[rune]Ingredient{'C': {cobblestone}}, Result{ItemID: andesite, Count: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = registry.Register(recipe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if registry.Register(recipe) == nil {
+		t.Fatalf("expected duplicated recipe to be rejected")
+	}
+
+	if _, ok := registry.MatchCrafting([]int{0, cobblestone, 0, cobblestone}, 2); !ok {
+		t.Fatalf("expected custom recipe to match")
+	}
+
+	_, err = NewShaped("custom:broken", []string{"CX"}, map[rune]Ingredient{'C': {cobblestone}}, Result{ItemID: andesite, Count: 1})
+	if err == nil {
+		t.Fatalf("expected undefined key to be rejected")
+	}
+}
@@ -0,0 +1,288 @@
+package recipes
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/mkorman9/go-minecraft-server/items"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Registry holds all recipes known to the server. Recipes can be added at any time.
+type Registry struct {
+	m       sync.RWMutex
+	recipes map[string]*Recipe
+}
+
+type recipeData struct {
+	Type        string              `json:"type"`
+	Group       string              `json:"group"`
+	Pattern     []string            `json:"pattern"`
+	Key         map[string]itemList `json:"key"`
+	Ingredients []itemList          `json:"ingredients"`
+	Ingredient  itemList            `json:"ingredient"`
+	Result      json.RawMessage     `json:"result"`
+	Experience  float32             `json:"experience"`
+	CookingTime *int                `json:"cookingtime"`
+}
+
+// itemList is an ingredient in the JSON format, either a single item or a list of alternatives.
+type itemList []itemEntry
+
+type itemEntry struct {
+	Item string `json:"item"`
+	Tag  string `json:"tag"`
+}
+
+type resultData struct {
+	Item  string `json:"item"`
+	Count int    `json:"count"`
+}
+
+// DefaultCookingTime is the cooking time of smelting recipes which don't specify it.
+const DefaultCookingTime = 200
+
+func NewRegistry() *Registry {
+	return &Registry{
+		recipes: make(map[string]*Recipe),
+	}
+}
+
+// LoadRegistry reads recipes in the vanilla data pack format from JSON files in the directory, named after
+// the recipes (e.g. stone.json holds minecraft:stone). Item tags used as ingredients are expanded into
+// the items they contain. Recipes of unsupported types, or using items or tags unknown to the server, are skipped.
+func LoadRegistry(directory string, itemRegistry *items.Registry, itemTags *items.Tags) (*Registry, error) {
+	paths, err := filepath.Glob(filepath.Join(directory, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	registry := NewRegistry()
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var recipe recipeData
+		err = json.Unmarshal(data, &recipe)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+
+		id := "minecraft:" + strings.TrimSuffix(filepath.Base(path), ".json")
+
+		parsed, ok, err := recipe.parse(id, itemRegistry, itemTags)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+		if !ok {
+			continue
+		}
+
+		err = registry.Register(parsed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return registry, nil
+}
+
+// Register adds the recipe, which has to have a unique ID.
+func (r *Registry) Register(recipe *Recipe) error {
+	err := recipe.validate()
+	if err != nil {
+		return err
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if _, ok := r.recipes[recipe.ID]; ok {
+		return fmt.Errorf("duplicated recipe: %s", recipe.ID)
+	}
+
+	r.recipes[recipe.ID] = recipe
+	return nil
+}
+
+func (r *Registry) Recipe(id string) (*Recipe, bool) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	recipe, ok := r.recipes[id]
+	return recipe, ok
+}
+
+// All returns all recipes sorted by ID.
+func (r *Registry) All() []*Recipe {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	all := make([]*Recipe, 0, len(r.recipes))
+	for _, recipe := range r.recipes {
+		all = append(all, recipe)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].ID < all[j].ID
+	})
+
+	return all
+}
+
+func (r *Registry) Len() int {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return len(r.recipes)
+}
+
+// MatchCrafting returns the recipe made of items in the square crafting grid (listed row by row, 0 for empty slots).
+// Recipes are checked in order of their IDs, so the result doesn't depend on the order of registration.
+func (r *Registry) MatchCrafting(grid []int, size int) (*Recipe, bool) {
+	for _, recipe := range r.All() {
+		if recipe.Matches(grid, size) {
+			return recipe, true
+		}
+	}
+
+	return nil, false
+}
+
+func (rd *recipeData) parse(id string, itemRegistry *items.Registry, itemTags *items.Tags) (*Recipe, bool, error) {
+	switch rd.Type {
+	case TypeShaped, TypeShapeless:
+		var result resultData
+		err := json.Unmarshal(rd.Result, &result)
+		if err != nil {
+			return nil, false, err
+		}
+		if result.Count == 0 {
+			result.Count = 1
+		}
+
+		item, ok := itemRegistry.ByName(result.Item)
+		if !ok {
+			return nil, false, nil
+		}
+
+		var recipe *Recipe
+		if rd.Type == TypeShaped {
+			key := make(map[rune]Ingredient, len(rd.Key))
+			for symbol, list := range rd.Key {
+				ingredient, ok := list.resolve(itemRegistry, itemTags)
+				if !ok {
+					return nil, false, nil
+				}
+
+				runes := []rune(symbol)
+				if len(runes) != 1 {
+					return nil, false, fmt.Errorf("invalid key: %s", symbol)
+				}
+				key[runes[0]] = ingredient
+			}
+
+			recipe, err = NewShaped(id, rd.Pattern, key, Result{ItemID: item.ID, Count: result.Count})
+		} else {
+			ingredients := make([]Ingredient, 0, len(rd.Ingredients))
+			for _, list := range rd.Ingredients {
+				ingredient, ok := list.resolve(itemRegistry, itemTags)
+				if !ok {
+					return nil, false, nil
+				}
+				ingredients = append(ingredients, ingredient)
+			}
+
+			recipe, err = NewShapeless(id, ingredients, Result{ItemID: item.ID, Count: result.Count})
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		recipe.Group = rd.Group
+		return recipe, true, nil
+	case TypeSmelting, TypeBlasting, TypeSmoking, TypeCampfireCooking:
+		var resultName string
+		err := json.Unmarshal(rd.Result, &resultName)
+		if err != nil {
+			return nil, false, err
+		}
+
+		item, ok := itemRegistry.ByName(resultName)
+		if !ok {
+			return nil, false, nil
+		}
+
+		ingredient, ok := rd.Ingredient.resolve(itemRegistry, itemTags)
+		if !ok {
+			return nil, false, nil
+		}
+
+		cookingTime := DefaultCookingTime
+		if rd.CookingTime != nil {
+			cookingTime = *rd.CookingTime
+		}
+
+		recipe, err := NewCooking(id, rd.Type, ingredient, Result{ItemID: item.ID, Count: 1}, rd.Experience, cookingTime)
+		if err != nil {
+			return nil, false, err
+		}
+
+		recipe.Group = rd.Group
+		return recipe, true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+func (il *itemList) UnmarshalJSON(data []byte) error {
+	var single itemEntry
+	if err := json.Unmarshal(data, &single); err == nil {
+		*il = itemList{single}
+		return nil
+	}
+
+	var list []itemEntry
+	err := json.Unmarshal(data, &list)
+	if err != nil {
+		return err
+	}
+
+	*il = list
+	return nil
+}
+
+// resolve converts item names and tags to IDs, and returns false if any of them is unknown.
+func (il itemList) resolve(itemRegistry *items.Registry, itemTags *items.Tags) (Ingredient, bool) {
+	if len(il) == 0 {
+		return nil, false
+	}
+
+	ingredient := make(Ingredient, 0, len(il))
+	for _, entry := range il {
+		if entry.Tag != "" {
+			ids, ok := itemTags.Tag(entry.Tag)
+			if !ok || len(ids) == 0 {
+				return nil, false
+			}
+
+			ingredient = append(ingredient, ids...)
+			continue
+		}
+
+		item, ok := itemRegistry.ByName(entry.Item)
+		if !ok {
+			return nil, false
+		}
+
+		ingredient = append(ingredient, item.ID)
+	}
+
+	return ingredient, true
+}
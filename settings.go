@@ -1,6 +1,12 @@
 package main
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
 
 type Settings struct {
 	ServerAddress         string        `json:"serverAddress"`
@@ -13,4 +19,109 @@ type Settings struct {
 	SimulationDistance    int           `json:"simulationDistance"`
 	KeepAliveSendInterval time.Duration `json:"keepAliveSendInterval"`
 	PlayerTimeout         time.Duration `json:"playerTimeout"`
+	WorldDirectory        string        `json:"worldDirectory"`
+	AutosaveInterval      time.Duration `json:"autosaveInterval"`
+	LevelType             string        `json:"levelType"`
+	GeneratorSettings     string        `json:"generatorSettings"`
+	LevelSeed             string        `json:"levelSeed"`
+	DeathMessage          string        `json:"deathMessage"`
+	PVP                   bool          `json:"pvp"`
+	GameMode              string        `json:"gameMode"`
+}
+
+const (
+	LevelTypeFlat   = "minecraft:flat"
+	LevelTypeNormal = "minecraft:normal"
+)
+
+const (
+	MinViewDistance = 2
+	MaxViewDistance = 32
+)
+
+func DefaultSettings() *Settings {
+	return &Settings{
+		ServerAddress:         "0.0.0.0:25565",
+		Description:           "Simple Go Server",
+		MaxPlayers:            2137,
+		OnlineMode:            false,
+		CompressionThreshold:  -1,
+		IsDebug:               true,
+		ViewDistance:          10,
+		SimulationDistance:    10,
+		KeepAliveSendInterval: 5,
+		PlayerTimeout:         15,
+		WorldDirectory:        "world",
+		AutosaveInterval:      300,
+		LevelType:             LevelTypeFlat,
+		GeneratorSettings:     "",
+		LevelSeed:             "",
+		DeathMessage:          "{player} {cause}",
+		PVP:                   true,
+		GameMode:              "survival",
+	}
+}
+
+// normalize converts values written the vanilla way into the form used by the server, whichever source they come from.
+// Level type is lowercased and prefixed with the minecraft namespace, and "default" means minecraft:normal.
+func (s *Settings) normalize() {
+	s.LevelType = strings.ToLower(s.LevelType)
+	if !strings.Contains(s.LevelType, ":") {
+		s.LevelType = "minecraft:" + s.LevelType
+	}
+	if s.LevelType == "minecraft:default" {
+		s.LevelType = LevelTypeNormal
+	}
+}
+
+func (s *Settings) Validate() error {
+	if _, _, err := net.SplitHostPort(s.ServerAddress); err != nil {
+		return fmt.Errorf("invalid serverAddress %q: %v", s.ServerAddress, err)
+	}
+	if s.MaxPlayers < 1 {
+		return fmt.Errorf("maxPlayers must be positive, got %d", s.MaxPlayers)
+	}
+	if s.CompressionThreshold < -1 {
+		return fmt.Errorf("compressionThreshold must be -1 (disabled) or non-negative, got %d", s.CompressionThreshold)
+	}
+	if s.ViewDistance < MinViewDistance || s.ViewDistance > MaxViewDistance {
+		return fmt.Errorf("viewDistance must be between %d and %d, got %d", MinViewDistance, MaxViewDistance, s.ViewDistance)
+	}
+	if s.SimulationDistance < MinViewDistance || s.SimulationDistance > MaxViewDistance {
+		return fmt.Errorf("simulationDistance must be between %d and %d, got %d", MinViewDistance, MaxViewDistance, s.SimulationDistance)
+	}
+	if s.KeepAliveSendInterval <= 0 {
+		return errors.New("keepAliveSendInterval must be positive")
+	}
+	if s.PlayerTimeout <= s.KeepAliveSendInterval {
+		return errors.New("playerTimeout must be greater than keepAliveSendInterval")
+	}
+	if s.AutosaveInterval < 0 {
+		return errors.New("autosaveInterval must not be negative")
+	}
+	if s.LevelType != LevelTypeFlat && s.LevelType != LevelTypeNormal {
+		return fmt.Errorf("unsupported levelType %q", s.LevelType)
+	}
+	if _, ok := ParseGameMode(s.GameMode); !ok {
+		return fmt.Errorf("unsupported gameMode %q", s.GameMode)
+	}
+
+	return nil
+}
+
+// ParseGameMode converts the name of a game mode (e.g. creative) or its number, as in server.properties,
+// into GameMode.
+func ParseGameMode(value string) (GameMode, bool) {
+	switch strings.ToLower(value) {
+	case "survival", "0":
+		return GameModeSurvival, true
+	case "creative", "1":
+		return GameModeCreative, true
+	case "adventure", "2":
+		return GameModeAdventure, true
+	case "spectator", "3":
+		return GameModeSpectator, true
+	default:
+		return GameModeUnknown, false
+	}
 }
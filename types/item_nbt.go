@@ -0,0 +1,193 @@
+package types
+
+import (
+	"github.com/mkorman9/go-minecraft-server/nbt"
+)
+
+// Names of item NBT tags.
+const (
+	ItemTagDisplay         = "display"
+	ItemTagName            = "Name"
+	ItemTagLore            = "Lore"
+	ItemTagEnchantments    = "Enchantments"
+	ItemTagDamage          = "Damage"
+	ItemTagCustomModelData = "CustomModelData"
+)
+
+// Enchantment is an entry of the Enchantments tag of an item.
+type Enchantment struct {
+	ID    string `nbt:"id"`
+	Level int16  `nbt:"lvl"`
+}
+
+// itemTags holds the top-level tags of item NBT, without decoding the ones the helpers don't touch,
+// so they are written back unchanged.
+type itemTags = map[string]nbt.RawMessage
+
+// DisplayName returns the custom name of the item as JSON text, or false if it has none.
+func (s *SlotData) DisplayName() (string, bool) {
+	var name string
+	ok := s.getTag(&name, ItemTagDisplay, ItemTagName)
+	return name, ok
+}
+
+// SetDisplayName sets the custom name of the item, given as JSON text. Empty name removes it.
+func (s *SlotData) SetDisplayName(name string) error {
+	if name == "" {
+		return s.setTag(nil, ItemTagDisplay, ItemTagName)
+	}
+
+	return s.setTag(name, ItemTagDisplay, ItemTagName)
+}
+
+// Lore returns lines of the item description as JSON text.
+func (s *SlotData) Lore() []string {
+	var lore []string
+	s.getTag(&lore, ItemTagDisplay, ItemTagLore)
+	return lore
+}
+
+func (s *SlotData) SetLore(lore []string) error {
+	if len(lore) == 0 {
+		return s.setTag(nil, ItemTagDisplay, ItemTagLore)
+	}
+
+	return s.setTag(lore, ItemTagDisplay, ItemTagLore)
+}
+
+func (s *SlotData) Enchantments() []Enchantment {
+	var enchantments []Enchantment
+	s.getTag(&enchantments, ItemTagEnchantments)
+	return enchantments
+}
+
+func (s *SlotData) SetEnchantments(enchantments []Enchantment) error {
+	if len(enchantments) == 0 {
+		return s.setTag(nil, ItemTagEnchantments)
+	}
+
+	return s.setTag(enchantments, ItemTagEnchantments)
+}
+
+// Damage returns how much durability the item has lost.
+func (s *SlotData) Damage() int {
+	var damage int32
+	s.getTag(&damage, ItemTagDamage)
+	return int(damage)
+}
+
+func (s *SlotData) SetDamage(damage int) error {
+	if damage == 0 {
+		return s.setTag(nil, ItemTagDamage)
+	}
+
+	return s.setTag(int32(damage), ItemTagDamage)
+}
+
+// CustomModelData returns the value used by resource packs to pick the model of the item, or false if it's not set.
+func (s *SlotData) CustomModelData() (int, bool) {
+	var value int32
+	ok := s.getTag(&value, ItemTagCustomModelData)
+	return int(value), ok
+}
+
+func (s *SlotData) SetCustomModelData(value int) error {
+	return s.setTag(int32(value), ItemTagCustomModelData)
+}
+
+// RemoveCustomModelData removes the custom model data, bringing back the default model.
+func (s *SlotData) RemoveCustomModelData() error {
+	return s.setTag(nil, ItemTagCustomModelData)
+}
+
+// getTag decodes the tag found under given path of nested compounds into value, and returns false
+// if it's missing or has a different type.
+func (s *SlotData) getTag(value any, path ...string) bool {
+	if s.NBT == nil || s.NBT.Type != nbt.TagCompound {
+		return false
+	}
+
+	raw := *s.NBT
+	for _, name := range path {
+		var tags itemTags
+		if raw.Type != nbt.TagCompound || raw.Unmarshal(&tags) != nil {
+			return false
+		}
+
+		var ok bool
+		raw, ok = tags[name]
+		if !ok {
+			return false
+		}
+	}
+
+	return raw.Unmarshal(value) == nil
+}
+
+// setTag stores value under given path of nested compounds, creating the compounds if needed.
+// Nil value removes the tag, along with compounds left empty. Item NBT left empty is removed.
+func (s *SlotData) setTag(value any, path ...string) error {
+	var root nbt.RawMessage
+	if s.NBT != nil && s.NBT.Type == nbt.TagCompound {
+		root = *s.NBT
+	}
+
+	root, err := setNestedTag(root, value, path)
+	if err != nil {
+		return err
+	}
+
+	if root.Type == nbt.TagEnd {
+		s.NBT = nil
+	} else {
+		s.NBT = &root
+	}
+
+	return nil
+}
+
+func setNestedTag(compound nbt.RawMessage, value any, path []string) (nbt.RawMessage, error) {
+	tags := make(itemTags)
+	if compound.Type == nbt.TagCompound {
+		err := compound.Unmarshal(&tags)
+		if err != nil {
+			return nbt.RawMessage{}, err
+		}
+	}
+
+	name := path[0]
+
+	var child nbt.RawMessage
+	var err error
+	if len(path) > 1 {
+		child, err = setNestedTag(tags[name], value, path[1:])
+	} else if value != nil {
+		child, err = rawTag(value)
+	}
+	if err != nil {
+		return nbt.RawMessage{}, err
+	}
+
+	if child.Type == nbt.TagEnd {
+		delete(tags, name)
+	} else {
+		tags[name] = child
+	}
+
+	if len(tags) == 0 {
+		return nbt.RawMessage{}, nil
+	}
+
+	return rawTag(tags)
+}
+
+// rawTag encodes the value as an unnamed tag.
+func rawTag(value any) (nbt.RawMessage, error) {
+	data, err := nbt.Marshal(value)
+	if err != nil {
+		return nbt.RawMessage{}, err
+	}
+
+	// skip the tag type and the empty name
+	return nbt.RawMessage{Type: data[0], Data: data[3:]}, nil
+}
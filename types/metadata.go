@@ -0,0 +1,247 @@
+package types
+
+import (
+	"fmt"
+	"github.com/mkorman9/go-minecraft-server/nbt"
+	"io"
+)
+
+// Types of entity metadata values in protocol 759.
+const (
+	MetadataTypeByte              = 0
+	MetadataTypeVarInt            = 1
+	MetadataTypeFloat             = 2
+	MetadataTypeString            = 3
+	MetadataTypeChat              = 4
+	MetadataTypeOptChat           = 5
+	MetadataTypeSlot              = 6
+	MetadataTypeBool              = 7
+	MetadataTypeRotation          = 8
+	MetadataTypePosition          = 9
+	MetadataTypeOptPosition       = 10
+	MetadataTypeDirection         = 11
+	MetadataTypeOptUUID           = 12
+	MetadataTypeOptBlockID        = 13
+	MetadataTypeNBT               = 14
+	MetadataTypeParticle          = 15
+	MetadataTypeVillagerData      = 16
+	MetadataTypeOptVarInt         = 17
+	MetadataTypePose              = 18
+	MetadataTypeCatVariant        = 19
+	MetadataTypeFrogVariant       = 20
+	MetadataTypeOptGlobalPosition = 21
+	MetadataTypePaintingVariant   = 22
+)
+
+const metadataEnd = 0xff
+
+// MetadataEntry is a single value of entity metadata. Go type of the value depends on the metadata type:
+// byte for Byte, int for VarInt, Direction, OptBlockID, Pose and variants, float32 for Float,
+// string for String and Chat (JSON), *string for OptChat, *SlotData for Slot, bool for Bool,
+// [3]float32 for Rotation, *Position for Position and OptPosition, *UUID for OptUUID,
+// *nbt.RawMessage for NBT, [3]int for VillagerData and *int for OptVarInt.
+// Nil pointers of optional types are encoded as absent values.
+type MetadataEntry struct {
+	Index byte
+	Type  int
+	Value any
+}
+
+type Metadata struct {
+	Entries []MetadataEntry
+}
+
+func WriteMetadata(writer io.Writer, metadata *Metadata) error {
+	for _, entry := range metadata.Entries {
+		err := WriteByte(writer, entry.Index)
+		if err != nil {
+			return err
+		}
+
+		err = WriteVarInt(writer, entry.Type)
+		if err != nil {
+			return err
+		}
+
+		err = writeMetadataValue(writer, entry.Type, entry.Value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return WriteByte(writer, metadataEnd)
+}
+
+func ReadMetadata(reader io.Reader) (Metadata, error) {
+	var metadata Metadata
+
+	for {
+		index, err := ReadByte(reader)
+		if err != nil {
+			return Metadata{}, err
+		}
+
+		if index == metadataEnd {
+			return metadata, nil
+		}
+
+		valueType, err := ReadVarInt(reader)
+		if err != nil {
+			return Metadata{}, err
+		}
+
+		value, err := readMetadataValue(reader, valueType)
+		if err != nil {
+			return Metadata{}, err
+		}
+
+		metadata.Entries = append(metadata.Entries, MetadataEntry{Index: index, Type: valueType, Value: value})
+	}
+}
+
+func writeMetadataValue(writer io.Writer, valueType int, value any) error {
+	switch valueType {
+	case MetadataTypeByte:
+		return WriteByte(writer, value.(byte))
+	case MetadataTypeVarInt, MetadataTypeDirection, MetadataTypeOptBlockID, MetadataTypePose,
+		MetadataTypeCatVariant, MetadataTypeFrogVariant, MetadataTypePaintingVariant:
+		return WriteVarInt(writer, value.(int))
+	case MetadataTypeFloat:
+		return WriteFloat32(writer, value.(float32))
+	case MetadataTypeString, MetadataTypeChat:
+		return WriteString(writer, value.(string))
+	case MetadataTypeOptChat:
+		text := value.(*string)
+		err := WriteBool(writer, text != nil)
+		if err != nil || text == nil {
+			return err
+		}
+		return WriteString(writer, *text)
+	case MetadataTypeSlot:
+		return WriteSlot(writer, value.(*SlotData))
+	case MetadataTypeBool:
+		return WriteBool(writer, value.(bool))
+	case MetadataTypeRotation:
+		rotation := value.([3]float32)
+		for _, angle := range rotation {
+			err := WriteFloat32(writer, angle)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case MetadataTypePosition:
+		return WritePosition(writer, value.(*Position))
+	case MetadataTypeOptPosition:
+		position := value.(*Position)
+		err := WriteBool(writer, position != nil)
+		if err != nil || position == nil {
+			return err
+		}
+		return WritePosition(writer, position)
+	case MetadataTypeOptUUID:
+		uuid := value.(*UUID)
+		err := WriteBool(writer, uuid != nil)
+		if err != nil || uuid == nil {
+			return err
+		}
+		return WriteUUID(writer, *uuid)
+	case MetadataTypeNBT:
+		return WriteNBT(writer, value.(*nbt.RawMessage))
+	case MetadataTypeVillagerData:
+		villagerData := value.([3]int)
+		for _, v := range villagerData {
+			err := WriteVarInt(writer, v)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case MetadataTypeOptVarInt:
+		v := value.(*int)
+		if v == nil {
+			return WriteVarInt(writer, 0)
+		}
+		return WriteVarInt(writer, *v+1)
+	}
+
+	return fmt.Errorf("unsupported metadata type: %d", valueType)
+}
+
+func readMetadataValue(reader io.Reader, valueType int) (any, error) {
+	switch valueType {
+	case MetadataTypeByte:
+		return ReadByte(reader)
+	case MetadataTypeVarInt, MetadataTypeDirection, MetadataTypeOptBlockID, MetadataTypePose,
+		MetadataTypeCatVariant, MetadataTypeFrogVariant, MetadataTypePaintingVariant:
+		return ReadVarInt(reader)
+	case MetadataTypeFloat:
+		return ReadFloat32(reader)
+	case MetadataTypeString, MetadataTypeChat:
+		return ReadString(reader)
+	case MetadataTypeOptChat:
+		present, err := ReadBool(reader)
+		if err != nil || !present {
+			return (*string)(nil), err
+		}
+		text, err := ReadString(reader)
+		return &text, err
+	case MetadataTypeSlot:
+		slot, err := ReadSlot(reader)
+		return &slot, err
+	case MetadataTypeBool:
+		return ReadBool(reader)
+	case MetadataTypeRotation:
+		var rotation [3]float32
+		for i := range rotation {
+			angle, err := ReadFloat32(reader)
+			if err != nil {
+				return nil, err
+			}
+			rotation[i] = angle
+		}
+		return rotation, nil
+	case MetadataTypePosition:
+		position, err := ReadPosition(reader)
+		return &position, err
+	case MetadataTypeOptPosition:
+		present, err := ReadBool(reader)
+		if err != nil || !present {
+			return (*Position)(nil), err
+		}
+		position, err := ReadPosition(reader)
+		return &position, err
+	case MetadataTypeOptUUID:
+		present, err := ReadBool(reader)
+		if err != nil || !present {
+			return (*UUID)(nil), err
+		}
+		uuid, err := ReadUUID(reader)
+		return &uuid, err
+	case MetadataTypeNBT:
+		tags, err := ReadNBT(reader, &nbt.RawMessage{})
+		if err != nil || tags == nil {
+			return (*nbt.RawMessage)(nil), err
+		}
+		return tags.(*nbt.RawMessage), nil
+	case MetadataTypeVillagerData:
+		var villagerData [3]int
+		for i := range villagerData {
+			v, err := ReadVarInt(reader)
+			if err != nil {
+				return nil, err
+			}
+			villagerData[i] = v
+		}
+		return villagerData, nil
+	case MetadataTypeOptVarInt:
+		v, err := ReadVarInt(reader)
+		if err != nil || v == 0 {
+			return (*int)(nil), err
+		}
+		v--
+		return &v, nil
+	}
+
+	return nil, fmt.Errorf("unsupported metadata type: %d", valueType)
+}
@@ -173,7 +173,7 @@ func ReadString(reader io.Reader) (string, error) {
 func ReadNBT(reader io.Reader, blueprint any) (any, error) {
 	obj := reflect.New(reflect.TypeOf(blueprint))
 
-	_, err := nbt.NewDecoder(reader).Decode(&obj)
+	_, err := nbt.NewDecoder(reader).Decode(obj.Interface())
 	if err != nil {
 		if errors.Is(err, nbt.ErrEND) {
 			return nil, nil
@@ -182,7 +182,7 @@ func ReadNBT(reader io.Reader, blueprint any) (any, error) {
 		return nil, err
 	}
 
-	return obj, nil
+	return obj.Elem().Interface(), nil
 }
 
 func ReadPosition(reader io.Reader) (Position, error) {
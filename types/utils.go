@@ -1,6 +1,8 @@
 package types
 
 import (
+	"crypto/md5"
+	"encoding/binary"
 	"math/rand"
 )
 
@@ -26,3 +28,16 @@ func GetRandomUUID() UUID {
 		Lower: rand.Int63(),
 	}
 }
+
+// GetOfflineUUID returns the UUID given to a player by the vanilla server in offline mode,
+// which is a name-based (version 3) UUID of "OfflinePlayer:<name>".
+func GetOfflineUUID(name string) UUID {
+	hash := md5.Sum([]byte("OfflinePlayer:" + name))
+	hash[6] = hash[6]&0x0f | 0x30
+	hash[8] = hash[8]&0x3f | 0x80
+
+	return UUID{
+		Upper: int64(binary.BigEndian.Uint64(hash[:8])),
+		Lower: int64(binary.BigEndian.Uint64(hash[8:])),
+	}
+}
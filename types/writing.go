@@ -127,6 +127,10 @@ func WritePosition(writer io.Writer, position *Position) error {
 }
 
 func WriteSlot(writer io.Writer, slot *SlotData) error {
+	if slot == nil {
+		return WriteBool(writer, false)
+	}
+
 	err := WriteBool(writer, slot.Present)
 	if err != nil {
 		return err
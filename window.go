@@ -0,0 +1,713 @@
+package main
+
+import (
+	"github.com/mkorman9/go-minecraft-server/types"
+	"sort"
+)
+
+const PlayerInventoryWindowID = 0
+
+// Click modes of the Click Container packet.
+const (
+	ClickModePickup    = 0
+	ClickModeQuickMove = 1
+	ClickModeSwap      = 2
+	ClickModeClone     = 3
+	ClickModeThrow     = 4
+	ClickModeDrag      = 5
+	ClickModePickupAll = 6
+)
+
+const (
+	// ClickSlotOutside is the slot number of clicks outside the window.
+	ClickSlotOutside = -999
+
+	// SwapButtonOffhand is the button of swap clicks made with the offhand key instead of a hotbar number.
+	SwapButtonOffhand = 40
+)
+
+// Kinds of drag, encoded in the upper bits of the drag click button.
+const (
+	dragSplit = 0
+	dragOne   = 1
+	dragClone = 2
+)
+
+type slotKind int
+
+const (
+	slotKindNormal slotKind = iota
+	// slotKindResult allows only taking items out, like the crafting result.
+	slotKindResult
+	// slotKindArmor holds a single piece of armor of the type matching the slot.
+	slotKindArmor
+)
+
+type windowSlot struct {
+	inventory *Inventory
+	index     int
+	kind      slotKind
+}
+
+// ContainerClick is a click in a window, along with the slots and the cursor item the client expects after it.
+type ContainerClick struct {
+	WindowID     byte
+	StateID      int
+	Slot         int
+	Button       int
+	Mode         int
+	ChangedSlots map[int]*types.SlotData
+	Carried      *types.SlotData
+}
+
+type dragState struct {
+	kind  int
+	slots []int
+}
+
+// Window is a screen with item slots opened by a player. Slots of the window map to slots of one or more
+// inventories, e.g. a chest window shows the chest followed by the main inventory and hotbar of the player.
+// The state ID is increased with every change the client didn't make itself, so the server can tell
+// which clicks were made on an outdated view.
+type Window struct {
+	ID          byte
+	Type        MenuType
+	Title       *ChatMessage
+	player      *Player
+	slots       []windowSlot
+	inventories []*Inventory
+	quickMove   func(slot int) []int
+	stateID     int
+	cursor      *types.SlotData
+	drag        *dragState
+	changed     map[int]struct{}
+	crafting    *craftingGrid
+	onClick     func(window *Window, click *ContainerClick)
+	onClose     func(window *Window)
+}
+
+// playerStorageSlots are the slots of the player inventory where picked up and returned items go.
+var playerStorageSlots = append(
+	slotRange(InventorySlotHotbarFirst, InventorySlotHotbarFirst+HotbarSize),
+	slotRange(InventorySlotMainFirst, InventorySlotHotbarFirst)...,
+)
+
+func newWindow(id byte, player *Player, slots []windowSlot, quickMove func(slot int) []int) *Window {
+	window := &Window{
+		ID:        id,
+		player:    player,
+		slots:     slots,
+		quickMove: quickMove,
+		changed:   make(map[int]struct{}),
+	}
+
+	// the player inventory is always locked by clicks, as swaps and closing of the window change it
+	window.inventories = append(window.inventories, player.inventory)
+	seen := map[*Inventory]struct{}{player.inventory: {}}
+	for _, slot := range slots {
+		if _, ok := seen[slot.inventory]; !ok {
+			seen[slot.inventory] = struct{}{}
+			window.inventories = append(window.inventories, slot.inventory)
+		}
+	}
+
+	// inventories are always locked in the same order, so windows sharing them can't deadlock
+	sort.Slice(window.inventories, func(i, j int) bool {
+		return window.inventories[i].id < window.inventories[j].id
+	})
+
+	for _, inventory := range window.inventories {
+		inventory.m.Lock()
+		inventory.windows[window] = struct{}{}
+		inventory.m.Unlock()
+	}
+
+	return window
+}
+
+// newPlayerInventoryWindow creates the window of the player inventory, which is always open.
+func newPlayerInventoryWindow(player *Player) *Window {
+	slots := make([]windowSlot, PlayerInventorySize)
+	for i := range slots {
+		kind := slotKindNormal
+		if i == InventorySlotCraftingResult {
+			kind = slotKindResult
+		} else if i >= InventorySlotArmorFirst && i < InventorySlotArmorFirst+ArmorSlotsCount {
+			kind = slotKindArmor
+		}
+
+		slots[i] = windowSlot{inventory: player.inventory, index: i, kind: kind}
+	}
+
+	storage := slotRange(InventorySlotMainFirst, InventorySlotOffhand)
+	main := slotRange(InventorySlotMainFirst, InventorySlotHotbarFirst)
+	hotbar := slotRange(InventorySlotHotbarFirst, InventorySlotOffhand)
+
+	window := newWindow(PlayerInventoryWindowID, player, slots, func(slot int) []int {
+		switch {
+		case slot == InventorySlotCraftingResult:
+			return reversed(storage)
+		case slot >= InventorySlotMainFirst && slot < InventorySlotHotbarFirst:
+			return hotbar
+		case slot >= InventorySlotHotbarFirst && slot < InventorySlotOffhand:
+			return main
+		default:
+			return storage
+		}
+	})
+	window.crafting = newCraftingGrid(player, 2, InventorySlotCraftingFirst, InventorySlotCraftingResult)
+
+	return window
+}
+
+// Click applies the click to the window, and returns false if the client has a different view of the window
+// than the server and has to get its contents again.
+func (w *Window) Click(click *ContainerClick) bool {
+	if w.onClick != nil {
+		w.onClick(w, click)
+		return false
+	}
+
+	w.lock()
+	defer w.unlock()
+
+	w.changed = make(map[int]struct{})
+
+	switch click.Mode {
+	case ClickModePickup:
+		w.pickup(click.Slot, click.Button)
+	case ClickModeQuickMove:
+		w.quickMoveSlot(click.Slot)
+	case ClickModeSwap:
+		w.swap(click.Slot, click.Button)
+	case ClickModeClone:
+		w.clone(click.Slot)
+	case ClickModeDrag:
+		w.dragClick(click.Slot, click.Button)
+	case ClickModePickupAll:
+		w.pickupAll()
+	}
+
+	// throwing items out requires item entities, which the world doesn't have yet,
+	// so throw clicks are ignored and the items come back to the client with a resync
+
+	if click.Mode != ClickModeDrag {
+		w.drag = nil
+	}
+
+	matches := w.matchesClient(click)
+
+	// clients don't know recipes, so the crafting result is always sent by the server
+	if w.crafting != nil {
+		w.updateCraftingResult()
+	}
+
+	return matches
+}
+
+// matchesClient tells whether the client ended up with the same slots and cursor as the server after the click.
+func (w *Window) matchesClient(click *ContainerClick) bool {
+	if click.StateID != w.stateID || !sameStack(click.Carried, w.cursor) {
+		return false
+	}
+
+	for slot, item := range click.ChangedSlots {
+		if !w.validSlot(slot) || !sameStack(item, w.get(slot)) {
+			return false
+		}
+	}
+
+	for slot := range w.changed {
+		if _, ok := click.ChangedSlots[slot]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Resync sends all slots and the cursor item to the client.
+func (w *Window) Resync() {
+	w.lock()
+
+	w.stateID++
+	stateID := w.stateID
+	cursor := copyItem(w.cursor)
+	items := make([]*types.SlotData, len(w.slots))
+	for i := range w.slots {
+		items[i] = copyItem(w.get(i))
+	}
+
+	w.unlock()
+
+	w.player.SendContainerContent(w.ID, stateID, items, cursor)
+}
+
+// Player returns the player who opened the window.
+func (w *Window) Player() *Player {
+	return w.player
+}
+
+// OnClose sets the handler called after the window is closed by the player or the server.
+func (w *Window) OnClose(handler func(window *Window)) {
+	w.onClose = handler
+}
+
+// Close puts the item held on the cursor and items left in the crafting grid back into the player inventory.
+// Items that don't fit stay where they are. Container windows stop receiving changes of their inventories.
+func (w *Window) Close() {
+	w.returnItems()
+
+	if w.ID == PlayerInventoryWindowID {
+		return
+	}
+
+	for _, inventory := range w.inventories {
+		inventory.m.Lock()
+		delete(inventory.windows, w)
+		inventory.m.Unlock()
+	}
+
+	if w.onClose != nil {
+		w.onClose(w)
+	}
+}
+
+func (w *Window) returnItems() {
+	w.lock()
+	defer w.unlock()
+
+	inventory := w.player.inventory
+
+	if !isEmpty(w.cursor) {
+		leftover := inventory.add(w.cursor, playerStorageSlots, nil)
+		w.cursor = withCount(w.cursor, leftover)
+	}
+
+	if w.crafting != nil {
+		for _, slot := range w.crafting.grid {
+			item := w.get(slot)
+			if item == nil {
+				continue
+			}
+
+			leftover := inventory.add(item, playerStorageSlots, nil)
+			w.slots[slot].inventory.set(w.slots[slot].index, withCount(item, leftover), nil)
+		}
+
+		w.updateCraftingResult()
+	}
+}
+
+// slotChanged sends the slot to the client after it was changed outside of clicks in this window.
+// The inventory is locked by the caller.
+func (w *Window) slotChanged(inventory *Inventory, index int, item *types.SlotData) {
+	for i, slot := range w.slots {
+		if slot.inventory == inventory && slot.index == index {
+			w.stateID++
+			w.player.SendContainerSlot(w.ID, w.stateID, i, copyItem(item))
+
+			// the grid is changed from outside of the window, e.g. by a creative inventory edit
+			if w.crafting != nil && w.crafting.inGrid(i) {
+				w.updateCraftingResult()
+			}
+		}
+	}
+}
+
+func (w *Window) pickup(slot int, button int) {
+	if !w.validSlot(slot) || button > 1 {
+		return
+	}
+
+	item := w.get(slot)
+
+	if w.slots[slot].kind == slotKindResult {
+		w.takeResult(slot)
+		return
+	}
+
+	switch {
+	case isEmpty(w.cursor):
+		if item == nil {
+			return
+		}
+
+		take := int(item.ItemCount)
+		if button == 1 {
+			take = (take + 1) / 2
+		}
+
+		w.cursor = withCount(item, take)
+		w.set(slot, withCount(item, int(item.ItemCount)-take))
+	case item == nil || sameItem(item, w.cursor):
+		existing := 0
+		if item != nil {
+			existing = int(item.ItemCount)
+		}
+
+		amount := int(w.cursor.ItemCount)
+		if button == 1 {
+			amount = 1
+		}
+		if room := w.maxStack(slot, w.cursor) - existing; amount > room {
+			amount = room
+		}
+		if amount <= 0 {
+			return
+		}
+
+		w.set(slot, withCount(w.cursor, existing+amount))
+		w.cursor = withCount(w.cursor, int(w.cursor.ItemCount)-amount)
+	default:
+		if int(w.cursor.ItemCount) <= w.maxStack(slot, w.cursor) {
+			cursor := w.cursor
+			w.cursor = item
+			w.set(slot, cursor)
+		}
+	}
+}
+
+// takeResult moves the whole stack from a result slot to the cursor, if the cursor can hold it.
+func (w *Window) takeResult(slot int) {
+	item := w.get(slot)
+	if item == nil {
+		return
+	}
+
+	if isEmpty(w.cursor) {
+		w.cursor = item
+	} else if sameItem(w.cursor, item) && int(w.cursor.ItemCount+item.ItemCount) <= w.player.inventory.maxStackSize(item) {
+		w.cursor = withCount(item, int(w.cursor.ItemCount+item.ItemCount))
+	} else {
+		return
+	}
+
+	w.set(slot, nil)
+	w.resultTaken(slot)
+}
+
+func (w *Window) quickMoveSlot(slot int) {
+	if !w.validSlot(slot) {
+		return
+	}
+
+	if w.crafting != nil && slot == w.crafting.result {
+		w.craftAll()
+		return
+	}
+
+	item := w.get(slot)
+	if item == nil {
+		return
+	}
+
+	leftover := w.fill(item, w.quickMove(slot))
+	w.set(slot, withCount(item, leftover))
+}
+
+// swap exchanges the item in the slot with the item in a hotbar slot or the offhand.
+func (w *Window) swap(slot int, button int) {
+	var target int
+	switch {
+	case button >= 0 && button < HotbarSize:
+		target = InventorySlotHotbarFirst + button
+	case button == SwapButtonOffhand:
+		target = InventorySlotOffhand
+	default:
+		return
+	}
+
+	if !w.validSlot(slot) {
+		return
+	}
+
+	inventory := w.player.inventory
+	if w.slots[slot].inventory == inventory && w.slots[slot].index == target {
+		return
+	}
+
+	item := w.get(slot)
+	other := inventory.get(target)
+
+	if other != nil && (w.slots[slot].kind == slotKindResult || int(other.ItemCount) > w.maxStack(slot, other)) {
+		return
+	}
+
+	w.set(slot, other)
+	w.setInventorySlot(inventory, target, item)
+
+	if item != nil {
+		w.resultTaken(slot)
+	}
+}
+
+// clone puts a full stack of the clicked item on the cursor, in creative mode only.
+func (w *Window) clone(slot int) {
+	if w.player.GameMode != GameModeCreative || !isEmpty(w.cursor) || !w.validSlot(slot) {
+		return
+	}
+
+	item := w.get(slot)
+	if item == nil {
+		return
+	}
+
+	w.cursor = withCount(item, w.player.inventory.maxStackSize(item))
+}
+
+// dragClick handles painting items over slots while holding a mouse button. A drag starts and ends with a click
+// outside the window, and the items are spread over the slots only when it ends.
+func (w *Window) dragClick(slot int, button int) {
+	stage, kind := button&3, button>>2
+
+	switch stage {
+	case 0:
+		w.drag = nil
+		if slot != ClickSlotOutside || kind > dragClone || (kind == dragClone && w.player.GameMode != GameModeCreative) {
+			return
+		}
+
+		w.drag = &dragState{kind: kind}
+	case 1:
+		if w.drag == nil || w.drag.kind != kind || !w.canDragTo(slot) {
+			return
+		}
+
+		for _, s := range w.drag.slots {
+			if s == slot {
+				return
+			}
+		}
+
+		if kind != dragClone && int(w.cursor.ItemCount) <= len(w.drag.slots) {
+			return
+		}
+
+		w.drag.slots = append(w.drag.slots, slot)
+	case 2:
+		drag := w.drag
+		w.drag = nil
+		if drag == nil || drag.kind != kind || len(drag.slots) == 0 || isEmpty(w.cursor) {
+			return
+		}
+
+		remaining := int(w.cursor.ItemCount)
+
+		perSlot := remaining / len(drag.slots)
+		if kind == dragOne {
+			perSlot = 1
+		} else if kind == dragClone {
+			perSlot = w.player.inventory.maxStackSize(w.cursor)
+		}
+
+		for _, s := range drag.slots {
+			if !w.canDragTo(s) {
+				continue
+			}
+
+			existing := 0
+			if item := w.get(s); item != nil {
+				existing = int(item.ItemCount)
+			}
+
+			placed := perSlot
+			if room := w.maxStack(s, w.cursor) - existing; placed > room {
+				placed = room
+			}
+			if kind != dragClone && placed > remaining {
+				placed = remaining
+			}
+			if placed <= 0 {
+				continue
+			}
+
+			w.set(s, withCount(w.cursor, existing+placed))
+			if kind != dragClone {
+				remaining -= placed
+			}
+		}
+
+		w.cursor = withCount(w.cursor, remaining)
+	default:
+		w.drag = nil
+	}
+}
+
+// pickupAll collects items of the same type as the one on the cursor from the whole window,
+// taking from incomplete stacks first.
+func (w *Window) pickupAll() {
+	if isEmpty(w.cursor) {
+		return
+	}
+
+	limit := w.player.inventory.maxStackSize(w.cursor)
+	count := int(w.cursor.ItemCount)
+
+	for pass := 0; pass < 2 && count < limit; pass++ {
+		for i := range w.slots {
+			if count >= limit {
+				break
+			}
+
+			item := w.get(i)
+			if w.slots[i].kind == slotKindResult || !sameItem(item, w.cursor) {
+				continue
+			}
+			if pass == 0 && int(item.ItemCount) >= w.player.inventory.maxStackSize(item) {
+				continue
+			}
+
+			take := int(item.ItemCount)
+			if take > limit-count {
+				take = limit - count
+			}
+
+			w.set(i, withCount(item, int(item.ItemCount)-take))
+			count += take
+		}
+	}
+
+	w.cursor = withCount(w.cursor, count)
+}
+
+// fill puts the item into the slots, first topping up stacks of the same item and then filling empty slots,
+// and returns the number of items that didn't fit.
+func (w *Window) fill(item *types.SlotData, slots []int) int {
+	remaining := int(item.ItemCount)
+
+	for pass := 0; pass < 2 && remaining > 0; pass++ {
+		for _, slot := range slots {
+			if remaining == 0 {
+				break
+			}
+			if w.slots[slot].kind == slotKindResult {
+				continue
+			}
+
+			current := w.get(slot)
+
+			room, existing := 0, 0
+			if pass == 0 && sameItem(current, item) {
+				existing = int(current.ItemCount)
+				room = w.maxStack(slot, current) - existing
+			} else if pass == 1 && current == nil {
+				room = w.maxStack(slot, item)
+			}
+
+			if room <= 0 {
+				continue
+			}
+			if room > remaining {
+				room = remaining
+			}
+
+			w.set(slot, withCount(item, existing+room))
+			remaining -= room
+		}
+	}
+
+	return remaining
+}
+
+// room returns how many of the items fit into the slots.
+func (w *Window) room(item *types.SlotData, slots []int) int {
+	room := 0
+	for _, slot := range slots {
+		if w.slots[slot].kind == slotKindResult {
+			continue
+		}
+
+		current := w.get(slot)
+		if current == nil {
+			room += w.maxStack(slot, item)
+		} else if sameItem(current, item) {
+			room += w.maxStack(slot, current) - int(current.ItemCount)
+		}
+	}
+
+	return room
+}
+
+func (w *Window) canDragTo(slot int) bool {
+	if !w.validSlot(slot) || w.slots[slot].kind == slotKindResult || isEmpty(w.cursor) {
+		return false
+	}
+
+	item := w.get(slot)
+	if item == nil {
+		return w.accepts(slot, w.cursor)
+	}
+
+	return sameItem(item, w.cursor) && int(item.ItemCount) < w.maxStack(slot, item)
+}
+
+// maxStack returns how many of the items fit into the slot, 0 if the slot doesn't accept them at all.
+func (w *Window) maxStack(slot int, item *types.SlotData) int {
+	if !w.accepts(slot, item) {
+		return 0
+	}
+	if w.slots[slot].kind == slotKindArmor {
+		return 1
+	}
+
+	return w.slots[slot].inventory.maxStackSize(item)
+}
+
+// accepts tells whether the item can be put into the slot. Armor slots take only armor worn in them.
+func (w *Window) accepts(slot int, item *types.SlotData) bool {
+	windowSlot := w.slots[slot]
+	if windowSlot.kind != slotKindArmor {
+		return true
+	}
+
+	return windowSlot.inventory.items.Equipment(item.ItemID) == armorSlotEquipment(windowSlot.index)
+}
+
+func (w *Window) validSlot(slot int) bool {
+	return slot >= 0 && slot < len(w.slots)
+}
+
+func (w *Window) get(slot int) *types.SlotData {
+	return w.slots[slot].inventory.get(w.slots[slot].index)
+}
+
+func (w *Window) set(slot int, item *types.SlotData) {
+	if !sameStack(item, w.get(slot)) {
+		w.changed[slot] = struct{}{}
+	}
+
+	w.slots[slot].inventory.set(w.slots[slot].index, item, w)
+}
+
+// setInventorySlot changes a slot of an inventory, which may or may not be shown in this window.
+func (w *Window) setInventorySlot(inventory *Inventory, index int, item *types.SlotData) {
+	for i, slot := range w.slots {
+		if slot.inventory == inventory && slot.index == index {
+			w.set(i, item)
+			return
+		}
+	}
+
+	inventory.set(index, item, w)
+}
+
+func (w *Window) lock() {
+	for _, inventory := range w.inventories {
+		inventory.m.Lock()
+	}
+}
+
+func (w *Window) unlock() {
+	for i := len(w.inventories) - 1; i >= 0; i-- {
+		w.inventories[i].m.Unlock()
+	}
+}
+
+func reversed(indexes []int) []int {
+	result := make([]int, len(indexes))
+	for i, index := range indexes {
+		result[len(indexes)-1-i] = index
+	}
+	return result
+}
@@ -0,0 +1,464 @@
+package main
+
+import (
+	"github.com/mkorman9/go-minecraft-server/items"
+	"github.com/mkorman9/go-minecraft-server/packets"
+	"github.com/mkorman9/go-minecraft-server/recipes"
+	"github.com/mkorman9/go-minecraft-server/types"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	testStone      = 1
+	testDirt       = 2
+	testEnderPearl = 3
+	testHelmet     = 4
+	testBoots      = 5
+)
+
+const testItems = `[
+	{"id": 0, "name": "minecraft:air"},
+	{"id": 1, "name": "minecraft:stone"},
+	{"id": 2, "name": "minecraft:dirt"},
+	{"id": 3, "name": "minecraft:ender_pearl", "maxStackSize": 16, "block": ""},
+	{"id": 4, "name": "minecraft:iron_helmet", "maxStackSize": 1, "block": ""},
+	{"id": 5, "name": "minecraft:iron_boots", "maxStackSize": 1, "block": ""}
+]`
+
+func newTestPlayer(t *testing.T, gameMode GameMode) *Player {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "items.json")
+	err := os.WriteFile(path, []byte(testItems), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := items.LoadRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	world := &World{data: &Data{Items: registry, Recipes: recipes.NewRegistry()}}
+
+	player := NewPlayer(world, "")
+	player.GameMode = gameMode
+	player.AssignPacketHandler(&PlayerPacketHandler{packetWriter: packets.NewPacketWriter(io.Discard)})
+
+	return player
+}
+
+func item(id int, count int) *types.SlotData {
+	return &types.SlotData{Present: true, ItemID: id, ItemCount: byte(count)}
+}
+
+type testClick struct {
+	slot   int
+	button int
+	mode   int
+}
+
+func TestPlayerInventoryClicks(t *testing.T) {
+	cases := []struct {
+		name           string
+		gameMode       GameMode
+		slots          map[int]*types.SlotData
+		cursor         *types.SlotData
+		clicks         []testClick
+		expectedSlots  map[int]*types.SlotData
+		expectedCursor *types.SlotData
+	}{
+		{
+			name:           "pickup whole stack",
+			slots:          map[int]*types.SlotData{9: item(testDirt, 10)},
+			clicks:         []testClick{{9, 0, ClickModePickup}},
+			expectedSlots:  map[int]*types.SlotData{9: nil},
+			expectedCursor: item(testDirt, 10),
+		},
+		{
+			name:           "pickup half of stack",
+			slots:          map[int]*types.SlotData{9: item(testDirt, 9)},
+			clicks:         []testClick{{9, 1, ClickModePickup}},
+			expectedSlots:  map[int]*types.SlotData{9: item(testDirt, 4)},
+			expectedCursor: item(testDirt, 5),
+		},
+		{
+			name:           "place one item",
+			cursor:         item(testDirt, 10),
+			clicks:         []testClick{{9, 1, ClickModePickup}},
+			expectedSlots:  map[int]*types.SlotData{9: item(testDirt, 1)},
+			expectedCursor: item(testDirt, 9),
+		},
+		{
+			name:           "merge up to max stack size",
+			slots:          map[int]*types.SlotData{9: item(testDirt, 60)},
+			cursor:         item(testDirt, 10),
+			clicks:         []testClick{{9, 0, ClickModePickup}},
+			expectedSlots:  map[int]*types.SlotData{9: item(testDirt, 64)},
+			expectedCursor: item(testDirt, 6),
+		},
+		{
+			name:           "merge up to max stack size of item",
+			slots:          map[int]*types.SlotData{9: item(testEnderPearl, 10)},
+			cursor:         item(testEnderPearl, 10),
+			clicks:         []testClick{{9, 0, ClickModePickup}},
+			expectedSlots:  map[int]*types.SlotData{9: item(testEnderPearl, 16)},
+			expectedCursor: item(testEnderPearl, 4),
+		},
+		{
+			name:           "swap with cursor",
+			slots:          map[int]*types.SlotData{9: item(testStone, 5)},
+			cursor:         item(testDirt, 3),
+			clicks:         []testClick{{9, 0, ClickModePickup}},
+			expectedSlots:  map[int]*types.SlotData{9: item(testDirt, 3)},
+			expectedCursor: item(testStone, 5),
+		},
+		{
+			name:   "drag split",
+			cursor: item(testDirt, 10),
+			clicks: []testClick{
+				{ClickSlotOutside, 0, ClickModeDrag},
+				{9, 1, ClickModeDrag},
+				{10, 1, ClickModeDrag},
+				{11, 1, ClickModeDrag},
+				{ClickSlotOutside, 2, ClickModeDrag},
+			},
+			expectedSlots:  map[int]*types.SlotData{9: item(testDirt, 3), 10: item(testDirt, 3), 11: item(testDirt, 3)},
+			expectedCursor: item(testDirt, 1),
+		},
+		{
+			name:   "drag one",
+			slots:  map[int]*types.SlotData{10: item(testDirt, 5)},
+			cursor: item(testDirt, 10),
+			clicks: []testClick{
+				{ClickSlotOutside, 4, ClickModeDrag},
+				{9, 5, ClickModeDrag},
+				{10, 5, ClickModeDrag},
+				{11, 5, ClickModeDrag},
+				{ClickSlotOutside, 6, ClickModeDrag},
+			},
+			expectedSlots:  map[int]*types.SlotData{9: item(testDirt, 1), 10: item(testDirt, 6), 11: item(testDirt, 1)},
+			expectedCursor: item(testDirt, 7),
+		},
+		{
+			name:     "drag clone in creative mode",
+			gameMode: GameModeCreative,
+			cursor:   item(testDirt, 10),
+			clicks: []testClick{
+				{ClickSlotOutside, 8, ClickModeDrag},
+				{9, 9, ClickModeDrag},
+				{10, 9, ClickModeDrag},
+				{ClickSlotOutside, 10, ClickModeDrag},
+			},
+			expectedSlots:  map[int]*types.SlotData{9: item(testDirt, 64), 10: item(testDirt, 64)},
+			expectedCursor: item(testDirt, 10),
+		},
+		{
+			name:   "drag clone in survival mode",
+			cursor: item(testDirt, 10),
+			clicks: []testClick{
+				{ClickSlotOutside, 8, ClickModeDrag},
+				{9, 9, ClickModeDrag},
+				{ClickSlotOutside, 10, ClickModeDrag},
+			},
+			expectedSlots:  map[int]*types.SlotData{9: nil},
+			expectedCursor: item(testDirt, 10),
+		},
+		{
+			name:   "drag interrupted by other click",
+			cursor: item(testDirt, 10),
+			clicks: []testClick{
+				{ClickSlotOutside, 0, ClickModeDrag},
+				{9, 1, ClickModeDrag},
+				{20, 0, ClickModeClone},
+				{ClickSlotOutside, 2, ClickModeDrag},
+			},
+			expectedSlots:  map[int]*types.SlotData{9: nil},
+			expectedCursor: item(testDirt, 10),
+		},
+		{
+			name:          "shift click from main inventory to hotbar",
+			slots:         map[int]*types.SlotData{9: item(testDirt, 10), 40: item(testDirt, 60)},
+			clicks:        []testClick{{9, 0, ClickModeQuickMove}},
+			expectedSlots: map[int]*types.SlotData{9: nil, 36: item(testDirt, 6), 40: item(testDirt, 64)},
+		},
+		{
+			name:          "shift click from hotbar to main inventory",
+			slots:         map[int]*types.SlotData{36: item(testDirt, 10)},
+			clicks:        []testClick{{36, 0, ClickModeQuickMove}},
+			expectedSlots: map[int]*types.SlotData{9: item(testDirt, 10), 36: nil},
+		},
+		{
+			name:          "shift click from armor slot to storage",
+			slots:         map[int]*types.SlotData{5: item(testHelmet, 1)},
+			clicks:        []testClick{{5, 0, ClickModeQuickMove}},
+			expectedSlots: map[int]*types.SlotData{5: nil, 9: item(testHelmet, 1)},
+		},
+		{
+			name:          "swap with hotbar",
+			slots:         map[int]*types.SlotData{9: item(testStone, 5), 38: item(testDirt, 3)},
+			clicks:        []testClick{{9, 2, ClickModeSwap}},
+			expectedSlots: map[int]*types.SlotData{9: item(testDirt, 3), 38: item(testStone, 5)},
+		},
+		{
+			name:          "swap with offhand",
+			slots:         map[int]*types.SlotData{9: item(testStone, 5)},
+			clicks:        []testClick{{9, SwapButtonOffhand, ClickModeSwap}},
+			expectedSlots: map[int]*types.SlotData{9: nil, InventorySlotOffhand: item(testStone, 5)},
+		},
+		{
+			name:           "pickup all takes incomplete stacks first",
+			slots:          map[int]*types.SlotData{9: item(testDirt, 30), 10: item(testDirt, 64), 11: item(testDirt, 10)},
+			cursor:         item(testDirt, 1),
+			clicks:         []testClick{{9, 0, ClickModePickupAll}},
+			expectedSlots:  map[int]*types.SlotData{9: nil, 10: item(testDirt, 41), 11: nil},
+			expectedCursor: item(testDirt, 64),
+		},
+		{
+			name:           "clone in creative mode",
+			gameMode:       GameModeCreative,
+			slots:          map[int]*types.SlotData{9: item(testEnderPearl, 1)},
+			clicks:         []testClick{{9, 2, ClickModeClone}},
+			expectedSlots:  map[int]*types.SlotData{9: item(testEnderPearl, 1)},
+			expectedCursor: item(testEnderPearl, 16),
+		},
+		{
+			name:           "put armor into matching slot",
+			cursor:         item(testHelmet, 1),
+			clicks:         []testClick{{5, 0, ClickModePickup}},
+			expectedSlots:  map[int]*types.SlotData{5: item(testHelmet, 1)},
+			expectedCursor: nil,
+		},
+		{
+			name:           "put armor into other armor slot",
+			cursor:         item(testHelmet, 1),
+			clicks:         []testClick{{8, 0, ClickModePickup}},
+			expectedSlots:  map[int]*types.SlotData{8: nil},
+			expectedCursor: item(testHelmet, 1),
+		},
+		{
+			name:           "put other item into armor slot",
+			cursor:         item(testDirt, 1),
+			clicks:         []testClick{{5, 0, ClickModePickup}},
+			expectedSlots:  map[int]*types.SlotData{5: nil},
+			expectedCursor: item(testDirt, 1),
+		},
+		{
+			name:           "swap armor with other item on cursor",
+			slots:          map[int]*types.SlotData{8: item(testBoots, 1)},
+			cursor:         item(testDirt, 1),
+			clicks:         []testClick{{8, 0, ClickModePickup}},
+			expectedSlots:  map[int]*types.SlotData{8: item(testBoots, 1)},
+			expectedCursor: item(testDirt, 1),
+		},
+		{
+			name:          "swap other item from hotbar into armor slot",
+			slots:         map[int]*types.SlotData{36: item(testDirt, 1)},
+			clicks:        []testClick{{5, 0, ClickModeSwap}},
+			expectedSlots: map[int]*types.SlotData{5: nil, 36: item(testDirt, 1)},
+		},
+		{
+			name:          "swap armor from hotbar into armor slot",
+			slots:         map[int]*types.SlotData{36: item(testBoots, 1)},
+			clicks:        []testClick{{8, 0, ClickModeSwap}},
+			expectedSlots: map[int]*types.SlotData{8: item(testBoots, 1), 36: nil},
+		},
+		{
+			name:   "drag over armor slot",
+			cursor: item(testDirt, 10),
+			clicks: []testClick{
+				{ClickSlotOutside, 0, ClickModeDrag},
+				{5, 1, ClickModeDrag},
+				{9, 1, ClickModeDrag},
+				{ClickSlotOutside, 2, ClickModeDrag},
+			},
+			expectedSlots:  map[int]*types.SlotData{5: nil, 9: item(testDirt, 10)},
+			expectedCursor: nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gameMode := c.gameMode
+			if gameMode == 0 {
+				gameMode = GameModeSurvival
+			}
+
+			player := newTestPlayer(t, gameMode)
+			for slot, item := range c.slots {
+				player.inventory.Set(slot, item)
+			}
+
+			window := player.Inventory()
+			window.cursor = c.cursor
+
+			for _, click := range c.clicks {
+				window.Click(&ContainerClick{
+					WindowID: window.ID,
+					StateID:  window.stateID,
+					Slot:     click.slot,
+					Button:   click.button,
+					Mode:     click.mode,
+				})
+			}
+
+			for slot, expected := range c.expectedSlots {
+				if actual := player.inventory.Get(slot); !sameStack(actual, expected) {
+					t.Errorf("slot %d: expected %+v, got %+v", slot, expected, actual)
+				}
+			}
+			if !sameStack(window.cursor, c.expectedCursor) {
+				t.Errorf("cursor: expected %+v, got %+v", c.expectedCursor, window.cursor)
+			}
+		})
+	}
+}
+
+func TestContainerQuickMove(t *testing.T) {
+	player := newTestPlayer(t, GameModeSurvival)
+	chest := NewInventory(9, player.inventory.items)
+
+	window, err := newContainerWindow(1, player, MenuGeneric9x1, nil, chest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the last slot of the window is the last hotbar slot
+	chest.Set(0, item(testDirt, 10))
+	window.Click(&ContainerClick{WindowID: 1, StateID: window.stateID, Slot: 0, Mode: ClickModeQuickMove})
+
+	if chest.Get(0) != nil || !sameStack(player.inventory.Get(InventorySlotOffhand-1), item(testDirt, 10)) {
+		t.Fatalf("expected items to move to the end of the hotbar")
+	}
+
+	// window slot 9 is the first slot of the main inventory
+	player.inventory.Set(InventorySlotMainFirst, item(testStone, 5))
+	window.Click(&ContainerClick{WindowID: 1, StateID: window.stateID, Slot: 9, Mode: ClickModeQuickMove})
+
+	if player.inventory.Get(InventorySlotMainFirst) != nil || !sameStack(chest.Get(0), item(testStone, 5)) {
+		t.Fatalf("expected items to move to the container")
+	}
+}
+
+func TestClickStateID(t *testing.T) {
+	player := newTestPlayer(t, GameModeSurvival)
+	player.inventory.Set(9, item(testDirt, 10))
+
+	window := player.Inventory()
+
+	cases := []struct {
+		name     string
+		stateID  func() int
+		changed  map[int]*types.SlotData
+		carried  *types.SlotData
+		expected bool
+	}{
+		{
+			name:     "matching prediction",
+			stateID:  func() int { return window.stateID },
+			changed:  map[int]*types.SlotData{9: nil},
+			carried:  item(testDirt, 10),
+			expected: true,
+		},
+		{
+			name:     "outdated state ID",
+			stateID:  func() int { return window.stateID - 1 },
+			changed:  map[int]*types.SlotData{9: nil},
+			carried:  item(testDirt, 10),
+			expected: false,
+		},
+		{
+			name:     "different cursor",
+			stateID:  func() int { return window.stateID },
+			changed:  map[int]*types.SlotData{9: nil},
+			carried:  item(testDirt, 5),
+			expected: false,
+		},
+		{
+			name:     "missing changed slot",
+			stateID:  func() int { return window.stateID },
+			changed:  map[int]*types.SlotData{},
+			carried:  item(testDirt, 10),
+			expected: false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			player.inventory.Set(9, item(testDirt, 10))
+			window.cursor = nil
+
+			matches := window.Click(&ContainerClick{
+				WindowID:     window.ID,
+				StateID:      c.stateID(),
+				Slot:         9,
+				Mode:         ClickModePickup,
+				ChangedSlots: c.changed,
+				Carried:      c.carried,
+			})
+			if matches != c.expected {
+				t.Fatalf("expected %v, got %v", c.expected, matches)
+			}
+
+			if !matches {
+				stateID := window.stateID
+				window.Resync()
+				if window.stateID != stateID+1 {
+					t.Fatalf("expected resync to increase state ID")
+				}
+			}
+		})
+	}
+
+	// changes made outside of the window make clicks on the old view outdated
+	stateID := window.stateID
+	player.inventory.Set(10, item(testStone, 1))
+	if window.stateID == stateID {
+		t.Fatalf("expected state ID to change after inventory update")
+	}
+}
+
+func TestArmorSlotsWithBundledItems(t *testing.T) {
+	world := newTestWorld(t)
+
+	cases := []struct {
+		item     string
+		slot     int
+		expected bool
+	}{
+		{"minecraft:iron_helmet", InventorySlotArmorFirst, true},
+		{"minecraft:turtle_helmet", InventorySlotArmorFirst, true},
+		{"minecraft:carved_pumpkin", InventorySlotArmorFirst, true},
+		{"minecraft:diamond_chestplate", InventorySlotArmorFirst + 1, true},
+		{"minecraft:elytra", InventorySlotArmorFirst + 1, true},
+		{"minecraft:leather_leggings", InventorySlotArmorFirst + 2, true},
+		{"minecraft:netherite_boots", InventorySlotArmorFirst + 3, true},
+		{"minecraft:iron_boots", InventorySlotArmorFirst, false},
+		{"minecraft:iron_pickaxe", InventorySlotArmorFirst, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.item, func(t *testing.T) {
+			player := newTestWorldPlayer(t, world, GameModeSurvival)
+
+			armor, ok := world.Data().Items.ByName(c.item)
+			if !ok {
+				t.Fatalf("unknown item: %s", c.item)
+			}
+			player.inventory.Set(InventorySlotMainFirst, item(armor.ID, 1))
+
+			window := player.Inventory()
+			for _, slot := range []int{InventorySlotMainFirst, c.slot} {
+				window.Click(&ContainerClick{WindowID: window.ID, StateID: window.stateID, Slot: slot, Mode: ClickModePickup})
+			}
+
+			worn := sameStack(player.inventory.Get(c.slot), item(armor.ID, 1))
+			if worn != c.expected {
+				t.Fatalf("expected %s in slot %d: %v, got %+v", c.item, c.slot, c.expected, player.inventory.Get(c.slot))
+			}
+		})
+	}
+}
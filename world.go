@@ -1,11 +1,31 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"github.com/mkorman9/go-minecraft-server/anvil"
+	"github.com/mkorman9/go-minecraft-server/chunk"
+	"github.com/mkorman9/go-minecraft-server/generator"
+	"github.com/mkorman9/go-minecraft-server/light"
+	"github.com/mkorman9/go-minecraft-server/nbt"
+	"github.com/mkorman9/go-minecraft-server/recipes"
+	"github.com/mkorman9/go-minecraft-server/types"
+	"log"
+	"math"
 	"math/rand"
 	"net"
+	"os"
+	"path/filepath"
+	"sync"
 	"time"
 )
 
+// lineOfSightStep is the distance between points checked by HasLineOfSight.
+const lineOfSightStep = 0.1
+
+// MaxFillVolume is the maximal number of blocks changed by a single Fill, the same as in the vanilla /fill command.
+const MaxFillVolume = 32768
+
 type World struct {
 	settings       *Settings
 	data           *Data
@@ -13,6 +33,15 @@ type World struct {
 	playerList     *PlayerList
 	backgroundJob  *BackgroundJob
 	entityStore    *EntityStore
+	entityTracker  *EntityTracker
+	playerData     *PlayerDataStore
+	chatHandler    ChatHandler
+	chunkGenerator chunk.Generator
+	chunkStorage   *anvil.Storage
+	chunkStore     *ChunkStore
+	palette        *WorldPalette
+	lightEngine    *light.Engine
+	lightMutex     sync.Mutex
 	serverListener net.Listener
 }
 
@@ -27,20 +56,62 @@ func NewWorld(settings *Settings) (*World, error) {
 		return nil, err
 	}
 
-	world := &World{
-		data:        data,
-		server:      server,
-		settings:    settings,
-		playerList:  NewPlayerList(),
-		entityStore: NewEntityStore(),
+	world, err := newWorld(settings, data)
+	if err != nil {
+		return nil, err
 	}
 
+	world.server = server
 	world.backgroundJob = NewBackgroundJob(world)
 	world.backgroundJob.Start()
 
 	return world, nil
 }
 
+// newWorld opens the world without accepting connections or running background jobs.
+func newWorld(settings *Settings, data *Data) (*World, error) {
+	world := &World{
+		data:          data,
+		settings:      settings,
+		playerList:    NewPlayerList(),
+		entityStore:   NewEntityStore(),
+		entityTracker: NewEntityTracker(),
+		playerData:    NewPlayerDataStore(),
+		palette:       NewWorldPalette(data),
+	}
+
+	seed, random := ParseSeed(settings.LevelSeed)
+	if random {
+		log.Printf("using random level seed: %d\n", seed)
+	}
+
+	data.HashedSeed = HashSeed(seed)
+	data.IsFlat = settings.LevelType == LevelTypeFlat
+	data.GameMode, _ = ParseGameMode(settings.GameMode)
+
+	var err error
+	world.chunkGenerator, err = newChunkGenerator(settings, data, world.palette)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create world generator: %v", err)
+	}
+
+	regionDirectory := filepath.Join(settings.WorldDirectory, "region")
+	if _, err := os.Stat(regionDirectory); os.IsNotExist(err) {
+		log.Printf("generating new world in %s\n", regionDirectory)
+	} else {
+		log.Printf("loading world from %s\n", regionDirectory)
+	}
+
+	world.chunkStorage = anvil.OpenStorage(regionDirectory)
+	world.chunkStore = NewChunkStore(world.loadChunk)
+	world.lightEngine = light.NewEngine(world.palette, func(x, z int) *chunk.Chunk {
+		return world.chunkStore.Peek(ChunkPosition{X: x, Z: z})
+	})
+	world.placeSpawn()
+
+	return world, nil
+}
+
 func (w *World) Settings() *Settings {
 	return w.settings
 }
@@ -53,17 +124,37 @@ func (w *World) Server() *Server {
 	return w.server
 }
 
+func (w *World) PlayerData() *PlayerDataStore {
+	return w.playerData
+}
+
+func (w *World) Palette() *WorldPalette {
+	return w.palette
+}
+
 func (w *World) PlayerList() *PlayerList {
 	return w.playerList
 }
 
+func (w *World) Entities() *EntityStore {
+	return w.entityStore
+}
+
+func (w *World) EntityTracker() *EntityTracker {
+	return w.entityTracker
+}
+
 func (w *World) JoinPlayer(player *Player) {
 	w.PlayerList().RegisterPlayer(player)
+
+	if !w.entityStore.Add(player) {
+		log.Printf("Failed to register player %s as an entity: duplicated ID or UUID\n", player.Name)
+	}
 }
 
 func (w *World) RemovePlayer(player *Player) {
 	w.PlayerList().UnregisterPlayer(player)
-	w.entityStore.RemoveID(player.EntityID)
+	w.entityStore.Remove(player)
 }
 
 func (w *World) GetStatus() *ServerStatus {
@@ -115,6 +206,448 @@ func (w *World) BroadcastPlayerDisconnected(player *Player) {
 	})
 }
 
+// RegisterRecipe adds a custom recipe, sends it to all players and unlocks it in their recipe books.
+func (w *World) RegisterRecipe(recipe *recipes.Recipe) error {
+	err := w.data.Recipes.Register(recipe)
+	if err != nil {
+		return err
+	}
+
+	all := w.data.Recipes.All()
+	w.PlayerList().All(func(p *Player) {
+		p.SendRecipes(all)
+		p.UnlockRecipes([]string{recipe.ID})
+	})
+
+	return nil
+}
+
+// OnChat sets the handler which can change or cancel chat messages before they are broadcast.
+func (w *World) OnChat(handler ChatHandler) {
+	w.chatHandler = handler
+}
+
+// BroadcastChatMessage sends the message to all players who have chat enabled. Signed messages are sent as player
+// chat messages, which clients can verify, with content changed by the chat handler shown in place of the signed one.
+// Messages without a signature are sent as system messages.
+func (w *World) BroadcastChatMessage(message *PlayerChatMessage) {
+	content := message.SignedContent()
+	if w.chatHandler != nil {
+		var ok bool
+		content, ok = w.chatHandler(message)
+		if !ok {
+			return
+		}
+	}
+
+	if len(message.Signature) == 0 {
+		// same format as the decoration of minecraft:chat
+		formatted := NewChatMessage("<").
+			Append(message.Sender.DisplayName).
+			Append(NewChatMessage("> ")).
+			Append(content)
+
+		w.PlayerList().All(func(p *Player) {
+			if p.ChatMode() == ChatModeEnabled {
+				p.SendSystemChatMessage(formatted)
+			}
+		})
+		return
+	}
+
+	chatType, ok := w.data.DimensionCodec.ChatType.ID("minecraft:chat")
+	if !ok {
+		log.Println("Failed to broadcast chat message: minecraft:chat type is missing")
+		return
+	}
+
+	var unsignedContent *ChatMessage
+	if content.Encode() != message.SignedContent().Encode() {
+		unsignedContent = content
+	}
+
+	w.PlayerList().All(func(p *Player) {
+		if p.ChatMode() == ChatModeEnabled {
+			p.SendPlayerChatMessage(message, unsignedContent, chatType)
+		}
+	})
+}
+
 func (w *World) GenerateEntityID() int32 {
-	return w.entityStore.GenerateID()
+	return w.entityStore.AllocateID()
+}
+
+func (w *World) GetChunk(position ChunkPosition) *chunk.Chunk {
+	c := w.chunkStore.Get(position)
+	w.lightChunk(position, c)
+	return c
+}
+
+// ChunkLight returns a copy of the chunk's light, safe to be read while the light engine is running.
+func (w *World) ChunkLight(c *chunk.Chunk) *chunk.Light {
+	w.lightMutex.Lock()
+	defer w.lightMutex.Unlock()
+
+	if c.Light == nil {
+		return nil
+	}
+
+	return c.Light.Clone()
+}
+
+// GetBlock returns the block state at given absolute position, loading the chunk if needed.
+func (w *World) GetBlock(x, y, z int) int {
+	return w.chunkStore.GetBlock(x, y, z)
+}
+
+// SetBlock replaces the block state at given absolute position and sends the change to every player
+// who has the chunk loaded. It returns false if the position is outside the world.
+func (w *World) SetBlock(x, y, z int, state int) bool {
+	if y < chunk.MinY || y >= chunk.MinY+chunk.ChunkHeight {
+		return false
+	}
+
+	w.SetBlocks([]BlockChange{{X: x, Y: y, Z: z, State: state}})
+	return true
+}
+
+// SetBlocks applies many changes at once. Heightmaps and light are updated, and players receive the changes
+// batched per chunk section. Changes outside the world are skipped. It returns the number of modified blocks.
+func (w *World) SetBlocks(changes []BlockChange) int {
+	// chunks have to be lit before they are modified
+	loaded := make(map[ChunkPosition]struct{})
+	for _, change := range changes {
+		position := ChunkPosition{X: change.X >> 4, Z: change.Z >> 4}
+		if _, ok := loaded[position]; !ok {
+			w.GetChunk(position)
+			loaded[position] = struct{}{}
+		}
+	}
+
+	applied := w.chunkStore.SetBlocks(changes, w.palette)
+	if len(applied) == 0 {
+		return 0
+	}
+
+	w.broadcastBlockChanges(applied)
+
+	positions := make([]types.Position, len(applied))
+	for i, change := range applied {
+		positions[i] = types.Position{X: change.X, Y: change.Y, Z: change.Z}
+	}
+	w.updateLight(positions)
+
+	return len(applied)
+}
+
+// Fill sets all blocks of the box between given corners (inclusive) to the state.
+// It returns the number of modified blocks.
+func (w *World) Fill(x1, y1, z1, x2, y2, z2 int, state int) (int, error) {
+	if x1 > x2 {
+		x1, x2 = x2, x1
+	}
+	if y1 > y2 {
+		y1, y2 = y2, y1
+	}
+	if z1 > z2 {
+		z1, z2 = z2, z1
+	}
+
+	if y1 < chunk.MinY {
+		y1 = chunk.MinY
+	}
+	if y2 >= chunk.MinY+chunk.ChunkHeight {
+		y2 = chunk.MinY + chunk.ChunkHeight - 1
+	}
+	if y1 > y2 {
+		return 0, nil
+	}
+
+	volume := (x2 - x1 + 1) * (y2 - y1 + 1) * (z2 - z1 + 1)
+	if volume > MaxFillVolume {
+		return 0, fmt.Errorf("too many blocks in the filled area: %d, at most %d allowed", volume, MaxFillVolume)
+	}
+
+	changes := make([]BlockChange, 0, volume)
+	for x := x1; x <= x2; x++ {
+		for z := z1; z <= z2; z++ {
+			for y := y1; y <= y2; y++ {
+				changes = append(changes, BlockChange{X: x, Y: y, Z: z, State: state})
+			}
+		}
+	}
+
+	return w.SetBlocks(changes), nil
+}
+
+// GetBlockEntity returns a copy of the block entity at given absolute position, or nil if there is none.
+func (w *World) GetBlockEntity(x, y, z int) *chunk.BlockEntity {
+	return w.chunkStore.GetBlockEntity(x, y, z)
+}
+
+// SetBlockEntityData replaces data of the block entity at given absolute position and sends it to every player
+// who has the chunk loaded. Data is an NBT compound without the id and coordinates. It returns false
+// if the block doesn't keep a block entity or its chunk is read-only.
+func (w *World) SetBlockEntityData(x, y, z int, data nbt.RawMessage) bool {
+	blockEntity := w.chunkStore.SetBlockEntityData(x, y, z, data)
+	if blockEntity == nil {
+		return false
+	}
+
+	w.entityStore.ViewersOf(ChunkPosition{X: x >> 4, Z: z >> 4}, func(p *Player) {
+		p.SendBlockEntityData(x, y, z, blockEntity)
+	})
+
+	return true
+}
+
+// ViewChunks calls the function while no blocks are being modified, so blocks of loaded chunks can be read directly.
+func (w *World) ViewChunks(view func()) {
+	w.chunkStore.View(view)
+}
+
+// IsOccupiedByPlayer tells whether any player's bounding box intersects the block at given position.
+func (w *World) IsOccupiedByPlayer(x, y, z int) (occupied bool) {
+	w.entityStore.InRadius(float64(x)+0.5, float64(y)+0.5, float64(z)+0.5, PlayerHeight+1, func(entity Entity) {
+		p, ok := entity.(*Player)
+		if !ok {
+			return
+		}
+
+		px, py, pz := p.Position()
+		if px+PlayerWidth/2 > float64(x) && px-PlayerWidth/2 < float64(x+1) &&
+			py+PlayerHeight > float64(y) && py < float64(y+1) &&
+			pz+PlayerWidth/2 > float64(z) && pz-PlayerWidth/2 < float64(z+1) {
+			occupied = true
+		}
+	})
+
+	return
+}
+
+// HasLineOfSight tells whether the segment between two points doesn't pass through any solid block.
+func (w *World) HasLineOfSight(x1, y1, z1, x2, y2, z2 float64) bool {
+	dx, dy, dz := x2-x1, y2-y1, z2-z1
+	steps := int(math.Ceil(math.Sqrt(dx*dx+dy*dy+dz*dz) / lineOfSightStep))
+
+	var last types.Position
+	for i := 0; i <= steps; i++ {
+		t := 1.0
+		if steps > 0 {
+			t = float64(i) / float64(steps)
+		}
+
+		block := types.Position{
+			X: int(math.Floor(x1 + dx*t)),
+			Y: int(math.Floor(y1 + dy*t)),
+			Z: int(math.Floor(z1 + dz*t)),
+		}
+		if i > 0 && block == last {
+			continue
+		}
+		last = block
+
+		if w.palette.IsSolid(w.GetBlock(block.X, block.Y, block.Z)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// UpdateLight recalculates light around the block at given position after its state has changed,
+// and sends the changed light to players viewing affected chunks.
+func (w *World) UpdateLight(x, y, z int) {
+	w.updateLight([]types.Position{{X: x, Y: y, Z: z}})
+}
+
+func (w *World) MarkChunkDirty(position ChunkPosition) {
+	w.chunkStore.MarkDirty(position)
+}
+
+func (w *World) Save() error {
+	dirty := w.chunkStore.TakeDirty()
+	if len(dirty) == 0 {
+		return nil
+	}
+
+	chunks := make([]*anvil.ChunkData, 0, len(dirty))
+	w.chunkStore.View(func() {
+		for position, c := range dirty {
+			chunks = append(chunks, anvil.NewChunkData(position.X, position.Z, c, w.palette))
+		}
+	})
+
+	err := w.chunkStorage.SaveChunks(chunks)
+	if err != nil {
+		for position := range dirty {
+			w.chunkStore.MarkDirty(position)
+		}
+
+		return err
+	}
+
+	log.Printf("saved %d chunks\n", len(chunks))
+	return nil
+}
+
+func (w *World) UnloadUnusedChunks() {
+	w.chunkStore.Unload(w.entityStore.IsViewed)
+}
+
+func (w *World) Shutdown() {
+	err := w.Save()
+	if err != nil {
+		log.Printf("Failed to save world: %v\n", err)
+	}
+
+	_ = w.chunkStorage.Close()
+}
+
+// loadChunk reads the chunk from the region files, or generates it if it has never been saved. Chunks which
+// can't be read are replaced with generated read-only placeholders, so that saving doesn't overwrite them.
+func (w *World) loadChunk(position ChunkPosition) (*chunk.Chunk, ChunkSource) {
+	c, err := w.chunkStorage.LoadChunk(position.X, position.Z, w.palette)
+	source := ChunkSourceStorage
+
+	if err != nil {
+		source = ChunkSourceGenerator
+		if !errors.Is(err, anvil.ErrChunkNotFound) {
+			log.Printf("Failed to load chunk (%d, %d), it won't be modified or saved: %v\n", position.X, position.Z, err)
+			source = ChunkSourcePlaceholder
+		}
+
+		c = w.chunkGenerator.GenerateChunk(position.X, position.Z)
+	}
+
+	c.Heightmaps = chunk.ComputeHeightmaps(c, w.palette.IsMotionBlocking)
+	return c, source
+}
+
+// placeSpawn moves the spawn point on top of the highest block in its column.
+func (w *World) placeSpawn() {
+	spawn := w.data.SpawnPosition
+	position := ChunkPositionAt(float64(spawn.X), float64(spawn.Z))
+	c := w.GetChunk(position)
+
+	y := c.HighestBlock(spawn.X&(chunk.ChunkSize-1), spawn.Z&(chunk.ChunkSize-1))
+	spawn.Y = y + 1
+}
+
+func newChunkGenerator(settings *Settings, data *Data, palette generator.Palette) (chunk.Generator, error) {
+	switch settings.LevelType {
+	case LevelTypeFlat:
+		preset, err := generator.ParseFlatPreset(settings.GeneratorSettings)
+		if err != nil {
+			return nil, err
+		}
+
+		return generator.NewFlatGenerator(preset, palette)
+	case LevelTypeNormal:
+		biomes := make([]generator.Biome, len(data.DimensionCodec.WorldGenBiome.Value))
+		for i, biome := range data.DimensionCodec.WorldGenBiome.Value {
+			biomes[i] = generator.Biome{
+				Name:        biome.Name,
+				ID:          int(biome.ID),
+				Temperature: biome.Element.Temperature,
+				Downfall:    biome.Element.Downfall,
+			}
+		}
+
+		return generator.NewTerrainGenerator(data.HashedSeed, biomes, palette)
+	default:
+		return nil, fmt.Errorf("unsupported level type: %s", settings.LevelType)
+	}
+}
+
+type lightUpdate struct {
+	position ChunkPosition
+	light    *chunk.Light
+	sections uint32
+}
+
+func (w *World) lightChunk(position ChunkPosition, c *chunk.Chunk) {
+	var updates []*lightUpdate
+
+	w.chunkStore.View(func() {
+		w.lightMutex.Lock()
+		defer w.lightMutex.Unlock()
+
+		if c.Light != nil {
+			return
+		}
+
+		changes := w.lightEngine.LightChunk(position.X, position.Z, c)
+		delete(changes, light.ChunkPosition{X: position.X, Z: position.Z})
+		updates = w.collectLightUpdates(changes)
+	})
+
+	// light of the chunk has spread to its neighbours, which may have already been sent
+	w.broadcastLightUpdates(updates)
+}
+
+func (w *World) updateLight(positions []types.Position) {
+	var updates []*lightUpdate
+
+	w.chunkStore.View(func() {
+		w.lightMutex.Lock()
+		defer w.lightMutex.Unlock()
+
+		changes := w.lightEngine.UpdateBlocks(positions)
+		updates = w.collectLightUpdates(changes)
+	})
+
+	w.broadcastLightUpdates(updates)
+}
+
+func (w *World) collectLightUpdates(changes light.Changes) []*lightUpdate {
+	updates := make([]*lightUpdate, 0, len(changes))
+
+	for position, sections := range changes {
+		c := w.chunkStore.Peek(ChunkPosition{X: position.X, Z: position.Z})
+		if c == nil || c.Light == nil {
+			continue
+		}
+
+		updates = append(updates, &lightUpdate{
+			position: ChunkPosition{X: position.X, Z: position.Z},
+			light:    c.Light.Clone(),
+			sections: sections,
+		})
+	}
+
+	return updates
+}
+
+func (w *World) broadcastLightUpdates(updates []*lightUpdate) {
+	if len(updates) == 0 {
+		return
+	}
+
+	for _, update := range updates {
+		w.entityStore.ViewersOf(update.position, func(p *Player) {
+			p.SendUpdateLight(update.position, update.light, update.sections)
+		})
+	}
+}
+
+// broadcastBlockChanges sends the changes to players who have the chunks loaded, as a single Block Update
+// or batched into Update Section Blocks per chunk section.
+func (w *World) broadcastBlockChanges(changes []BlockChange) {
+	sections := make(map[SectionPosition][]BlockChange)
+	for _, change := range changes {
+		position := SectionPosition{X: change.X >> 4, Y: change.Y >> 4, Z: change.Z >> 4}
+		sections[position] = append(sections[position], change)
+	}
+
+	for position, sectionChanges := range sections {
+		w.entityStore.ViewersOf(ChunkPosition{X: position.X, Z: position.Z}, func(p *Player) {
+			if len(sectionChanges) == 1 {
+				change := sectionChanges[0]
+				p.SendBlockUpdate(change.X, change.Y, change.Z, change.State)
+			} else {
+				p.SendSectionBlocksUpdate(position, sectionChanges)
+			}
+		})
+	}
 }
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"github.com/mkorman9/go-minecraft-server/blocks"
+	"github.com/mkorman9/go-minecraft-server/items"
+)
+
+// WorldPalette translates between protocol IDs and names of blocks and biomes, and exposes block properties
+// needed by the light engine and heightmaps.
+type WorldPalette struct {
+	blocks     *blocks.Registry
+	items      *items.Registry
+	biomes     map[string]int
+	biomeNames map[int]string
+}
+
+func NewWorldPalette(data *Data) *WorldPalette {
+	biomes := make(map[string]int)
+	biomeNames := make(map[int]string)
+	for _, biome := range data.DimensionCodec.WorldGenBiome.Value {
+		biomes[biome.Name] = int(biome.ID)
+		biomeNames[int(biome.ID)] = biome.Name
+	}
+
+	return &WorldPalette{
+		blocks:     data.Blocks,
+		items:      data.Items,
+		biomes:     biomes,
+		biomeNames: biomeNames,
+	}
+}
+
+func (wp *WorldPalette) BlockStateID(name string, properties map[string]string) (int, bool) {
+	return wp.blocks.StateID(name, properties)
+}
+
+func (wp *WorldPalette) BiomeID(name string) (int, bool) {
+	id, ok := wp.biomes[name]
+	return id, ok
+}
+
+func (wp *WorldPalette) BlockStateName(id int) (string, map[string]string, bool) {
+	state, ok := wp.blocks.State(id)
+	if !ok {
+		return "", nil, false
+	}
+
+	return state.Block.Name, state.Properties, true
+}
+
+func (wp *WorldPalette) BiomeName(id int) (string, bool) {
+	name, ok := wp.biomeNames[id]
+	return name, ok
+}
+
+func (wp *WorldPalette) Opacity(state int) int {
+	return wp.blocks.Opacity(state)
+}
+
+func (wp *WorldPalette) Emission(state int) int {
+	return wp.blocks.Emission(state)
+}
+
+func (wp *WorldPalette) IsMotionBlocking(state int) bool {
+	return wp.blocks.IsMotionBlocking(state)
+}
+
+func (wp *WorldPalette) BlockState(id int) (*blocks.State, bool) {
+	return wp.blocks.State(id)
+}
+
+func (wp *WorldPalette) IsReplaceable(state int) bool {
+	return wp.blocks.IsReplaceable(state)
+}
+
+func (wp *WorldPalette) IsSolid(state int) bool {
+	return wp.blocks.IsSolid(state)
+}
+
+func (wp *WorldPalette) BlockEntityType(state int) (int, bool) {
+	return wp.blocks.BlockEntityType(state)
+}
+
+// ItemBlock returns the block placed by the item with given ID.
+func (wp *WorldPalette) ItemBlock(itemID int) (*blocks.Block, bool) {
+	item, ok := wp.items.Item(itemID)
+	if !ok || item.Block == "" {
+		return nil, false
+	}
+
+	return wp.blocks.Block(item.Block)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"github.com/mkorman9/go-minecraft-server/anvil"
+	"github.com/mkorman9/go-minecraft-server/chunk"
+	"github.com/mkorman9/go-minecraft-server/nbt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnreadableChunkIsReadOnly(t *testing.T) {
+	directory := t.TempDir()
+	regionDirectory := filepath.Join(directory, "region")
+	position := ChunkPosition{X: 5, Z: 5}
+
+	// a chunk saved by a newer version, with a block unknown to the server
+	storage := anvil.OpenStorage(regionDirectory)
+	err := storage.SaveChunks([]*anvil.ChunkData{{
+		DataVersion: anvil.DataVersion + 1,
+		XPos:        int32(position.X),
+		ZPos:        int32(position.Z),
+		Status:      "full",
+		Sections: []anvil.SectionData{{
+			Y: 0,
+			BlockStates: anvil.BlockStatesData{
+				Palette: []anvil.BlockStateData{{Name: "minecraft:air"}, {Name: "minecraft:unknown_block"}},
+				Data:    make([]int64, chunk.BlocksPerSection/16),
+			},
+			Biomes: anvil.BiomesData{Palette: []string{"minecraft:plains"}},
+		}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = storage.Close()
+
+	regionPath := filepath.Join(regionDirectory, anvil.RegionFileName(0, 0))
+	saved := readRegionChunk(t, regionPath, position)
+
+	world := openTestWorld(t, directory)
+	defer world.chunkStorage.Close()
+
+	x, y, z := position.X*chunk.ChunkSize, 0, position.Z*chunk.ChunkSize
+	state := world.GetBlock(x, y, z)
+	world.SetBlock(x, y, z, testState(t, world, "stone"))
+
+	if world.GetBlock(x, y, z) != state {
+		t.Fatalf("expected the chunk not to be modified")
+	}
+
+	// chunks generated around the spawn are saved, the unreadable one is left untouched
+	err = world.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(readRegionChunk(t, regionPath, position), saved) {
+		t.Fatalf("expected the unreadable chunk to stay untouched")
+	}
+}
+
+func readRegionChunk(t *testing.T, path string, position ChunkPosition) []byte {
+	t.Helper()
+
+	region, err := anvil.OpenRegion(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer region.Close()
+
+	data, err := region.ReadChunk(position.X, position.Z)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestVanillaBlockEntities(t *testing.T) {
+	directory := t.TempDir()
+	copyVanillaRegion(t, filepath.Join(directory, "region"))
+
+	world := openTestWorld(t, directory)
+
+	// a chest generated by the vanilla server in a dungeon of chunk (8, 8)
+	chestX, chestY, chestZ := 139, -54, 143
+	if entityType, ok := world.Data().Blocks.BlockEntityType(world.GetBlock(chestX, chestY, chestZ)); !ok || entityType != 1 {
+		t.Fatalf("expected a chest, got block entity type %d", entityType)
+	}
+
+	signX, signY, signZ := 136, 100, 140
+	if !world.SetBlock(signX, signY, signZ, testState(t, world, "oak_sign[rotation=4]")) {
+		t.Fatalf("expected the sign to be placed")
+	}
+
+	encoded, err := nbt.Marshal(map[string]any{"Text1": `{"text":"Hello"}`, "Color": "black"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var signData nbt.RawMessage
+	err = nbt.Unmarshal(encoded, &signData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !world.SetBlockEntityData(signX, signY, signZ, signData) {
+		t.Fatalf("expected the sign data to be set")
+	}
+
+	err = world.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = world.chunkStorage.Close()
+
+	world = openTestWorld(t, directory)
+	defer world.chunkStorage.Close()
+
+	cases := []struct {
+		name       string
+		x, y, z    int
+		entityType int
+		field      string
+		expected   any
+	}{
+		{name: "chest", x: chestX, y: chestY, z: chestZ, entityType: 1, field: "LootTable", expected: "minecraft:chests/simple_dungeon"},
+		{name: "sign", x: signX, y: signY, z: signZ, entityType: 7, field: "Text1", expected: `{"text":"Hello"}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			blockEntity := world.GetBlockEntity(c.x, c.y, c.z)
+			if blockEntity == nil || blockEntity.Type != c.entityType {
+				t.Fatalf("expected block entity of type %d, got %+v", c.entityType, blockEntity)
+			}
+
+			var fields map[string]any
+			err := blockEntity.Data.Unmarshal(&fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fields[c.field] != c.expected {
+				t.Fatalf("expected %s to be %v, got %v", c.field, c.expected, fields[c.field])
+			}
+		})
+	}
+}
+
+// copyVanillaRegion copies the region file saved by the vanilla server from the anvil test data.
+func copyVanillaRegion(t *testing.T, regionDirectory string) {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("anvil", "testdata", anvil.RegionFileName(0, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.MkdirAll(regionDirectory, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(regionDirectory, anvil.RegionFileName(0, 0)), data, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}